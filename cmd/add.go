@@ -23,6 +23,7 @@ var (
 	modProperties  []string
 	modMetadata    []string
 	modResolveDeps bool
+	modDeprecated  string
 	writeInPlace   bool
 
 	addCmd = &cobra.Command{
@@ -48,7 +49,7 @@ var (
 			if err != nil {
 				logging.Errorln(err)
 			}
-			newModReq, err = createNewModuleRequest(args)
+			newModReq, err = createNewModuleRequest(args, puppetfile)
 			if err != nil {
 				logging.Errorln("Failed to create new module request with error:", err)
 			}
@@ -120,6 +121,8 @@ func init() {
 	addModuleCmd.Flags().StringSliceVarP(&modMetadata, "metadata", "m", []string{}, "comma-separated list of metadata (Ex. -m '# @maintainer: team@fake.com','# @critical:')")
 	addModuleCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the current Puppetfile with changes")
 	viper.BindPFlag("always.write_in_place", addModuleCmd.Flags().Lookup("write-in-place"))
+	addModuleCmd.Flags().StringVar(&modDeprecated, "deprecated", pconf.ForgeDeprecatedModules, "what to do when adding a deprecated Forge module, either warn or refuse")
+	viper.BindPFlag("forge.deprecated_modules", addModuleCmd.Flags().Lookup("deprecated"))
 
 	addCmd.AddCommand(addMetaCmd)
 	addMetaCmd.Flags().StringSliceVarP(&modMetadata, "metadata", "m", []string{}, "comma-separated list of metadata (Ex. -m '# @maintainer: team@fake.com','# @critical:')")
@@ -173,6 +176,11 @@ func addModulePreRun() {
 	} else {
 		logging.Errorln("You must specify --show (-s), --write-in-place (-w), or --out-file (-o)")
 	}
+	switch viper.GetString("forge.deprecated_modules") {
+	case "warn", "refuse":
+	default:
+		logging.Errorf("Invalid deprecated modules policy %q, must be warn or refuse\n", viper.GetString("forge.deprecated_modules"))
+	}
 	parseOpts = helpers.ParseOptions{
 		Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
 		GitRef:   viper.GetString("puppetfile_branch"),
@@ -212,7 +220,7 @@ func addMetaPreRun(args []string) {
 	}
 }
 
-func createNewModuleRequest(args []string) (*newModuleRequest, error) {
+func createNewModuleRequest(args []string, puppetfile *ast.Puppetfile) (*newModuleRequest, error) {
 	var newModReq *newModuleRequest
 	var normalized string
 	isGit := validators.IsGitURL(args[0])
@@ -251,7 +259,7 @@ func createNewModuleRequest(args []string) (*newModuleRequest, error) {
 		newModReq = &newModuleRequest{
 			Input:     args[0],
 			Slug:      normalized,
-			URL:       viper.GetString("forge.api_url"),
+			URL:       forgeAPIURL(puppetfile),
 			UserAgent: viper.GetString("forge.user_agent"),
 			Mod:       Mod{Props: modProperties, Meta: modMetadata},
 			AddFunc:   forgeAddModule,
@@ -269,6 +277,12 @@ func forgeAddModule(mod newModuleRequest, puppetfile *ast.Puppetfile, resolveDep
 		logging.Errorln(err)
 	}
 	logging.Debugln("Successfully retrieved module from Puppet Forge")
+	if fm.Deprecated() {
+		if viper.GetString("forge.deprecated_modules") == "refuse" {
+			return changes, fmt.Errorf("%s Use --deprecated warn to add it anyways", fm.DeprecationMessage())
+		}
+		logging.Warnln(fm.DeprecationMessage())
+	}
 	p := mod.GetMod().Props
 	p = append(p, fm.CurrentRelease.Version)
 	for _, prop := range p {
@@ -309,7 +323,7 @@ func gitAddModule(mod newModuleRequest, puppetfile *ast.Puppetfile, resolveDeps
 		source = fmt.Sprintf("%s.git", source)
 		logging.Debugln("Proceeding with modified source", source)
 	}
-	slug := strings.Replace(meta.Name, "/", "-", 1)
+	slug := ast.NormalizeName(meta.Name)
 	logging.Debugln("Proceeding with slug", slug)
 	p := mod.GetMod().Props
 	if len(p) < 1 {
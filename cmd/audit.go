@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	pconf "github.com/hsnodgrass/pufctl/internal/config"
+	"github.com/hsnodgrass/pufctl/internal/convert"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/forgesource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+)
+
+var (
+	auditRewrite bool
+
+	auditCmd = &cobra.Command{
+		Use:   uitext.AuditUse,
+		Short: uitext.AuditShort,
+		Long:  uitext.AuditLong,
+	}
+
+	auditDeprecatedCmd = &cobra.Command{
+		Use:   uitext.AuditDeprecatedUse,
+		Short: uitext.AuditDeprecatedShort,
+		Long:  uitext.AuditDeprecatedLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+			if auditRewrite {
+				_s := helpers.MaxBools(show, viper.GetBool("always.show"))
+				_w := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+				if !_s && !_w && outFile == "" {
+					logging.Errorln("You must specify --show (-s), --write-in-place (-w), or --out-file (-o) with --rewrite")
+				}
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			_confirm := helpers.MaxBools(confirm, viper.GetBool("always.confirm"))
+			_show := helpers.MaxBools(show, viper.GetBool("always.show"))
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			url := forgeAPIURL(puppetfile)
+			agent := viper.GetString("forge.user_agent")
+			mods := forgesource.GetModules(convert.ForgeSlugs(puppetfile), url, agent)
+			deprecated := convert.DeprecatedModules(puppetfile, mods)
+			if len(deprecated) < 1 {
+				fmt.Printf("No deprecated modules in %s\n", pfilePath)
+				return
+			}
+			if auditRewrite {
+				latest := func(slug string) (string, error) {
+					m, err := forgesource.GetModule(slug, url, agent)
+					return m.CurrentRelease.Version, err
+				}
+				rewrites, err := convert.RewriteDeprecated(puppetfile, deprecated, latest)
+				if err != nil {
+					logging.Errorln("Failed to rewrite deprecated modules with error:", err)
+				}
+				for _, r := range rewrites {
+					switch {
+					case r.Removed:
+						fmt.Printf("Removed %s, %s is already in the Puppetfile\n", r.Name, r.Replacement)
+					case r.Version != "":
+						fmt.Printf("Replaced %s with %s %s\n", r.Name, r.Replacement, r.Version)
+					default:
+						fmt.Printf("Replaced %s with %s\n", r.Name, r.Replacement)
+					}
+				}
+				editOutput(_show, _writeInPlace, _confirm, len(rewrites) > 0, pfilePath, outFile, puppetfile)
+				return
+			}
+			fmt.Printf("Deprecated modules in %s\n%s\n", pfilePath, uitext.DashSep)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "MODULE\tVERSION\tDEPRECATED\tREASON\tREPLACEMENT")
+			for _, d := range deprecated {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Name, orNone(d.Version), d.DeprecatedAt, orNone(d.Reason), orNone(d.Replacement))
+			}
+			w.Flush()
+			os.Exit(2)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.AddCommand(auditDeprecatedCmd)
+	auditDeprecatedCmd.Flags().BoolVar(&auditRewrite, "rewrite", false, "replace deprecated modules with the modules that supersede them")
+	auditDeprecatedCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the current Puppetfile with changes")
+	viper.BindPFlag("always.write_in_place", auditDeprecatedCmd.Flags().Lookup("write-in-place"))
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	pconf "github.com/hsnodgrass/pufctl/internal/config"
+	"github.com/hsnodgrass/pufctl/internal/convert"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+)
+
+var (
+	boltProjectPath string
+
+	boltCmd = &cobra.Command{
+		Use:   uitext.BoltUse,
+		Short: uitext.BoltShort,
+		Long:  uitext.BoltLong,
+	}
+
+	boltImportCmd = &cobra.Command{
+		Use:   uitext.BoltImportUse,
+		Short: uitext.BoltImportShort,
+		Long:  uitext.BoltImportLong,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			data, err := ioutil.ReadFile(boltProjectPath)
+			if err != nil {
+				logging.Errorf("Failed to read %s with error: %v\n", boltProjectPath, err)
+			}
+			mods, err := convert.ParseBoltProject(data)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			puppetfile, err := convert.BoltPuppetfile(mods, forgeVersionResolver(viper.GetString("forge.api_url")))
+			if err != nil {
+				logging.Errorln("Failed to generate Puppetfile with error:", err)
+			}
+			writeGenerated(puppetfile)
+		},
+	}
+
+	boltExportCmd = &cobra.Command{
+		Use:   uitext.BoltExportUse,
+		Short: uitext.BoltExportShort,
+		Long:  uitext.BoltExportLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			_confirm := helpers.MaxBools(confirm, viper.GetBool("always.confirm"))
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			puppetfile, err := helpers.Parse(viper.GetString("puppetfile"), parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			data, err := ioutil.ReadFile(boltProjectPath)
+			if err != nil && !os.IsNotExist(err) {
+				logging.Errorf("Failed to read %s with error: %v\n", boltProjectPath, err)
+			}
+			out, skipped, err := convert.ExportBoltProject(data, puppetfile)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			for _, s := range skipped {
+				logging.Warnf("Skipping module %s, Bolt only supports Forge modules and Git modules with a ref\n", s)
+			}
+			fileOutput(boltProjectPath, _writeInPlace, _confirm, !bytes.Equal(data, out), out)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(boltCmd)
+	boltCmd.AddCommand(boltImportCmd)
+	boltCmd.AddCommand(boltExportCmd)
+	boltCmd.PersistentFlags().StringVar(&boltProjectPath, "project", "bolt-project.yaml", "path to the bolt-project.yaml")
+	boltExportCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the bolt-project.yaml with changes")
+}
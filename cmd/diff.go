@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hsnodgrass/pufctl/internal/validators"
@@ -14,27 +15,40 @@ import (
 	"github.com/hsnodgrass/pufctl/internal/logging"
 	"github.com/hsnodgrass/pufctl/internal/uitext"
 	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
 )
 
 var (
-	diffMeta  bool
-	branchOne string
-	branchTwo string
+	diffMeta   bool
+	diffStaged bool
+	diffFormat string
+	branchOne  string
+	branchTwo  string
 
 	diffCmd = &cobra.Command{
 		Use:   uitext.DiffUse,
 		Short: uitext.DiffShort,
 		Long:  uitext.DiffLong,
-		Args:  cobra.MaximumNArgs(2),
+		Args:  cobra.ArbitraryArgs,
 		PreRun: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
+			if len(args) == 0 && !diffStaged {
 				cmd.Help()
 				logging.Errorln("At least one Puppetfile path is required")
 			}
+			if diffStaged && len(args) > 1 {
+				logging.Errorln("The --staged flag accepts at most one revision")
+			}
+			if diffStaged && len(args) == 1 && helpers.ValidatePath(args[0]) == nil {
+				logging.Errorf("The --staged flag compares revisions of the Puppetfile, but %s is a file", args[0])
+			}
+			if diffFormat != "" && !diff.ValidFormat(diffFormat) {
+				logging.Errorf("Output format %s is not valid, should be one of [%s]", diffFormat, strings.Join(diff.Formats, "|"))
+			}
+			if len(args) > 2 && diffFormat != "" && !validManyFormat(diffFormat) {
+				logging.Errorf("Output format %s is not valid with more than two Puppetfiles, should be one of [%s]", diffFormat, strings.Join(diff.ManyFormats, "|"))
+			}
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			var pOnePath string
-			var pTwoPath string
 			var pOne *ast.Puppetfile
 			var pTwo *ast.Puppetfile
 			var err error
@@ -42,123 +56,70 @@ var (
 			var exitCode int
 			var showPathOne string
 			var showPathTwo string
-			diffParseOptsOne := helpers.ParseOptions{
-				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
-				GitRef:   branchOne,
-				SSHKey:   viper.GetString("auth.ssh_key"),
-				Username: viper.GetString("auth.username"),
-				Password: viper.GetString("auth.password"),
-				Token:    viper.GetString("auth.token"),
-			}
-			diffParseOptsTwo := helpers.ParseOptions{
-				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
-				GitRef:   branchTwo,
-				SSHKey:   viper.GetString("auth.ssh_key"),
-				Username: viper.GetString("auth.username"),
-				Password: viper.GetString("auth.password"),
-				Token:    viper.GetString("auth.token"),
-			}
 			_show := helpers.MaxBools(show, viper.GetBool("always.show"))
-			if len(args) == 1 {
-				pOnePath = viper.GetString("puppetfile")
-				pTwoPath = args[0]
-				if validators.IsGitURL(pOnePath) || validators.IsGitURLNoSuffix(pOnePath) {
-					showPathOne = fmt.Sprintf("%s#%s", pOnePath, diffParseOptsOne.GitRef)
-				} else {
-					showPathOne = pOnePath
-				}
-				if validators.IsGitURL(pTwoPath) || validators.IsGitURLNoSuffix(pTwoPath) {
-					showPathTwo = fmt.Sprintf("%s#%s", pTwoPath, diffParseOptsTwo.GitRef)
-				} else {
-					showPathTwo = pTwoPath
-				}
-				pOne, err = helpers.Parse(pOnePath, diffParseOptsOne)
-				if err != nil {
-					logging.Errorln(fmt.Errorf("Failed to parse first Puppetfile %s with error: %w", showPathOne, err))
-				}
-				pTwo, err = helpers.Parse(pTwoPath, diffParseOptsTwo)
-				if err != nil {
-					logging.Errorf("Failed to parse second Puppetfile %s with error: %v", showPathTwo, err)
-				}
+			if len(args) > 2 {
+				os.Exit(manyDiff(args, _show))
+			}
+			if diffStaged || isRevisionDiff(args) {
+				pOne, pTwo, showPathOne, showPathTwo = parseRevisionDiff(args)
 			} else {
-				pOnePath = args[0]
-				pTwoPath = args[1]
-				if validators.IsGitURL(pOnePath) || validators.IsGitURLNoSuffix(pOnePath) {
-					showPathOne = fmt.Sprintf("%s#%s", pOnePath, diffParseOptsOne.GitRef)
-				} else {
-					showPathOne = pOnePath
+				argOne, argTwo := viper.GetString("puppetfile"), args[0]
+				if len(args) == 2 {
+					argOne, argTwo = args[0], args[1]
 				}
-				if validators.IsGitURL(pTwoPath) || validators.IsGitURLNoSuffix(pTwoPath) {
-					showPathTwo = fmt.Sprintf("%s#%s", pTwoPath, diffParseOptsTwo.GitRef)
-				} else {
-					showPathTwo = pTwoPath
-				}
-				pOne, err = helpers.Parse(pOnePath, diffParseOptsOne)
+				pOne, showPathOne, err = parseDiffSource(argOne, branchOne)
 				if err != nil {
-					logging.Errorln(fmt.Errorf("Failed to parse first Puppetfile %s with error: %w", showPathOne, err))
+					logging.Errorf("Failed to parse first Puppetfile %s with error: %v", showPathOne, err)
 				}
-				pTwo, err = helpers.Parse(pTwoPath, diffParseOptsTwo)
+				pTwo, showPathTwo, err = parseDiffSource(argTwo, branchTwo)
 				if err != nil {
-					logging.Errorln(fmt.Errorf("Failed to parse second Puppetfile %s with error: %w", showPathTwo, err))
+					logging.Errorf("Failed to parse second Puppetfile %s with error: %v", showPathTwo, err)
 				}
 			}
-			exOne, exTwo, diffResult := diffPuppetfiles(pOne, pTwo, diffMeta)
-			if len(exOne) == 0 && len(exTwo) == 0 && len(diffResult) == 0 {
+			result := diff.Puppetfiles(pOne, pTwo, diff.Options{Meta: diffMeta})
+			switch {
+			case result.Empty():
 				logging.Debugln("No difference between Puppetfiles")
-				outString = "No difference between Puppetfiles"
 				exitCode = 0
-			}
-			exStrParts := []string{fmt.Sprintf("\nExclusive Statements\n%s\n", uitext.DashSep)}
-			diffStrParts := []string{fmt.Sprintf("\n%s\nDiff Statements\n", uitext.DashSep)}
-			if len(exOne) > 0 {
-				exStrParts = append(exStrParts, fmt.Sprintf("Only found in %s\n%s", showPathOne, uitext.DashSep))
+			case len(result.Changed) == 0 && len(result.Added) == 0:
 				logging.Debugln("Found exclusives in first Puppetfile")
-				for _, d := range exOne {
-					exStrParts = append(exStrParts, fmt.Sprintf("Module: %s", d.Module.Name))
-				}
-				if len(exTwo) == 0 {
-					exitCode = 3
-				}
-			}
-			if len(exTwo) > 0 {
-				exStrParts = append(exStrParts, fmt.Sprintf("\n%s\nOnly found in %s\n%s", uitext.DashSep, showPathTwo, uitext.DashSep))
+				exitCode = 3
+			case len(result.Changed) == 0 && len(result.Removed) == 0:
 				logging.Debugln("Found exclusives in second Puppetfile")
-				for _, d := range exTwo {
-					exStrParts = append(exStrParts, fmt.Sprintf("Module: %s", d.Module.Name))
-				}
-				if len(exOne) == 0 {
-					exitCode = 4
-				}
-			}
-			if len(exOne) > 0 && len(exTwo) > 0 {
+				exitCode = 4
+			default:
 				exitCode = 5
 			}
-			if len(diffResult) > 0 {
-				for _, d := range diffResult {
-					diffStrParts = append(diffStrParts, fmt.Sprintf("%s\nMODULE: %s\n%s", uitext.DashSep, d.Name, uitext.DashSep))
-					diffStrParts = append(diffStrParts, fmt.Sprintf("SOURCE: %s\n%s", showPathOne, d.One))
-					diffStrParts = append(diffStrParts, fmt.Sprintf("SOURCE: %s\n%s", showPathTwo, d.Two))
+			switch diffFormat {
+			case "unified":
+				outString = diff.Unified(pOne, pTwo, showPathOne, showPathTwo, 3)
+			case "json":
+				out, err := result.JSON(showPathOne, showPathTwo)
+				if err != nil {
+					logging.Errorln("Failed to encode diff as JSON with error:", err)
 				}
-				exitCode = 5
-			}
-			if len(exStrParts) > 0 {
-				outString = strings.Join(exStrParts, "\n")
-			}
-			if len(diffStrParts) > 0 {
-				diffout := strings.Join(diffStrParts, "\n")
-				outString = fmt.Sprintf("%s\n%s", outString, diffout)
+				outString = fmt.Sprintf("%s\n", out)
+			case "markdown":
+				outString = result.Markdown(showPathOne, showPathTwo)
+			case "junit":
+				out, err := result.JUnit(showPathOne, showPathTwo)
+				if err != nil {
+					logging.Errorln("Failed to encode diff as JUnit XML with error:", err)
+				}
+				outString = fmt.Sprintf("%s\n", out)
+			default:
+				outString = result.Text(showPathOne, showPathTwo)
 			}
-			outString = fmt.Sprintf("%s\n%s\n", outString, uitext.DashSep)
 			if outFile != "" {
 				err = helpers.PromptConfirmFile(outFile, outString, confirm)
 				if err != nil {
 					logging.Errorln("Failed to write output to file with error:", err)
 				}
 			}
-			if _show {
-				fmt.Println(outString)
-			} else {
-				logging.Warnln("If you would like to see the diff, please use the flag --show (-s)")
+			if _show || diffFormat != "" {
+				fmt.Print(outString)
+			} else if outFile == "" {
+				logging.Warnln("If you would like to see the diff, please use the flag --show (-s) or --format (-f)")
 			}
 			os.Exit(exitCode)
 		},
@@ -167,133 +128,183 @@ var (
 
 func init() {
 	rootCmd.AddCommand(diffCmd)
-	diffCmd.Flags().BoolVar(&diffMeta, "diff-meta", false, "Include meta statements (comments, etc.) in diff")
+	diffCmd.Flags().BoolVar(&diffMeta, "diff-meta", false, "Include module meta tags in diff")
 	diffCmd.Flags().StringVar(&branchOne, "branch-one", "production", "The branch for the first Puppetfile (if using a Git source)")
 	diffCmd.Flags().StringVar(&branchTwo, "branch-two", "development", "The branch for the second Puppetfile (if using a Git source)")
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "", "output format, prints the diff without --show [text|unified|json|markdown|junit]")
+	diffCmd.Flags().BoolVar(&diffStaged, "staged", false, "Diff the staged Puppetfile against HEAD (or the given revision) of the local Git repository")
 }
 
-type modDiff struct {
-	Name string
-	One  string
-	Two  string
+func validManyFormat(format string) bool {
+	for _, f := range diff.ManyFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
 }
 
-func diffPuppetfiles(pOne, pTwo *ast.Puppetfile, diffMeta bool) ([]*ast.Statement, []*ast.Statement, []modDiff) {
-	// diffPuppetfiles returns the difference between two Puppetfiles
-	// If diffMeta is true, the diff will contain comments in the diff.
-	// The diff does not account for the ordering of the Statements in
-	// the Puppethelpers.
-	stmtsOne := []*ast.Statement{}
-	stmtsTwo := []*ast.Statement{}
-	if diffMeta {
-		logging.Debugln("Including metadata in diff")
-		stmtsOne = append(stmtsOne, pOne.Statements...)
-		stmtsTwo = append(stmtsTwo, pTwo.Statements...)
-	} else {
-		logging.Debugln("Only diffing Module statements")
-		for _, s := range pOne.Statements {
-			if s.Module != nil {
-				stmtsOne = append(stmtsOne, s)
-			}
+// manyDiff diffs more than two Puppetfiles, prints or writes the
+// consolidated report, and returns the exit code. The exit codes extend
+// the two Puppetfile exit codes: 3 if only the first Puppetfile has
+// modules the others are missing, 4 if only the other Puppetfiles have
+// modules the first is missing, and 5 for any other difference.
+func manyDiff(args []string, _show bool) int {
+	var names []string
+	var puppetfiles []*ast.Puppetfile
+	seen := map[string]bool{}
+	for _, arg := range args {
+		_, _, name := diffSource(arg, viper.GetString("puppetfile_branch"))
+		if seen[name] {
+			logging.Warnf("Skipping duplicate Puppetfile %s\n", name)
+			continue
 		}
-		for _, s := range pTwo.Statements {
-			if s.Module != nil {
-				stmtsTwo = append(stmtsTwo, s)
-			}
+		seen[name] = true
+		p, _, err := parseDiffSource(arg, viper.GetString("puppetfile_branch"))
+		if err != nil {
+			logging.Errorf("Failed to parse Puppetfile %s with error: %v", name, err)
 		}
+		names = append(names, name)
+		puppetfiles = append(puppetfiles, p)
 	}
-	targetOne := map[string]string{}
-	targetTwo := map[string]string{}
-	for _, s := range stmtsTwo {
-		// normalize the name before adding it
-		var name string
-		var err error
-		if s.Module != nil && validators.IsModuleRef(s.Module.Name) {
-			name, err = helpers.ModStringToSlug(s.Module.Name)
-			if err != nil {
-				logging.Errorf("Failed to normalized module name %s: %w", s.Module.Name, err)
-			}
-		} else if s.Module != nil {
-			name = s.Module.Name
-		} else {
-			name = "metadata"
-		}
-		logging.Debugf("Adding %s to target two map\n", name)
-		targetTwo[name] = s.Sprint()
+	result := diff.Many(names, puppetfiles)
+	var exitCode int
+	switch {
+	case result.Empty():
+		exitCode = 0
+	case result.Differing() == 0 && !result.ExclusiveOthers():
+		exitCode = 3
+	case result.Differing() == 0 && !result.ExclusiveFirst():
+		exitCode = 4
+	default:
+		exitCode = 5
 	}
-	for _, s := range stmtsOne {
-		// normalize the name before adding it
-		var name string
-		var err error
-		if s.Module != nil && validators.IsModuleRef(s.Module.Name) {
-			name, err = helpers.ModStringToSlug(s.Module.Name)
-			if err != nil {
-				logging.Errorf("Failed to normalized module name %s: %w", s.Module.Name, err)
-			}
-		} else if s.Module != nil {
-			name = s.Module.Name
-		} else {
-			name = "metadata"
+	var outString string
+	switch diffFormat {
+	case "json":
+		out, err := result.JSON()
+		if err != nil {
+			logging.Errorln("Failed to encode diff as JSON with error:", err)
 		}
-		logging.Debugf("Adding %s to target one map\n", name)
-		targetOne[name] = s.Sprint()
+		outString = fmt.Sprintf("%s\n", out)
+	case "markdown":
+		outString = result.Markdown()
+	default:
+		outString = result.Text()
 	}
-	diffResult := []modDiff{}
-	exclusiveOne := []*ast.Statement{}
-	exclusiveTwo := []*ast.Statement{}
-	for _, s := range stmtsOne {
-		// normalize the name
-		var name string
-		var err error
-		if s.Module != nil && validators.IsModuleRef(s.Module.Name) {
-			name, err = helpers.ModStringToSlug(s.Module.Name)
-			if err != nil {
-				logging.Errorf("Failed to normalized module name %s: %w", s.Module.Name, err)
-			}
-		} else if s.Module != nil {
-			name = s.Module.Name
-		} else {
-			name = "metadata"
-		}
-		val, ok := targetTwo[name]
-		if !ok {
-			exclusiveOne = append(exclusiveOne, s)
-		} else {
-			if val != targetOne[name] {
-				_diff := modDiff{
-					Name: name,
-					One:  targetOne[name],
-					Two:  val,
-				}
-				diffResult = append(diffResult, _diff)
-			}
+	if outFile != "" {
+		err := helpers.PromptConfirmFile(outFile, outString, confirm)
+		if err != nil {
+			logging.Errorln("Failed to write output to file with error:", err)
 		}
 	}
-	for _, s := range stmtsTwo {
-		// normalize the name
-		var name string
-		var err error
-		if s.Module != nil && validators.IsModuleRef(s.Module.Name) {
-			name, err = helpers.ModStringToSlug(s.Module.Name)
-			if err != nil {
-				logging.Errorf("Failed to normalized module name %s: %w", s.Module.Name, err)
-			}
-		} else if s.Module != nil {
-			name = s.Module.Name
-		} else {
-			name = "metadata"
-		}
-		if _, ok := targetOne[name]; !ok {
-			exclusiveTwo = append(exclusiveTwo, s)
+	if _show || diffFormat != "" {
+		fmt.Print(outString)
+	} else if outFile == "" {
+		logging.Warnln("If you would like to see the diff, please use the flag --show (-s) or --format (-f)")
+	}
+	return exitCode
+}
+
+// diffSource splits a Puppetfile argument into its path or Git URL, the
+// branch to use, and the name the Puppetfile is shown as. Git URLs can
+// include the branch after a "#", otherwise defaultRef is used.
+func diffSource(arg, defaultRef string) (string, string, string) {
+	path, ref := arg, defaultRef
+	if i := strings.LastIndex(arg, "#"); i > 0 {
+		url := arg[:i]
+		if validators.IsGitURL(url) || validators.IsGitURLNoSuffix(url) {
+			path, ref = url, arg[i+1:]
 		}
 	}
-	return exclusiveOne, exclusiveTwo, diffResult
+	if validators.IsGitURL(path) || validators.IsGitURLNoSuffix(path) {
+		return path, ref, fmt.Sprintf("%s#%s", path, ref)
+	}
+	return path, ref, path
+}
+
+// parseDiffSource parses a Puppetfile argument of the diff command, and
+// returns it with the name it is shown as. See diffSource for the forms of
+// the argument.
+func parseDiffSource(arg, defaultRef string) (*ast.Puppetfile, string, error) {
+	path, ref, name := diffSource(arg, defaultRef)
+	opts := helpers.ParseOptions{
+		Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+		GitRef:   ref,
+		SSHKey:   viper.GetString("auth.ssh_key"),
+		Username: viper.GetString("auth.username"),
+		Password: viper.GetString("auth.password"),
+		Token:    viper.GetString("auth.token"),
+	}
+	puppetfile, err := helpers.Parse(path, opts)
+	return puppetfile, name, err
+}
+
+// isRevisionDiff returns true if the diff args should be treated as
+// revisions of the local Git repository containing the configured
+// Puppetfile instead of paths to Puppetfiles.
+func isRevisionDiff(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	arg := args[0]
+	if validators.IsGitURL(arg) || validators.IsGitURLNoSuffix(arg) {
+		return false
+	}
+	if err := helpers.ValidatePath(arg); err == nil {
+		return false
+	}
+	if strings.Contains(arg, "..") {
+		return helpers.IsRevisionRange(viper.GetString("puppetfile"), arg)
+	}
+	return helpers.IsRevision(viper.GetString("puppetfile"), arg)
 }
 
-// ModuleDiff is used to hold the relevant diff properties of a Puppetfile
-// TODO: Actually implement this (more detailed diff reporting)
-type ModuleDiff struct {
-	Name       string
-	Properties []string
-	Puppetfile string
+// parseRevisionDiff parses the Puppetfiles to compare when diffing
+// revisions of a local Git repository. The supported forms mirror git diff:
+// <rev> compares a revision to the working tree, <rev>..<rev> compares two
+// revisions, and --staged compares HEAD (or the given revision) to the index.
+func parseRevisionDiff(args []string) (*ast.Puppetfile, *ast.Puppetfile, string, string) {
+	var pOne *ast.Puppetfile
+	var pTwo *ast.Puppetfile
+	var err error
+	path := filepath.Clean(viper.GetString("puppetfile"))
+	revOne := "HEAD"
+	revTwo := ""
+	if len(args) > 0 {
+		revOne = args[0]
+	}
+	if parts := strings.SplitN(revOne, "..", 2); len(parts) == 2 {
+		if diffStaged {
+			logging.Errorln("The --staged flag can not be used with a revision range")
+		}
+		revOne, revTwo = parts[0], parts[1]
+		if revOne == "" {
+			revOne = "HEAD"
+		}
+		if revTwo == "" {
+			revTwo = "HEAD"
+		}
+	}
+	showPathOne := fmt.Sprintf("%s:%s", revOne, path)
+	pOne, err = helpers.ParseRevision(path, revOne)
+	if err != nil {
+		logging.Errorf("Failed to parse Puppetfile at revision %s with error: %v\n", revOne, err)
+	}
+	var showPathTwo string
+	switch {
+	case diffStaged:
+		showPathTwo = fmt.Sprintf("index:%s", path)
+		pTwo, err = helpers.ParseStaged(path)
+	case revTwo != "":
+		showPathTwo = fmt.Sprintf("%s:%s", revTwo, path)
+		pTwo, err = helpers.ParseRevision(path, revTwo)
+	default:
+		showPathTwo = path
+		pTwo, err = helpers.ParseFile(path)
+	}
+	if err != nil {
+		logging.Errorf("Failed to parse Puppetfile %s with error: %v\n", showPathTwo, err)
+	}
+	return pOne, pTwo, showPathOne, showPathTwo
 }
@@ -0,0 +1,24 @@
+package cmd
+
+import "testing"
+
+func TestDiffSource(t *testing.T) {
+	cases := []struct {
+		arg  string
+		path string
+		ref  string
+		name string
+	}{
+		{"prod/Puppetfile", "prod/Puppetfile", "production", "prod/Puppetfile"},
+		{"git@github.com:fakeorg/control-repo.git", "git@github.com:fakeorg/control-repo.git", "production", "git@github.com:fakeorg/control-repo.git#production"},
+		{"git@github.com:fakeorg/control-repo.git#test", "git@github.com:fakeorg/control-repo.git", "test", "git@github.com:fakeorg/control-repo.git#test"},
+		{"https://github.com/fakeorg/control-repo#dev", "https://github.com/fakeorg/control-repo", "dev", "https://github.com/fakeorg/control-repo#dev"},
+		{"env#1/Puppetfile", "env#1/Puppetfile", "production", "env#1/Puppetfile"},
+	}
+	for _, c := range cases {
+		path, ref, name := diffSource(c.arg, "production")
+		if path != c.path || ref != c.ref || name != c.name {
+			t.Errorf("Expected diffSource(%s) to be %s, %s, %s, got %s, %s, %s", c.arg, c.path, c.ref, c.name, path, ref, name)
+		}
+	}
+}
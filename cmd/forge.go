@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi/forgeserver"
+)
+
+var (
+	forgeListen string
+	forgeReload time.Duration
+
+	forgeCmd = &cobra.Command{
+		Use:   uitext.ForgeUse,
+		Short: uitext.ForgeShort,
+		Long:  uitext.ForgeLong,
+	}
+
+	forgeServeCmd = &cobra.Command{
+		Use:   uitext.ForgeServeUse,
+		Short: uitext.ForgeServeShort,
+		Long:  uitext.ForgeServeLong,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			server := &forgeserver.Server{Dir: dir, Logger: warnLogger{}}
+			if err := server.Load(); err != nil {
+				logging.Errorf("Failed to load module tarballs from %s with error: %v\n", dir, err)
+			}
+			mods, rels := server.Count()
+			logging.Infof("Serving %d modules with %d releases from %s at http://%s\n", mods, rels, dir, forgeListen)
+			if forgeReload > 0 {
+				go func() {
+					for range time.Tick(forgeReload) {
+						if err := server.Load(); err != nil {
+							logging.Warnf("Failed to reload module tarballs from %s with error: %v\n", dir, err)
+						}
+					}
+				}()
+			}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logging.Debugf("%s %s\n", r.Method, r.URL)
+				server.ServeHTTP(w, r)
+			})
+			if err := http.ListenAndServe(forgeListen, handler); err != nil {
+				logging.Errorln("Forge server failed with error:", err)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(forgeCmd)
+	forgeCmd.AddCommand(forgeServeCmd)
+	forgeServeCmd.Flags().StringVarP(&forgeListen, "listen", "l", "127.0.0.1:8080", "address to listen on")
+	forgeServeCmd.Flags().DurationVar(&forgeReload, "reload", 0, "how often to load the tarballs again, Ex. 5m (default never)")
+}
+
+// warnLogger logs messages as warnings
+type warnLogger struct{}
+
+func (warnLogger) Printf(format string, v ...interface{}) {
+	logging.Warnf(format+"\n", v...)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/convert"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/internal/sources/forgesource"
+	"github.com/hsnodgrass/pufctl/internal/sources/gitsource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+var (
+	fixturesModules []string
+	fixturesSymlink bool
+
+	generateCmd = &cobra.Command{
+		Use:   uitext.GenerateUse,
+		Short: uitext.GenerateShort,
+		Long:  uitext.GenerateLong,
+	}
+
+	generateFixturesCmd = &cobra.Command{
+		Use:   uitext.GenerateFixturesUse,
+		Short: uitext.GenerateFixturesShort,
+		Long:  uitext.GenerateFixturesLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if fixturesSymlink && len(fixturesModules) < 1 {
+				logging.Errorln("The flag --symlink requires a module, please use --module")
+			}
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			result, err := convert.Fixtures(puppetfile, convert.FixturesOptions{
+				Modules:      fixturesModules,
+				Symlink:      fixturesSymlink,
+				Dependencies: fixtureDependencies,
+			})
+			if err != nil {
+				logging.Errorln("Failed to generate fixtures with error:", err)
+			}
+			for _, name := range result.Missing {
+				logging.Warnf("Dependency %s is not in the Puppetfile\n", name)
+			}
+			for _, name := range result.Skipped {
+				logging.Warnf("Skipping module %s, its source can't be used as a fixture\n", name)
+			}
+			out, err := result.YAML()
+			if err != nil {
+				logging.Errorln("Failed to generate fixtures with error:", err)
+			}
+			if outFile == "" {
+				fmt.Print(string(out))
+				return
+			}
+			if err := helpers.PromptConfirmFile(outFile, string(out), confirm); err != nil {
+				logging.Errorln("Failed to write fixtures with error:", err)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateFixturesCmd)
+	generateFixturesCmd.Flags().StringSliceVarP(&fixturesModules, "module", "m", []string{}, "only add the module and its dependencies (comma-separated)")
+	generateFixturesCmd.Flags().BoolVar(&fixturesSymlink, "symlink", false, "symlink the module given with --module instead of fetching it")
+}
+
+// fixtureDependencies returns the names of the dependencies of a module.
+// The metadata.json in the current directory is used for the module under
+// test, the Forge API for Forge modules, and the module's repo for Git
+// modules.
+func fixtureDependencies(m *ast.Module) ([]string, error) {
+	var meta forgeapi.ModuleMetadata
+	local, err := moduledir.ReadMetadata(".")
+	switch {
+	case err == nil && ast.NormalizeName(local.Name) == ast.NormalizeName(m.Name):
+		meta = local
+	case diff.Source(m) == diff.Forge:
+		slug := ast.NormalizeName(m.Name)
+		url, agent := viper.GetString("forge.api_url"), viper.GetString("forge.user_agent")
+		version := m.GetPropertyValue("version")
+		if version == "" || version == ":latest" {
+			mod, err := forgesource.GetModule(slug, url, agent)
+			if err != nil {
+				return nil, err
+			}
+			meta = mod.CurrentRelease.Metadata
+		} else {
+			rel, err := forgeapi.FetchRelease(fmt.Sprintf("%s-%s", slug, version), url, agent)
+			if err != nil {
+				return nil, err
+			}
+			meta = rel.Metadata
+		}
+	case diff.Source(m) == diff.Git:
+		url := m.GetPropertyValue(":git")
+		repo, err := gitsource.CloneRepo(url, "", moduleGitAuth(url))
+		if err != nil {
+			return nil, err
+		}
+		revs := []string{"HEAD"}
+		if ref := moduledir.GitRef(m); ref != "" {
+			revs = []string{ref, fmt.Sprintf("origin/%s", ref)}
+		}
+		var data []byte
+		for _, rev := range revs {
+			if data, err = gitsource.ReadRevisionFile(repo, rev, "metadata.json"); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("Failed to decode metadata.json with error: %w", err)
+		}
+	default:
+		return nil, nil
+	}
+	var names []string
+	for _, d := range meta.Dependencies {
+		names = append(names, d.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/gitsource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/internal/validators"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+var (
+	histModules []string
+	histSince   string
+	histUntil   string
+	histRev     string
+	histFormat  string
+
+	historyCmd = &cobra.Command{
+		Use:   uitext.HistoryUse,
+		Short: uitext.HistoryShort,
+		Long:  uitext.HistoryLong,
+		Args:  cobra.MaximumNArgs(0),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			switch histFormat {
+			case "text", "json":
+				logging.Debugln("Using history output format", histFormat)
+			default:
+				logging.Errorf("Output format %s is not valid, should be \"text\" or \"json\"", histFormat)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			opts := gitsource.FileHistoryOptions{Rev: histRev}
+			if histSince != "" {
+				since, err := gitsource.ParseDate(histSince, false)
+				if err != nil {
+					logging.Errorln(err)
+				}
+				opts.Since = &since
+			}
+			if histUntil != "" {
+				until, err := gitsource.ParseDate(histUntil, true)
+				if err != nil {
+					logging.Errorln(err)
+				}
+				opts.Until = &until
+			}
+			repo, relPath, err := openHistoryRepo(pfilePath)
+			if err != nil {
+				logging.Errorln("Failed to open Git repository with error:", err)
+			}
+			logging.Debugf("Walking commits that changed %s\n", relPath)
+			revs, err := gitsource.FileHistory(repo, relPath, opts)
+			if err != nil {
+				logging.Errorln("Failed to walk Puppetfile history with error:", err)
+			}
+			history := buildModuleHistory(revs, histModules)
+			var outString string
+			switch histFormat {
+			case "json":
+				out, err := json.MarshalIndent(history, "", "  ")
+				if err != nil {
+					logging.Errorln("Failed to encode history as JSON with error:", err)
+				}
+				outString = fmt.Sprintf("%s\n", out)
+			default:
+				outString = sprintModuleHistory(history)
+			}
+			if outFile != "" {
+				err = helpers.PromptConfirmFile(outFile, outString, confirm)
+				if err != nil {
+					logging.Errorln("Failed to write output to file with error:", err)
+				}
+			} else {
+				fmt.Print(outString)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringSliceVarP(&histModules, "module", "m", []string{}, "only show history for the specified modules (comma-separated)")
+	historyCmd.Flags().StringVar(&histSince, "since", "", "only show changes made on or after the date (YYYY-MM-DD or RFC3339)")
+	historyCmd.Flags().StringVar(&histUntil, "until", "", "only show changes made on or before the date (YYYY-MM-DD or RFC3339)")
+	historyCmd.Flags().StringVar(&histRev, "rev", "HEAD", "the revision to start walking history from")
+	historyCmd.Flags().StringVarP(&histFormat, "format", "f", "text", "output format [text|json]")
+}
+
+// moduleChange is a single change to a module's version or ref
+type moduleChange struct {
+	Commit  string    `json:"commit"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Change  string    `json:"change"`
+	Old     string    `json:"old"`
+	New     string    `json:"new"`
+	Summary string    `json:"summary"`
+}
+
+// moduleHistory is the timeline of changes for a single module
+type moduleHistory struct {
+	Name    string         `json:"name"`
+	Changes []moduleChange `json:"changes"`
+}
+
+// openHistoryRepo opens the local repository containing the Puppetfile or,
+// if the Puppetfile is a Git URL, clones the configured branch into memory.
+func openHistoryRepo(pfilePath string) (*git.Repository, string, error) {
+	if !validators.IsGitURL(pfilePath) && !validators.IsGitURLNoSuffix(pfilePath) {
+		return gitsource.OpenLocal(pfilePath)
+	}
+	url := pfilePath
+	if validators.IsGitURLNoSuffix(url) {
+		url = fmt.Sprintf("%s.git", url)
+	}
+	gitAuth, err := auth.GitAuth(url, viper.GetString("auth.username"), viper.GetString("auth.password"), viper.GetString("auth.token"), viper.GetString("auth.ssh_key"))
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := gitsource.CloneRepo(url, viper.GetString("puppetfile_branch"), gitAuth)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, "Puppetfile", nil
+}
+
+// historyVersions parses Puppetfile text and returns a map of
+// normalized module names to their version or ref.
+func historyVersions(text []byte, hash string, cache map[string]map[string]string) map[string]string {
+	versions := map[string]string{}
+	if text == nil {
+		return versions
+	}
+	if v, ok := cache[string(text)]; ok {
+		return v
+	}
+	puppetfile, err := ast.Parse(string(text))
+	if err != nil {
+		logging.Warnf("Failed to parse Puppetfile at commit %s, skipping: %v\n", hash, err)
+		return versions
+	}
+	for _, s := range puppetfile.Statements {
+		if s.Module != nil {
+			versions[ast.NormalizeName(s.Module.Name)] = s.Module.GetVersionOrRef()
+		}
+	}
+	cache[string(text)] = versions
+	return versions
+}
+
+// buildModuleHistory compares each revision of the Puppetfile with the
+// revision before it and returns the version / ref changes per module.
+func buildModuleHistory(revs []gitsource.FileRevision, modules []string) []moduleHistory {
+	filter := map[string]bool{}
+	for _, m := range modules {
+		filter[ast.NormalizeName(m)] = true
+	}
+	cache := map[string]map[string]string{}
+	changes := map[string][]moduleChange{}
+	for _, r := range revs {
+		before := historyVersions(r.ParentContents, r.Hash, cache)
+		after := historyVersions(r.Contents, r.Hash, cache)
+		names := map[string]bool{}
+		for n := range before {
+			names[n] = true
+		}
+		for n := range after {
+			names[n] = true
+		}
+		for n := range names {
+			if len(filter) > 0 && !filter[n] {
+				continue
+			}
+			oldVer, inBefore := before[n]
+			newVer, inAfter := after[n]
+			var change string
+			switch {
+			case !inBefore:
+				change = "added"
+			case !inAfter:
+				change = "removed"
+			case oldVer != newVer:
+				change = "changed"
+			default:
+				continue
+			}
+			changes[n] = append(changes[n], moduleChange{
+				Commit:  r.Hash,
+				Author:  r.Author,
+				Email:   r.Email,
+				Date:    r.When,
+				Change:  change,
+				Old:     oldVer,
+				New:     newVer,
+				Summary: strings.SplitN(strings.TrimSpace(r.Message), "\n", 2)[0],
+			})
+		}
+	}
+	history := make([]moduleHistory, 0, len(changes))
+	for n, c := range changes {
+		history = append(history, moduleHistory{Name: n, Changes: c})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Name < history[j].Name })
+	return history
+}
+
+func sprintModuleHistory(history []moduleHistory) string {
+	if len(history) == 0 {
+		return "No module changes found\n"
+	}
+	var b strings.Builder
+	for _, h := range history {
+		fmt.Fprintf(&b, "%s\nMODULE: %s\n%s\n", uitext.DashSep, h.Name, uitext.DashSep)
+		for _, c := range h.Changes {
+			oldVer := c.Old
+			if oldVer == "" {
+				oldVer = "(none)"
+			}
+			newVer := c.New
+			if newVer == "" {
+				newVer = "(none)"
+			}
+			var change string
+			switch c.Change {
+			case "added":
+				change = fmt.Sprintf("added %s", newVer)
+			case "removed":
+				change = fmt.Sprintf("removed %s", oldVer)
+			default:
+				change = fmt.Sprintf("%s -> %s", oldVer, newVer)
+			}
+			fmt.Fprintf(&b, "%s  %.8s  %s <%s>  %s\n", c.Date.Format("2006-01-02 15:04"), c.Commit, c.Author, c.Email, change)
+		}
+	}
+	fmt.Fprintf(&b, "%s\n", uitext.DashSep)
+	return b.String()
+}
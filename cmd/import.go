@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hsnodgrass/pufctl/internal/convert"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+var (
+	importModulepath string
+
+	importCmd = &cobra.Command{
+		Use:   uitext.ImportUse,
+		Short: uitext.ImportShort,
+		Long:  uitext.ImportLong,
+	}
+
+	importModuleListCmd = &cobra.Command{
+		Use:   uitext.ImportModuleListUse,
+		Short: uitext.ImportModuleListShort,
+		Long:  uitext.ImportModuleListLong,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			data, err := readImportFile(args[0])
+			if err != nil {
+				logging.Errorf("Failed to read %s with error: %v\n", args[0], err)
+			}
+			mods, err := convert.ParseModuleList(data)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			if importModulepath != "" {
+				found := false
+				for _, p := range convert.Modulepaths(mods) {
+					found = found || p == importModulepath
+				}
+				if !found {
+					logging.Errorf("Modulepath %s not found, should be one of [%s]\n", importModulepath, strings.Join(convert.Modulepaths(mods), "|"))
+				}
+			}
+			puppetfile, shadowed, err := convert.ModuleListPuppetfile(mods, importModulepath)
+			if err != nil {
+				logging.Errorln("Failed to generate Puppetfile with error:", err)
+			}
+			for _, m := range shadowed {
+				logging.Warnf("Skipping module %s in %s, it is shadowed by an earlier modulepath\n", m.Name, m.Modulepath)
+			}
+			writeGenerated(puppetfile)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importModuleListCmd)
+	importModuleListCmd.Flags().StringVar(&importModulepath, "modulepath", "", "only import modules in this modulepath")
+}
+
+// readImportFile reads the file to import, or stdin if path is -
+func readImportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// writeGenerated prints a generated Puppetfile, or writes it to --out-file
+func writeGenerated(puppetfile *ast.Puppetfile) {
+	if outFile == "" {
+		fmt.Print(puppetfile.Sprint())
+		return
+	}
+	if err := helpers.PromptConfirmFile(outFile, puppetfile.Sprint(), confirm); err != nil {
+		logging.Errorln("Failed to write Puppetfile with error:", err)
+	}
+}
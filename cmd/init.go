@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+)
+
+var (
+	initFromModuledir string
+
+	initCmd = &cobra.Command{
+		Use:   uitext.InitUse,
+		Short: uitext.InitShort,
+		Long:  uitext.InitLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if initFromModuledir == "" {
+				logging.Errorln("A modules directory is required, please use --from-moduledir")
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			scanned, err := moduledir.Scan(initFromModuledir)
+			if err != nil {
+				logging.Errorf("Failed to scan %s with error: %v\n", initFromModuledir, err)
+			}
+			for _, m := range scanned {
+				if m.Unclassified != "" {
+					logging.Warnf("Could not classify module %s: %s\n", m.Name, m.Unclassified)
+				}
+			}
+			puppetfile, err := moduledir.Bootstrap(scanned)
+			if err != nil {
+				logging.Errorln("Failed to generate Puppetfile with error:", err)
+			}
+			logging.Debugf("Generated Puppetfile with %d modules from %s\n", len(scanned), initFromModuledir)
+			writeGenerated(puppetfile)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVar(&initFromModuledir, "from-moduledir", "", "modules directory to generate the Puppetfile from")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+var (
+	installModuledir string
+	installJobs      int
+
+	installCmd = &cobra.Command{
+		Use:   uitext.InstallUse,
+		Short: uitext.InstallShort,
+		Long:  uitext.InstallLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if installJobs < 1 {
+				logging.Errorln("The flag --jobs must be at least 1")
+			}
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			opts := moduledirOptions(pfilePath, puppetfile, installModuledir, installJobs)
+			logging.Infoln("Installing modules into", moduledir.Path(puppetfile, opts))
+			results := moduledir.Install(puppetfile, opts)
+			failed := false
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "MODULE\tSTATUS\tVERSION\tPATH")
+			for _, r := range results {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Status, r.Version, r.Path)
+			}
+			w.Flush()
+			for _, r := range results {
+				if r.Err == nil {
+					continue
+				}
+				logging.Warnf("Module %s %s: %v\n", r.Name, r.Status, r.Err)
+				if r.Status == moduledir.Failed {
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(installCmd)
+	installCmd.Flags().StringVar(&installModuledir, "moduledir", "", "directory to install modules into (defaults to the Puppetfile's moduledir, or ./modules)")
+	installCmd.Flags().IntVarP(&installJobs, "jobs", "j", 4, "number of modules to install in parallel")
+}
+
+// moduledirOptions returns the moduledir options for the Puppetfile at
+// pfilePath. Relative paths are resolved against the directory of a
+// local Puppetfile, or the current directory for a Puppetfile in Git.
+func moduledirOptions(pfilePath string, puppetfile *ast.Puppetfile, dir string, jobs int) moduledir.Options {
+	basedir := "."
+	if !isGitTarget(pfilePath) {
+		basedir = filepath.Dir(pfilePath)
+	}
+	return moduledir.Options{
+		Basedir:   basedir,
+		Moduledir: dir,
+		ForgeURL:  forgeAPIURL(puppetfile),
+		UserAgent: viper.GetString("forge.user_agent"),
+		Jobs:      jobs,
+		GitAuth:   moduleGitAuth,
+	}
+}
+
+// moduleGitAuth returns the configured authentication for a Git URL, or
+// no authentication for URLs that don't need it, such as file:// URLs.
+func moduleGitAuth(url string) auth.Auth {
+	modauth, err := auth.GitAuth(url, viper.GetString("auth.username"), viper.GetString("auth.password"), viper.GetString("auth.token"), viper.GetString("auth.ssh_key"))
+	if err != nil {
+		logging.Debugln("Using no authentication for", url)
+		return auth.Auth{}
+	}
+	return modauth
+}
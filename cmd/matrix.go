@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/gitsource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/internal/validators"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+var (
+	mxBranches  []string
+	mxFile      string
+	mxFormat    string
+	mxJobs      int
+	mxDriftOnly bool
+
+	// matrixClone clones the repository of a Git URL
+	matrixClone = gitsource.CloneRepo
+
+	matrixCmd = &cobra.Command{
+		Use:   uitext.MatrixUse,
+		Short: uitext.MatrixShort,
+		Long:  uitext.MatrixLong,
+		Args:  cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			switch mxFormat {
+			case "text", "csv", "json", "markdown":
+				logging.Debugln("Using matrix output format", mxFormat)
+			default:
+				logging.Errorf("Output format %s is not valid, should be one of [text|csv|json|markdown]", mxFormat)
+			}
+			if mxJobs < 1 {
+				logging.Errorln("The flag --jobs must be at least 1")
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			repo, relPath, err := openMatrixRepo(args[0])
+			if err != nil {
+				logging.Errorln("Failed to open Git repository with error:", err)
+			}
+			branches, err := gitsource.ListBranches(repo)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			branches, err = gitsource.FilterBranches(branches, mxBranches)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			if len(branches) < 1 {
+				logging.Errorln("No branches found, please check the --branches flag")
+			}
+			puppetfiles := parseBranchPuppetfiles(repo, relPath, branches, mxJobs)
+			matrix := diff.Matrix(puppetfiles, mxDriftOnly)
+			var outString string
+			switch mxFormat {
+			case "csv":
+				outString, err = matrix.CSV()
+			case "json":
+				var out []byte
+				out, err = matrix.JSON()
+				outString = fmt.Sprintf("%s\n", out)
+			case "markdown":
+				outString = matrix.Markdown()
+			default:
+				outString = matrix.Text()
+			}
+			if err != nil {
+				logging.Errorln("Failed to render matrix with error:", err)
+			}
+			if outFile != "" {
+				err = helpers.PromptConfirmFile(outFile, outString, confirm)
+				if err != nil {
+					logging.Errorln("Failed to write output to file with error:", err)
+				}
+			} else {
+				fmt.Print(outString)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(matrixCmd)
+	matrixCmd.Flags().StringSliceVarP(&mxBranches, "branches", "b", []string{}, "only include branches matching the comma-separated glob patterns (Ex. -b 'prod*,dev*')")
+	matrixCmd.Flags().StringVar(&mxFile, "file", "Puppetfile", "path of the Puppetfile relative to the root of the repository")
+	matrixCmd.Flags().StringVarP(&mxFormat, "format", "f", "text", "output format [text|csv|json|markdown]")
+	matrixCmd.Flags().IntVarP(&mxJobs, "jobs", "j", 4, "number of branches to parse in parallel")
+	matrixCmd.Flags().BoolVar(&mxDriftOnly, "drift-only", false, "only show modules that differ between environments")
+}
+
+// openMatrixRepo opens a local repository or clones a remote repository
+// into memory once, fetching all of its branches.
+func openMatrixRepo(target string) (*git.Repository, string, error) {
+	if !validators.IsGitURL(target) && !validators.IsGitURLNoSuffix(target) {
+		return gitsource.OpenLocal(filepath.Join(target, mxFile))
+	}
+	url := target
+	if validators.IsGitURLNoSuffix(url) {
+		url = fmt.Sprintf("%s.git", url)
+	}
+	gitAuth, err := auth.GitAuth(url, viper.GetString("auth.username"), viper.GetString("auth.password"), viper.GetString("auth.token"), viper.GetString("auth.ssh_key"))
+	if err != nil {
+		return nil, "", err
+	}
+	// Info logs go to stdout, so they would break csv and json output
+	if mxFormat == "text" {
+		logging.Infoln("Cloning repository. This may take a few seconds.")
+	}
+	repo, err := matrixClone(url, "", gitAuth)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, filepath.ToSlash(mxFile), nil
+}
+
+// parseBranchPuppetfiles reads the Puppetfile of each branch, and parses
+// them using a pool of workers. The repository's object storage isn't safe
+// for concurrent use, so the Puppetfiles are all read before parsing starts.
+func parseBranchPuppetfiles(repo *git.Repository, relPath string, branches map[string]plumbing.Hash, jobs int) map[string]*ast.Puppetfile {
+	texts := map[string][]byte{}
+	for name, hash := range branches {
+		text, err := gitsource.ReadRevisionFile(repo, hash.String(), relPath)
+		if err != nil {
+			logging.Warnf("Skipping branch %s: %v\n", name, err)
+			continue
+		}
+		texts[name] = text
+	}
+	var resMutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	results := map[string]*ast.Puppetfile{}
+	names := make(chan string)
+	for i := 0; i < jobs; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for name := range names {
+				puppetfile, err := ast.Parse(string(texts[name]))
+				if err != nil {
+					logging.Warnf("Skipping branch %s, failed to parse Puppetfile: %v\n", name, err)
+					continue
+				}
+				logging.Debugln("Parsed Puppetfile from branch", name)
+				resMutex.Lock()
+				results[name] = puppetfile
+				resMutex.Unlock()
+			}
+		}()
+	}
+	for name := range texts {
+		names <- name
+	}
+	close(names)
+	waitGroup.Wait()
+	return results
+}
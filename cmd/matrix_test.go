@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// TestMatrixJSONFromGitURL runs pufctl matrix in a child process, so that
+// everything it writes to stdout is captured, and clones the repository of
+// the Git URL from a local repository instead
+func TestMatrixJSONFromGitURL(t *testing.T) {
+	if dir := os.Getenv("PUFCTL_TEST_MATRIX_REPO"); dir != "" {
+		matrixClone = func(url, branch string, modauth auth.Auth) (*git.Repository, error) {
+			return git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: dir})
+		}
+		rootCmd.SetArgs([]string{"matrix", "https://github.com/fakeorg/control-repo.git", "--format", "json"})
+		Execute()
+		os.Exit(0)
+	}
+
+	dir, err := ioutil.TempDir("", "pufctl")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory with error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to initialize repository with error: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "Puppetfile"), []byte("mod 'puppetlabs-stdlib', '6.0.0'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write Puppetfile with error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	if _, err := wt.Add("Puppetfile"); err != nil {
+		t.Fatalf("Failed to stage Puppetfile with error: %v", err)
+	}
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("Add Puppetfile", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Failed to commit Puppetfile with error: %v", err)
+	}
+
+	child := exec.Command(os.Args[0], "-test.run=^TestMatrixJSONFromGitURL$")
+	child.Dir = dir
+	child.Env = append(os.Environ(), "PUFCTL_TEST_MATRIX_REPO="+dir, "HOME="+dir)
+	out, err := child.Output()
+	if err != nil {
+		t.Fatalf("Failed to run pufctl matrix with error: %v\n%s", err, out)
+	}
+	var matrix diff.MatrixResult
+	if err := json.Unmarshal(out, &matrix); err != nil {
+		t.Fatalf("Expected JSON output, failed to parse it with error: %v\n%s", err, out)
+	}
+	if len(matrix.Environments) != 1 || matrix.Environments[0] != "master" || len(matrix.Modules) != 1 {
+		t.Errorf("Expected the master branch with one module, got %+v", matrix)
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/merge"
+)
+
+var (
+	mergeDriver       bool
+	mergePreferHigher bool
+	mergeMarkerSize   int
+
+	mergeCmd = &cobra.Command{
+		Use:   uitext.MergeUse,
+		Short: uitext.MergeShort,
+		Long:  uitext.MergeLong,
+		Args:  cobra.ExactArgs(3),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if mergeMarkerSize < 1 {
+				logging.Errorln("The flag --marker-size must be at least 1")
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			base := parseMergeFile(args[0], "base")
+			ours := parseMergeFile(args[1], "ours")
+			theirs := parseMergeFile(args[2], "theirs")
+			_preferHigher := helpers.MaxBools(mergePreferHigher, viper.GetBool("merge.prefer_higher"))
+			result, err := merge.Puppetfiles(base, ours, theirs, merge.Options{PreferHigher: _preferHigher})
+			if err != nil {
+				logging.Errorln("Failed to merge Puppetfiles with error:", err)
+			}
+			for _, name := range result.Resolved {
+				logging.Debugf("Resolved module %s by choosing the higher version\n", name)
+			}
+			for _, c := range result.Conflicts {
+				logging.Warnf("Merge conflict in module %s\n", c.Name)
+			}
+			for _, c := range result.SectionConflicts {
+				logging.Warnf("Merge conflict in %s\n", c.Name)
+			}
+			oursLabel, theirsLabel := args[1], args[2]
+			if mergeDriver {
+				oursLabel, theirsLabel = "ours", "theirs"
+			}
+			outString := result.Sprint(mergeMarkerSize, oursLabel, theirsLabel)
+			switch {
+			case mergeDriver:
+				// Git expects the merge result to be written to the ours file (%A)
+				err = helpers.OverwriteFile(args[1], outString)
+			case outFile != "":
+				err = helpers.PromptConfirmFile(outFile, outString, confirm)
+			default:
+				fmt.Print(outString)
+			}
+			if err != nil {
+				logging.Errorln("Failed to write merged Puppetfile with error:", err)
+			}
+			if result.HasConflicts() {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().BoolVar(&mergeDriver, "driver", false, "run as a Git merge driver, writing the result to the ours Puppetfile (%A)")
+	mergeCmd.Flags().BoolVar(&mergePreferHigher, "prefer-higher", false, "resolve modules whose version changed on both sides by choosing the higher version")
+	mergeCmd.Flags().IntVar(&mergeMarkerSize, "marker-size", merge.DefaultMarkerSize, "length of conflict markers (%L in a Git merge driver)")
+	viper.BindPFlag("merge.prefer_higher", mergeCmd.Flags().Lookup("prefer-higher"))
+}
+
+func parseMergeFile(path, name string) *ast.Puppetfile {
+	puppetfile, err := helpers.ParseFile(path)
+	if err != nil {
+		logging.Errorf("Failed to parse %s Puppetfile %s with error: %v\n", name, path, err)
+	}
+	return puppetfile
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	pconf "github.com/hsnodgrass/pufctl/internal/config"
+	"github.com/hsnodgrass/pufctl/internal/convert"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/forgesource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+var (
+	metadataPath string
+	metadataTo   string
+
+	metadataCmd = &cobra.Command{
+		Use:   uitext.MetadataUse,
+		Short: uitext.MetadataShort,
+		Long:  uitext.MetadataLong,
+	}
+
+	metadataSyncCmd = &cobra.Command{
+		Use:   uitext.MetadataSyncUse,
+		Short: uitext.MetadataSyncShort,
+		Long:  uitext.MetadataSyncLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if metadataTo != "puppetfile" && metadataTo != "metadata" {
+				logging.Errorf("Invalid value %s for --to, should be one of [puppetfile|metadata]\n", metadataTo)
+			}
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			_confirm := helpers.MaxBools(confirm, viper.GetBool("always.confirm"))
+			_show := helpers.MaxBools(show, viper.GetBool("always.show"))
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			data, err := ioutil.ReadFile(metadataPath)
+			if err != nil {
+				logging.Errorf("Failed to read %s with error: %v\n", metadataPath, err)
+			}
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			if metadataTo == "metadata" {
+				out, changes, err := convert.UpdateRequirements(data, puppetfile)
+				if err != nil {
+					logging.Errorln(err)
+				}
+				for _, c := range changes {
+					logging.Debugf("Updating %s from \"%s\" to \"%s\"\n", c.Name, c.Old, c.New)
+				}
+				fileOutput(metadataPath, _writeInPlace, _confirm, len(changes) > 0, out)
+				return
+			}
+			meta, err := convert.ParseMetadata(data)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			logging.Infoln("Adding dependencies to Puppetfile. This may take a few seconds.")
+			added, mismatched, err := convert.AddDependencies(puppetfile, meta, forgeVersionResolver(forgeAPIURL(puppetfile)))
+			if err != nil {
+				logging.Errorln(err)
+			}
+			for _, a := range added {
+				fmt.Printf("Added %s %s\n", a.Name, a.New)
+			}
+			for _, m := range mismatched {
+				logging.Warnf("Module %s is at %s in the Puppetfile, which doesn't satisfy \"%s\"\n", m.Name, m.Version, m.Requirement)
+			}
+			editOutput(_show, _writeInPlace, _confirm, len(added) > 0, pfilePath, outFile, puppetfile)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(metadataCmd)
+	metadataCmd.AddCommand(metadataSyncCmd)
+	metadataSyncCmd.Flags().StringVar(&metadataPath, "metadata", "metadata.json", "path to the module's metadata.json")
+	metadataSyncCmd.Flags().StringVar(&metadataTo, "to", "puppetfile", "what to update, either puppetfile or metadata")
+	metadataSyncCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the current Puppetfile, or metadata.json with --to metadata, with changes")
+}
+
+// forgeVersionResolver returns a function that resolves the highest
+// release of a Forge module that satisfies a version range, from the Forge
+// at url
+func forgeVersionResolver(url string) func(name string, r semver.Range) (string, error) {
+	return func(name string, r semver.Range) (string, error) {
+		mod, err := forgesource.GetModule(name, url, viper.GetString("forge.user_agent"))
+		if err != nil {
+			return "", err
+		}
+		var best semver.SemVer
+		found := false
+		for _, rel := range mod.Releases {
+			v, err := semver.Make(rel.Version)
+			if err != nil || !r.Contains(v) {
+				continue
+			}
+			if !found || v.Compare(best) > 0 {
+				best = v
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("no release of %s satisfies \"%s\"", name, r)
+		}
+		return best.String(), nil
+	}
+}
+
+// fileOutput prints an updated file other than the Puppetfile, or writes it
+// in place or to --out-file
+func fileOutput(path string, _writeInPlace, _confirm, _changes bool, out []byte) {
+	if !_changes {
+		logging.Infoln("No changes to write to", path)
+		return
+	}
+	if _writeInPlace {
+		if err := helpers.PromptConfirmFile(path, string(out), _confirm); err != nil {
+			logging.Errorln("Failed to write in place with error: ", err)
+		}
+	}
+	if outFile != "" {
+		if err := helpers.PromptConfirmFile(outFile, string(out), _confirm); err != nil {
+			logging.Errorln("Failed to write output to file with error: ", err)
+		}
+	}
+	if !_writeInPlace && outFile == "" {
+		fmt.Print(string(out))
+	}
+}
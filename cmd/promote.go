@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	pconf "github.com/hsnodgrass/pufctl/internal/config"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/gitsource"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/internal/validators"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/merge"
+)
+
+var (
+	prSourceBranch string
+	prTargetBranch string
+	prModules      []string
+	prTag          string
+	prAll          bool
+	prCommit       bool
+	prMessage      string
+
+	promoteCmd = &cobra.Command{
+		Use:   uitext.PromoteUse,
+		Short: uitext.PromoteShort,
+		Long:  uitext.PromoteLong,
+		Args:  cobra.ExactArgs(2),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(prModules) < 1 && prTag == "" && !prAll {
+				logging.Errorln("Please select modules to promote with --modules, --tag, or --all")
+			}
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			if _writeInPlace && isGitTarget(args[1]) {
+				logging.Errorln("Can't write in place to a Git URL, please use --commit instead")
+			}
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			_show := helpers.MaxBools(show, viper.GetBool("always.show"))
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			sourceOpts := parseOpts
+			if prSourceBranch != "" {
+				sourceOpts.GitRef = prSourceBranch
+			}
+			source, err := helpers.Parse(args[0], sourceOpts)
+			if err != nil {
+				logging.Errorln("Failed to parse source Puppetfile with error:", err)
+			}
+			var target *ast.Puppetfile
+			var targetRepo *git.Repository
+			if prCommit && isGitTarget(args[1]) {
+				targetRepo, target, err = clonePromoteTarget(args[1])
+			} else {
+				targetOpts := parseOpts
+				if prTargetBranch != "" {
+					targetOpts.GitRef = prTargetBranch
+				}
+				target, err = helpers.Parse(args[1], targetOpts)
+			}
+			if err != nil {
+				logging.Errorln("Failed to parse target Puppetfile with error:", err)
+			}
+			result, err := merge.Sync(source, target, merge.SyncOptions{
+				Modules: prModules,
+				Tag:     prTag,
+				All:     prAll,
+			})
+			if err != nil {
+				logging.Errorln("Failed to promote modules with error:", err)
+			}
+			for _, n := range append(result.NotFound, result.NotPruned...) {
+				logging.Warnf("Module %s could not be found in the source Puppetfile\n", n)
+			}
+			promoted := result.Synced
+			if len(promoted) < 1 {
+				logging.Infoln("No module changes to promote from", args[0], "to", args[1])
+				return
+			}
+			summary := sprintSynced(promoted)
+			fmt.Printf("%s\nPromoted %d modules from %s to %s\n%s\n%s", uitext.DashSep, len(promoted), args[0], args[1], uitext.DashSep, summary)
+			if prCommit {
+				checkShow(_show, target)
+				message := prMessage
+				if message == "" {
+					message = fmt.Sprintf("Promote %d modules from %s to %s\n\n%s", len(promoted), args[0], args[1], summary)
+				}
+				err = commitPromoted(targetRepo, args[1], message, target)
+				if err != nil {
+					logging.Errorln("Failed to commit promoted Puppetfile with error:", err)
+				}
+				return
+			}
+			editOutput(_show, _writeInPlace, confirm, true, args[1], outFile, target)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.Flags().StringVar(&prSourceBranch, "source-branch", "", "the branch to use if the source Puppetfile is a Git URL (defaults to --puppetfile-branch)")
+	promoteCmd.Flags().StringVar(&prTargetBranch, "target-branch", "", "the branch to use if the target Puppetfile is a Git URL (defaults to --puppetfile-branch)")
+	promoteCmd.Flags().StringSliceVarP(&prModules, "modules", "m", []string{}, "promote the specified modules (comma-separated)")
+	promoteCmd.Flags().StringVarP(&prTag, "tag", "t", "", "promote all modules with the specified meta tag in the source Puppetfile (Ex. -t @platform)")
+	promoteCmd.Flags().BoolVarP(&prAll, "all", "a", false, "promote all modules that differ between the source and target")
+	promoteCmd.Flags().BoolVar(&prCommit, "commit", false, "commit the promoted Puppetfile to the target's Git repository (pushes if the target is a Git URL)")
+	promoteCmd.Flags().StringVar(&prMessage, "message", "", "commit message to use with --commit")
+	promoteCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the target Puppetfile with changes")
+}
+
+func isGitTarget(target string) bool {
+	return validators.IsGitURL(target) || validators.IsGitURLNoSuffix(target)
+}
+
+// clonePromoteTarget clones the target branch with a working tree into
+// memory so the promoted Puppetfile can be committed and pushed.
+func clonePromoteTarget(url string) (*git.Repository, *ast.Puppetfile, error) {
+	if validators.IsGitURLNoSuffix(url) {
+		url = fmt.Sprintf("%s.git", url)
+	}
+	branch := prTargetBranch
+	if branch == "" {
+		branch = viper.GetString("puppetfile_branch")
+	}
+	gitAuth, err := auth.GitAuth(url, parseOpts.Username, parseOpts.Password, parseOpts.Token, parseOpts.SSHKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := gitsource.CloneWorktree(url, branch, gitAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := wt.Filesystem.Open("Puppetfile")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open Puppetfile with error: %w", err)
+	}
+	defer f.Close()
+	text, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read Puppetfile with error: %w", err)
+	}
+	puppetfile, err := ast.Parse(string(text))
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, puppetfile, nil
+}
+
+// commitPromoted commits the promoted Puppetfile. Remote targets are
+// committed in the in-memory clone and pushed, local targets are committed
+// to the repository that contains them.
+func commitPromoted(repo *git.Repository, target, message string, puppetfile *ast.Puppetfile) error {
+	relPath := "Puppetfile"
+	var err error
+	if repo == nil {
+		repo, relPath, err = gitsource.OpenLocal(target)
+		if err != nil {
+			return err
+		}
+	}
+	hash, err := gitsource.CommitFile(repo, relPath, []byte(puppetfile.Sprint()), message)
+	if err != nil {
+		return err
+	}
+	logging.Infof("Committed promoted Puppetfile as %s\n", hash)
+	if !isGitTarget(target) {
+		return nil
+	}
+	url := target
+	if validators.IsGitURLNoSuffix(url) {
+		url = fmt.Sprintf("%s.git", url)
+	}
+	gitAuth, err := auth.GitAuth(url, parseOpts.Username, parseOpts.Password, parseOpts.Token, parseOpts.SSHKey)
+	if err != nil {
+		return err
+	}
+	err = gitsource.Push(repo, gitAuth)
+	if err != nil {
+		return err
+	}
+	logging.Infoln("Pushed promoted Puppetfile to", target)
+	return nil
+}
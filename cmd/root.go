@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -12,6 +13,7 @@ import (
 	"github.com/hsnodgrass/pufctl/internal/logging"
 	"github.com/hsnodgrass/pufctl/internal/uitext"
 	pufctlver "github.com/hsnodgrass/pufctl/internal/version"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
 	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
 )
 
@@ -35,6 +37,8 @@ var (
 	user             string
 	pass             string
 	token            string
+	offline          bool
+	forgeToken       string
 
 	parseOpts helpers.ParseOptions
 
@@ -160,6 +164,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&token, "token", "", "Forge / Git authentication token")
 	rootCmd.PersistentFlags().StringVar(&user, "user", "", "Forge / Git authentication username")
 	rootCmd.PersistentFlags().StringVar(&pass, "pass", "", "Forge / Git authentication password")
+	rootCmd.PersistentFlags().StringVar(&forgeToken, "forge-token", "", "Forge authentication token, for a private Forge")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", pconf.AlwaysOffline, "Only use cached Forge responses, don't make requests to the Forge")
 
 	viper.BindPFlag("auth.username", rootCmd.PersistentFlags().Lookup("user"))
 	viper.BindPFlag("auth.password", rootCmd.PersistentFlags().Lookup("pass"))
@@ -171,6 +177,8 @@ func init() {
 	viper.BindPFlag("forge.api_url", rootCmd.PersistentFlags().Lookup("forge-api"))
 	viper.BindPFlag("always.show", rootCmd.PersistentFlags().Lookup("show"))
 	viper.BindPFlag("auth.ssh_key", rootCmd.PersistentFlags().Lookup("ssh-key"))
+	viper.BindPFlag("forge.token", rootCmd.PersistentFlags().Lookup("forge-token"))
+	viper.BindPFlag("always.offline", rootCmd.PersistentFlags().Lookup("offline"))
 
 	rootCmd.AddCommand(showCmd)
 	showCmd.Flags().BoolVar(&versionsOnly, "versions-only", false, "Show a truncated for of Puppetfile with only <module name>: <version>")
@@ -203,11 +211,87 @@ func initConfig() {
 			fmt.Println(uitext.DashSep)
 		}
 	}
+	initForgeClient()
+	initForgeCache()
+}
+
+// forgeCredentials are the credentials for a Forge URL under the
+// forge.credentials config key
+type forgeCredentials struct {
+	URL      string `mapstructure:"url"`
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// initForgeClient sets the TLS options of Forge requests, and the
+// credentials for each Forge under forge.credentials. The forge.token,
+// forge.username, and forge.password credentials are for the Forge at
+// forge.api_url, and take precedence over forge.credentials.
+func initForgeClient() {
+	err := forgeapi.ConfigureTLS(forgeapi.TLSOptions{
+		CAFile:   viper.GetString("forge.ca_file"),
+		CertFile: viper.GetString("forge.client_cert"),
+		KeyFile:  viper.GetString("forge.client_key"),
+	})
+	if err != nil {
+		logging.Errorln("Failed to configure TLS for the Forge with error:", err)
+	}
+	var creds []forgeCredentials
+	if err := viper.UnmarshalKey("forge.credentials", &creds); err != nil {
+		logging.Errorln("Failed to read forge.credentials with error:", err)
+	}
+	for _, c := range creds {
+		forgeapi.SetCredentials(c.URL, forgeapi.Credentials{Token: c.Token, Username: c.Username, Password: c.Password})
+	}
+	global := forgeapi.Credentials{
+		Token:    viper.GetString("forge.token"),
+		Username: viper.GetString("forge.username"),
+		Password: viper.GetString("forge.password"),
+	}
+	if global.Token != "" || global.Username != "" {
+		forgeapi.SetCredentials(viper.GetString("forge.api_url"), global)
+	}
+}
+
+// forgeAPIURL returns the URL of the Forge for the modules of a Puppetfile,
+// which is the Puppetfile's forge directive unless --forge-api is set
+func forgeAPIURL(puppetfile *ast.Puppetfile) string {
+	if !rootCmd.PersistentFlags().Changed("forge-api") && puppetfile != nil && puppetfile.Forge != nil && puppetfile.Forge.URL != "" {
+		return puppetfile.Forge.URL
+	}
+	return viper.GetString("forge.api_url")
+}
+
+// initForgeCache caches Forge responses in forge.cache_dir, unless it is
+// set to an empty string
+func initForgeCache() {
+	dir := viper.GetString("forge.cache_dir")
+	if dir == "" {
+		if viper.GetBool("always.offline") {
+			logging.Errorln("Can't use --offline with the Forge cache disabled, set forge.cache_dir to enable it")
+		}
+		return
+	}
+	ttl, err := time.ParseDuration(viper.GetString("forge.cache_ttl"))
+	if err != nil {
+		logging.Warnf("Invalid forge.cache_ttl %s, using the default of %s\n", viper.GetString("forge.cache_ttl"), forgeapi.DefaultCacheTTL)
+		ttl = forgeapi.DefaultCacheTTL
+	}
+	cache := forgeapi.NewCache(dir, ttl, forgeapi.DefaultHTTPClient)
+	cache.Offline = viper.GetBool("always.offline")
+	forgeapi.DefaultHTTPClient = cache
+	downloads := forgeapi.NewCache(dir, ttl, forgeapi.DefaultDownloadHTTPClient)
+	downloads.Offline = cache.Offline
+	forgeapi.DefaultDownloadHTTPClient = downloads
 }
 
 func initViperDefaults() {
 	viper.SetDefault("forge", pconf.ForgeStrDefaults)
 	viper.SetDefault("forge.user_agent", pufctlver.UserAgent())
+	viper.SetDefault("forge.cache_dir", pconf.ForgeCacheDir)
+	viper.SetDefault("forge.cache_ttl", pconf.ForgeCacheTTL)
+	viper.SetDefault("forge.deprecated_modules", pconf.ForgeDeprecatedModules)
 	viper.SetDefault("always", pconf.AlwaysBoolDefaults)
 	viper.SetDefault("auth", pconf.AuthStrDefaults)
 	viper.SetDefault("genopts", pconf.GenoptsStrDefaults)
@@ -36,6 +36,8 @@ var (
 	fsWithHTML       bool
 	fsIncludeFields  []string
 	fsExcludeFields  []string
+	fsAll            bool
+	fsMax            int
 
 	searchCmd = &cobra.Command{
 		Use:   uitext.SearchUse,
@@ -53,7 +55,16 @@ var (
 			agent := viper.GetString("forge.user_agent")
 			opts := newListModulesOpts(args)
 			handleMissingFields(&opts)
-			finds, err := forgesource.Search(_url, agent, opts)
+			var finds []forgeapi.Module
+			var err error
+			if fsAll {
+				if !cmd.Flags().Changed("limit") {
+					opts.Limit = forgeapi.MaxListLimit
+				}
+				finds, err = forgesource.SearchAll(_url, agent, opts, fsMax)
+			} else {
+				finds, err = forgesource.Search(_url, agent, opts)
+			}
 			if err != nil {
 				logging.Errorln("Search failed with error: ", err)
 			}
@@ -73,7 +84,9 @@ var (
 func init() {
 	rootCmd.AddCommand(searchCmd)
 	searchCmd.AddCommand(searchForgeCmd)
-	searchForgeCmd.Flags().IntVar(&fsLimit, "limit", 5, "limit the number of search hits returned")
+	searchForgeCmd.Flags().IntVar(&fsLimit, "limit", 5, "limit the number of search hits returned, or the number per request with --all")
+	searchForgeCmd.Flags().BoolVar(&fsAll, "all", false, "return all search hits, following the pages of results")
+	searchForgeCmd.Flags().IntVar(&fsMax, "max", 0, "with --all, stop after this many search hits (0 for no maximum)")
 	searchForgeCmd.Flags().IntVar(&fsOffset, "offset", 0, "return only search hits after the offset")
 	searchForgeCmd.Flags().StringVarP(&fsSortBy, "sort-by", "b", "rank", "sort search hits by [rank|downloads|latest_release]")
 	searchForgeCmd.Flags().StringVarP(&fsTag, "tag", "t", "", "return only modules with specified tag")
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	pconf "github.com/hsnodgrass/pufctl/internal/config"
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/merge"
+)
+
+var (
+	syncFrom       string
+	syncFromBranch string
+	syncModules    []string
+	syncTag        string
+	syncAll        bool
+	syncPrune      bool
+	syncDryRun     bool
+
+	syncCmd = &cobra.Command{
+		Use:   uitext.SyncUse,
+		Short: uitext.SyncShort,
+		Long:  uitext.SyncLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if syncFrom == "" {
+				logging.Errorln("A reference Puppetfile is required, please use --from")
+			}
+			if len(syncModules) < 1 && syncTag == "" && !syncAll {
+				logging.Errorln("Please select modules to sync with --modules, --tag, or --all")
+			}
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			_show := helpers.MaxBools(show, viper.GetBool("always.show"))
+			_writeInPlace := helpers.MaxBools(writeInPlace, viper.GetBool("always.write_in_place"))
+			refOpts := parseOpts
+			if syncFromBranch != "" {
+				refOpts.GitRef = syncFromBranch
+			}
+			reference, err := helpers.Parse(syncFrom, refOpts)
+			if err != nil {
+				logging.Errorln("Failed to parse reference Puppetfile with error:", err)
+			}
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			before := puppetfile.Sprint()
+			result, err := merge.Sync(reference, puppetfile, merge.SyncOptions{
+				Modules: syncModules,
+				Tag:     syncTag,
+				All:     syncAll,
+				Prune:   syncPrune,
+			})
+			if err != nil {
+				logging.Errorln("Failed to sync Puppetfile with error:", err)
+			}
+			for _, n := range result.NotFound {
+				logging.Warnf("Module %s could not be found in either Puppetfile\n", n)
+			}
+			for _, n := range result.NotPruned {
+				logging.Warnf("Module %s could not be found in the reference Puppetfile, use --prune to remove it\n", n)
+			}
+			synced := result.Synced
+			if len(synced) < 1 {
+				logging.Infoln("Puppetfile is already in sync with", syncFrom)
+				return
+			}
+			if syncDryRun {
+				original, err := ast.Parse(before)
+				if err != nil {
+					logging.Errorln(err)
+				}
+				fmt.Printf("%s\nWould sync %d modules from %s\n%s\n%s", uitext.DashSep, len(synced), syncFrom, uitext.DashSep, sprintSynced(synced))
+				fmt.Printf("%s\n%s", uitext.DashSep, diff.Unified(original, puppetfile, pfilePath, pfilePath, 3))
+				return
+			}
+			fmt.Printf("%s\nSynced %d modules from %s\n%s\n%s", uitext.DashSep, len(synced), syncFrom, uitext.DashSep, sprintSynced(synced))
+			editOutput(_show, _writeInPlace, confirm, true, pfilePath, outFile, puppetfile)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringVar(&syncFrom, "from", "", "path or Git URL of the reference Puppetfile")
+	syncCmd.Flags().StringVar(&syncFromBranch, "from-branch", "", "the branch to use if the reference Puppetfile is a Git URL (defaults to --puppetfile-branch)")
+	syncCmd.Flags().StringSliceVarP(&syncModules, "modules", "m", []string{}, "sync the specified modules (comma-separated)")
+	syncCmd.Flags().StringVarP(&syncTag, "tag", "t", "", "sync all modules with the specified meta tag in the reference Puppetfile (Ex. -t @platform)")
+	syncCmd.Flags().BoolVarP(&syncAll, "all", "a", false, "sync all modules in the reference Puppetfile")
+	syncCmd.Flags().BoolVar(&syncPrune, "prune", false, "remove modules absent from the reference Puppetfile (with --tag, only modules with the tag)")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "preview the changes without writing them")
+	syncCmd.Flags().BoolVarP(&writeInPlace, "write-in-place", "w", pconf.AlwaysWriteInPlace, "Overwrite the current Puppetfile with changes")
+}
+
+func sprintSynced(synced []merge.SyncedModule) string {
+	var b strings.Builder
+	for _, s := range synced {
+		oldVer := s.Old
+		if oldVer == "" {
+			oldVer = "(none)"
+		}
+		newVer := s.New
+		if newVer == "" {
+			newVer = "(none)"
+		}
+		switch s.Change {
+		case "added":
+			fmt.Fprintf(&b, "  %s: added %s\n", s.Name, newVer)
+		case "removed":
+			fmt.Fprintf(&b, "  %s: removed %s\n", s.Name, oldVer)
+		default:
+			fmt.Fprintf(&b, "  %s: %s -> %s\n", s.Name, oldVer, newVer)
+		}
+	}
+	return b.String()
+}
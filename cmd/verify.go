@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/hsnodgrass/pufctl/internal/helpers"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/internal/uitext"
+)
+
+var (
+	verifyModuledir string
+
+	verifyCmd = &cobra.Command{
+		Use:   uitext.VerifyUse,
+		Short: uitext.VerifyShort,
+		Long:  uitext.VerifyLong,
+		Args:  cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			parseOpts = helpers.ParseOptions{
+				Verbose:  helpers.MaxBools(viper.GetBool("always.verbose"), verbose),
+				GitRef:   viper.GetString("puppetfile_branch"),
+				SSHKey:   viper.GetString("auth.ssh_key"),
+				Username: viper.GetString("auth.username"),
+				Password: viper.GetString("auth.password"),
+				Token:    viper.GetString("auth.token"),
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pfilePath := viper.GetString("puppetfile")
+			puppetfile, err := helpers.Parse(pfilePath, parseOpts)
+			if err != nil {
+				logging.Errorln(err)
+			}
+			opts := moduledirOptions(pfilePath, puppetfile, verifyModuledir, 1)
+			dir := moduledir.Path(puppetfile, opts)
+			drift, err := moduledir.Verify(puppetfile, opts)
+			if err != nil {
+				logging.Errorf("Failed to verify moduledir %s with error: %v\n", dir, err)
+			}
+			if len(drift) < 1 {
+				fmt.Printf("No drift between %s and %s\n", pfilePath, dir)
+				return
+			}
+			fmt.Printf("Drift between %s and %s\n%s\n", pfilePath, dir, uitext.DashSep)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "MODULE\tDRIFT\tEXPECTED\tACTUAL\tFILES")
+			for _, d := range drift {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Name, d.Type, orNone(d.Expected), orNone(d.Actual), strings.Join(d.Files, ","))
+			}
+			w.Flush()
+			os.Exit(2)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVar(&verifyModuledir, "moduledir", "", "directory the modules are installed in (defaults to the Puppetfile's moduledir, or ./modules)")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -44,6 +44,17 @@ const AlwaysShow bool = false
 // AlwaysPreferGit is the default setting for the prefer-git flag
 const AlwaysPreferGit bool = false
 
+// AlwaysOffline is the default setting for the offline flag
+const AlwaysOffline bool = false
+
+// ForgeCacheTTL is the default time Forge responses are cached for before
+// they are revalidated
+const ForgeCacheTTL string = "1h"
+
+// ForgeDeprecatedModules is the default policy for adding Forge modules that
+// are deprecated, either "warn" or "refuse"
+const ForgeDeprecatedModules string = "warn"
+
 // DocGenPath is the default directory path where all docs will be generated
 const DocGenPath string = "./doc/"
 
@@ -59,11 +70,23 @@ var (
 	// SSHKeyPath is the default path for an SSH key
 	SSHKeyPath string = fmt.Sprintf("%s/.ssh/id_rsa", homeDir)
 
+	// ForgeCacheDir is the default directory Forge responses are cached in
+	ForgeCacheDir string = fmt.Sprintf("%s/.cache/pufctl/forge", homeDir)
+
 	// ForgeStrDefaults is a map of default values under the "forge" config key
 	// used in setting Viper defaults. The key user_agent is set at runtime.
 	ForgeStrDefaults = map[string]string{
-		"user_agent": "",
-		"api_url":    ForgeAPI,
+		"user_agent":         "",
+		"api_url":            ForgeAPI,
+		"cache_dir":          ForgeCacheDir,
+		"cache_ttl":          ForgeCacheTTL,
+		"token":              "",
+		"username":           "",
+		"password":           "",
+		"ca_file":            "",
+		"client_cert":        "",
+		"client_key":         "",
+		"deprecated_modules": ForgeDeprecatedModules,
 	}
 
 	//AlwaysBoolDefaults is a map of default values under the "always" config key
@@ -73,6 +96,7 @@ var (
 		"show":           AlwaysShow,
 		"prefer_git":     AlwaysPreferGit,
 		"write_in_place": AlwaysWriteInPlace,
+		"offline":        AlwaysOffline,
 	}
 
 	// AuthStrDefaults is a map of default values under the "auth" config key
@@ -0,0 +1,229 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+// VersionRequirementTag is the meta tag that records the version_requirement
+// of a module imported from a Bolt project, so it can be exported again
+const VersionRequirementTag = "version_requirement"
+
+// BoltResolveTag is the meta tag that records resolve: false for a module
+// imported from a Bolt project
+const BoltResolveTag = "bolt_resolve"
+
+// BoltModule is a module declared under modules in a bolt-project.yaml
+type BoltModule struct {
+	Name               string
+	VersionRequirement string
+	Git                string
+	Ref                string
+	// NoResolve is set if Bolt shouldn't resolve the module's dependencies
+	NoResolve bool
+}
+
+// ParseBoltProject returns the modules declared in a bolt-project.yaml.
+// Modules may be declared as a name, or as a map with name and
+// version_requirement for Forge modules, or git and ref for Git modules.
+func ParseBoltProject(data []byte) ([]BoltModule, error) {
+	var project struct {
+		Modules []interface{} `yaml:"modules"`
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("Failed to parse bolt-project.yaml with error: %w", err)
+	}
+	var mods []BoltModule
+	for i, entry := range project.Modules {
+		m, err := parseBoltModule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid module %d in bolt-project.yaml: %w", i+1, err)
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+func parseBoltModule(entry interface{}) (BoltModule, error) {
+	var m BoltModule
+	switch e := entry.(type) {
+	case string:
+		m.Name = e
+	case map[interface{}]interface{}:
+		for k, v := range e {
+			value := fmt.Sprint(v)
+			switch fmt.Sprint(k) {
+			case "name":
+				m.Name = value
+			case "version_requirement":
+				m.VersionRequirement = value
+			case "git":
+				m.Git = value
+			case "ref":
+				m.Ref = value
+			case "resolve":
+				m.NoResolve = value == "false"
+			}
+		}
+	default:
+		return m, fmt.Errorf("expected a name or a map, got %v", entry)
+	}
+	m.Name = ast.NormalizeName(strings.TrimSpace(m.Name))
+	switch {
+	case m.Git != "" && m.Ref == "":
+		return m, fmt.Errorf("Git module %s has no ref", m.Git)
+	case m.Git == "" && m.Name == "":
+		return m, fmt.Errorf("Forge module has no name")
+	case m.NoResolve && m.Name == "":
+		return m, fmt.Errorf("module %s has resolve: false, which requires a name", m.Git)
+	}
+	return m, nil
+}
+
+// BoltPuppetfile returns a sorted Puppetfile with the modules of a Bolt
+// project. Forge modules with an exact version are pinned to it. Forge
+// modules with a version range are pinned to the version chosen by resolve,
+// and tagged with the range. Git modules are added with their ref as :ref,
+// and named after their repository if they don't have a name.
+func BoltPuppetfile(mods []BoltModule, resolve func(name string, r semver.Range) (string, error)) (*ast.Puppetfile, error) {
+	puppetfile := &ast.Puppetfile{}
+	var tags []ast.ModuleMetadata
+	for _, m := range mods {
+		name := m.Name
+		var props []string
+		var meta ast.Metadata
+		if m.Git != "" {
+			if name == "" {
+				name = moduledir.DirName(strings.TrimSuffix(path.Base(m.Git), ".git"))
+			}
+			props = []string{fmt.Sprintf(":git=>%s", m.Git), fmt.Sprintf(":ref=>%s", m.Ref)}
+		} else if req := strings.TrimSpace(m.VersionRequirement); req != "" {
+			if v, err := semver.Make(strings.TrimPrefix(req, "=")); err == nil {
+				props = []string{v.String()}
+			} else {
+				r, err := semver.ParseRange(req)
+				if err != nil {
+					return nil, fmt.Errorf("Module %s has an invalid version_requirement: %w", name, err)
+				}
+				version, err := resolve(name, r)
+				if err != nil {
+					return nil, fmt.Errorf("Failed to resolve a version of %s with error: %w", name, err)
+				}
+				props = []string{version}
+				meta.MetaPairs = append(meta.MetaPairs, ast.MetaPair{Tag: VersionRequirementTag, Data: req})
+			}
+		}
+		if m.NoResolve {
+			meta.MetaPairs = append(meta.MetaPairs, ast.MetaPair{Tag: BoltResolveTag, Data: "false"})
+		}
+		if err := puppetfile.AddModule(name, props); err != nil {
+			return nil, err
+		}
+		tags = append(tags, ast.ModuleMetadata{Name: name, Metadata: meta})
+	}
+	for _, t := range tags {
+		for _, mp := range t.Metadata.MetaPairs {
+			if err := puppetfile.AddModuleMetadata(t.Name, mp.Tag, mp.Data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return puppetfile, puppetfile.ParseMetadata()
+}
+
+// BoltModules returns the modules of a Puppetfile as a Bolt project module
+// list, in the order of the Puppetfile. Forge modules keep the
+// version_requirement they were imported with if their pinned version still
+// satisfies it, and otherwise require their pinned version. Git modules
+// require a ref, Git modules without one and modules from other sources are
+// returned as skipped.
+func BoltModules(puppetfile *ast.Puppetfile) ([]yaml.MapSlice, []string) {
+	var mods []yaml.MapSlice
+	var skipped []string
+	for _, s := range puppetfile.Statements {
+		m := s.Module
+		if m == nil {
+			continue
+		}
+		meta := puppetfile.GetModuleMetadata(m.Name)
+		var entry yaml.MapSlice
+		switch diff.Source(m) {
+		case diff.Forge:
+			entry = yaml.MapSlice{{Key: "name", Value: ast.NormalizeName(m.Name)}}
+			if req := boltRequirement(m, meta); req != "" {
+				entry = append(entry, yaml.MapItem{Key: "version_requirement", Value: req})
+			}
+		case diff.Git:
+			ref := moduledir.GitRef(m)
+			if ref == "" {
+				skipped = append(skipped, m.Name)
+				continue
+			}
+			entry = yaml.MapSlice{
+				{Key: "name", Value: ast.NormalizeName(m.Name)},
+				{Key: "git", Value: m.GetPropertyValue(":git")},
+				{Key: "ref", Value: ref},
+			}
+		default:
+			skipped = append(skipped, m.Name)
+			continue
+		}
+		if p := meta.SearchByTag(BoltResolveTag); p != nil && p[0].Data == "false" {
+			entry = append(entry, yaml.MapItem{Key: "resolve", Value: false})
+		}
+		mods = append(mods, entry)
+	}
+	return mods, skipped
+}
+
+func boltRequirement(m *ast.Module, meta ast.Metadata) string {
+	req := ""
+	if p := meta.SearchByTag(VersionRequirementTag); p != nil {
+		req = p[0].Data
+	}
+	v, pinned := PinnedVersion(m)
+	if !pinned {
+		return req
+	}
+	if r, err := semver.ParseRange(req); req != "" && err == nil && r.Contains(v) {
+		return req
+	}
+	return v.String()
+}
+
+// ExportBoltProject sets the modules of a bolt-project.yaml to the modules
+// of the Puppetfile. Other keys are kept in their order, but comments are
+// not kept. The names of modules that could not be exported are returned.
+func ExportBoltProject(data []byte, puppetfile *ast.Puppetfile) ([]byte, []string, error) {
+	var project yaml.MapSlice
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse bolt-project.yaml with error: %w", err)
+	}
+	mods, skipped := BoltModules(puppetfile)
+	found := false
+	for i, item := range project {
+		if item.Key == "modules" {
+			project[i].Value = mods
+			found = true
+		}
+	}
+	if !found {
+		project = append(project, yaml.MapItem{Key: "modules", Value: mods})
+	}
+	out, err := yaml.Marshal(project)
+	if err != nil {
+		return nil, skipped, err
+	}
+	if strings.HasPrefix(string(data), "---") {
+		out = append([]byte("---\n"), out...)
+	}
+	return out, skipped, nil
+}
@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+const boltProject = `---
+name: myproject
+modules:
+  - puppetlabs-stdlib
+  - name: puppetlabs/apache
+    version_requirement: '5.5.0'
+  - name: puppetlabs-concat
+    version_requirement: '>= 4.0.0 < 7.0.0'
+  - git: https://github.com/puppetlabs/puppetlabs-ntp.git
+    ref: main
+  - name: fakeorg-profile
+    git: git@github.com:fakeorg/profile.git
+    ref: v1.0.0
+    resolve: false
+modulepath:
+  - modules
+  - site
+`
+
+func lowestVersion(name string, r semver.Range) (string, error) {
+	v, _ := r.Lowest()
+	return v.String(), nil
+}
+
+func TestBoltPuppetfile(t *testing.T) {
+	mods, err := ParseBoltProject([]byte(boltProject))
+	if err != nil {
+		t.Fatalf("Failed to parse Bolt project with error: %v", err)
+	}
+	puppetfile, err := BoltPuppetfile(mods, lowestVersion)
+	if err != nil {
+		t.Fatalf("Failed to generate Puppetfile with error: %v", err)
+	}
+	out := puppetfile.Sprint()
+	for _, expected := range []string{
+		"mod 'puppetlabs-stdlib'\n",
+		"mod 'puppetlabs-apache',\n  '5.5.0'",
+		"# @version_requirement: >= 4.0.0 < 7.0.0\nmod 'puppetlabs-concat',\n  '4.0.0'",
+		"mod 'ntp',\n  :git => 'https://github.com/puppetlabs/puppetlabs-ntp.git',\n  :ref => 'main'",
+		"# @bolt_resolve: false\nmod 'fakeorg-profile',",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected Puppetfile to contain:\n%s\nGot:\n%s", expected, out)
+		}
+	}
+}
+
+func TestParseBoltProjectInvalid(t *testing.T) {
+	for _, project := range []string{
+		"modules:\n  - git: https://github.com/fakeorg/ntp.git\n",
+		"modules:\n  - version_requirement: 1.0.0\n",
+		"modules:\n  - git: https://github.com/fakeorg/ntp.git\n    ref: main\n    resolve: false\n",
+	} {
+		if _, err := ParseBoltProject([]byte(project)); err == nil {
+			t.Errorf("Expected an error for Bolt project:\n%s", project)
+		}
+	}
+}
+
+func TestExportBoltProject(t *testing.T) {
+	mods, err := ParseBoltProject([]byte(boltProject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	puppetfile, err := BoltPuppetfile(mods, lowestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	puppetfile.GetModule("puppetlabs-apache").EditProperty("bare", "6.0.0")
+	if err := puppetfile.AddModule("localmod", []string{":local=>true"}); err != nil {
+		t.Fatal(err)
+	}
+	out, skipped, err := ExportBoltProject([]byte(boltProject), puppetfile)
+	if err != nil {
+		t.Fatalf("Failed to export Bolt project with error: %v", err)
+	}
+	expected := `---
+name: myproject
+modules:
+- name: fakeorg-profile
+  git: git@github.com:fakeorg/profile.git
+  ref: v1.0.0
+  resolve: false
+- name: ntp
+  git: https://github.com/puppetlabs/puppetlabs-ntp.git
+  ref: main
+- name: puppetlabs-apache
+  version_requirement: 6.0.0
+- name: puppetlabs-concat
+  version_requirement: '>= 4.0.0 < 7.0.0'
+- name: puppetlabs-stdlib
+modulepath:
+- modules
+- site
+`
+	if string(out) != expected {
+		t.Errorf("Expected bolt-project.yaml:\n%s\nGot:\n%s", expected, out)
+	}
+	if len(skipped) != 1 || skipped[0] != "localmod" {
+		t.Errorf("Expected localmod to be skipped, got: %v", skipped)
+	}
+}
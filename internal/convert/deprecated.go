@@ -0,0 +1,112 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// DeprecatedModule is a Forge module in a Puppetfile that is deprecated on
+// the Forge
+type DeprecatedModule struct {
+	// Name is the module's name in the Puppetfile
+	Name         string
+	Version      string
+	DeprecatedAt string
+	Reason       string
+	// Replacement is the slug of the module that supersedes it, if any
+	Replacement string
+}
+
+// ModuleRewrite is a deprecated module replaced by its replacement
+type ModuleRewrite struct {
+	Name        string
+	Replacement string
+	// Version is the version the replacement is pinned to, if the
+	// deprecated module was pinned
+	Version string
+	// Removed is set if the replacement was in the Puppetfile already, so
+	// the deprecated module was removed
+	Removed bool
+}
+
+// ForgeSlugs returns the normalized slugs of the Forge modules in a
+// Puppetfile
+func ForgeSlugs(puppetfile *ast.Puppetfile) []string {
+	var slugs []string
+	for _, s := range puppetfile.Statements {
+		if s.Module != nil && diff.Source(s.Module) == diff.Forge {
+			slugs = append(slugs, ast.NormalizeName(s.Module.Name))
+		}
+	}
+	return slugs
+}
+
+// DeprecatedModules returns the Forge modules of a Puppetfile that are
+// deprecated, in the order of the Puppetfile. The modules fetched from the
+// Forge are keyed by their normalized slug, and modules that weren't
+// fetched are left out.
+func DeprecatedModules(puppetfile *ast.Puppetfile, mods map[string]forgeapi.Module) []DeprecatedModule {
+	var deprecated []DeprecatedModule
+	for _, s := range puppetfile.Statements {
+		m := s.Module
+		if m == nil || diff.Source(m) != diff.Forge {
+			continue
+		}
+		fm, ok := mods[ast.NormalizeName(m.Name)]
+		if !ok || !fm.Deprecated() {
+			continue
+		}
+		deprecated = append(deprecated, DeprecatedModule{
+			Name:         m.Name,
+			Version:      m.GetPropertyValue("version"),
+			DeprecatedAt: fm.DeprecatedAt,
+			Reason:       fm.DeprecatedFor,
+			Replacement:  ast.NormalizeName(fm.SupersededBy.Slug),
+		})
+	}
+	return deprecated
+}
+
+// RewriteDeprecated replaces each deprecated module that has a replacement
+// with its replacement. Pinned modules are pinned to the version of the
+// replacement chosen by latest, which is given its slug, and unpinned
+// modules, including those on :latest, are left as they are. If the
+// replacement is in the Puppetfile already, the deprecated module is
+// removed.
+func RewriteDeprecated(puppetfile *ast.Puppetfile, deprecated []DeprecatedModule, latest func(slug string) (string, error)) ([]ModuleRewrite, error) {
+	var rewrites []ModuleRewrite
+	for _, d := range deprecated {
+		if d.Replacement == "" {
+			continue
+		}
+		rewrite := ModuleRewrite{Name: d.Name, Replacement: d.Replacement}
+		if puppetfile.GetModule(d.Replacement) != nil {
+			if err := puppetfile.RemoveModule(d.Name); err != nil {
+				return rewrites, err
+			}
+			rewrite.Removed = true
+			rewrites = append(rewrites, rewrite)
+			continue
+		}
+		m := puppetfile.GetModule(d.Name)
+		if m == nil {
+			return rewrites, fmt.Errorf("Module %s can't be found in the Puppetfile", d.Name)
+		}
+		if d.Version != "" && d.Version != ":latest" {
+			version, err := latest(d.Replacement)
+			if err != nil {
+				return rewrites, fmt.Errorf("Failed to get the latest version of %s with error: %w", d.Replacement, err)
+			}
+			m.EditProperty("bare", version)
+			rewrite.Version = version
+		}
+		if err := puppetfile.RenameModule(m.Name, d.Replacement); err != nil {
+			return rewrites, err
+		}
+		rewrites = append(rewrites, rewrite)
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,141 @@
+package convert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+const deprecatedPuppetfile = `
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs/firewall'
+
+mod 'puppetlabs-apt', :latest
+
+mod 'puppetlabs-translate', '2.2.0'
+
+mod 'puppetlabs-ntp', '8.0.0'
+
+mod 'puppetlabs-legacy',
+  :git => 'https://github.com/puppetlabs/puppetlabs-legacy.git',
+  :tag => 'v1.0.0'
+`
+
+var deprecatedForge = map[string]forgeapi.Module{
+	"puppetlabs-stdlib": {Slug: "puppetlabs-stdlib"},
+	"puppetlabs-firewall": {
+		Slug:          "puppetlabs-firewall",
+		DeprecatedAt:  "2021-01-01 00:00:00 -0700",
+		DeprecatedFor: "Moved to a new namespace.",
+		SupersededBy:  forgeapi.ModuleMinimal{Slug: "puppet-firewall"},
+	},
+	"puppetlabs-apt": {
+		Slug:         "puppetlabs-apt",
+		DeprecatedAt: "2021-05-01 00:00:00 -0700",
+		SupersededBy: forgeapi.ModuleMinimal{Slug: "puppet-apt"},
+	},
+	"puppetlabs-translate": {
+		Slug:          "puppetlabs-translate",
+		DeprecatedAt:  "2021-02-01 00:00:00 -0700",
+		DeprecatedFor: "No longer maintained",
+	},
+	"puppetlabs-ntp": {
+		Slug:         "puppetlabs-ntp",
+		DeprecatedAt: "2021-03-01 00:00:00 -0700",
+		SupersededBy: forgeapi.ModuleMinimal{Slug: "puppetlabs/stdlib"},
+	},
+	"puppetlabs-legacy": {
+		Slug:         "puppetlabs-legacy",
+		DeprecatedAt: "2021-04-01 00:00:00 -0700",
+	},
+}
+
+func TestDeprecatedModules(t *testing.T) {
+	puppetfile, err := ast.Parse(deprecatedPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	deprecated := DeprecatedModules(puppetfile, deprecatedForge)
+	expected := []DeprecatedModule{
+		{Name: "puppetlabs-apt", Version: ":latest", DeprecatedAt: "2021-05-01 00:00:00 -0700", Replacement: "puppet-apt"},
+		{Name: "puppetlabs-ntp", Version: "8.0.0", DeprecatedAt: "2021-03-01 00:00:00 -0700", Replacement: "puppetlabs-stdlib"},
+		{Name: "puppetlabs-translate", Version: "2.2.0", DeprecatedAt: "2021-02-01 00:00:00 -0700", Reason: "No longer maintained"},
+		{Name: "puppetlabs/firewall", DeprecatedAt: "2021-01-01 00:00:00 -0700", Reason: "Moved to a new namespace.", Replacement: "puppet-firewall"},
+	}
+	if fmt.Sprint(deprecated) != fmt.Sprint(expected) {
+		t.Errorf("Expected deprecated modules %v, got %v", expected, deprecated)
+	}
+}
+
+func TestRewriteDeprecated(t *testing.T) {
+	puppetfile, err := ast.Parse(deprecatedPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	var asked []string
+	latest := func(slug string) (string, error) {
+		asked = append(asked, slug)
+		return "3.0.0", nil
+	}
+	rewrites, err := RewriteDeprecated(puppetfile, DeprecatedModules(puppetfile, deprecatedForge), latest)
+	if err != nil {
+		t.Fatalf("Failed to rewrite deprecated modules with error: %v", err)
+	}
+	expected := []ModuleRewrite{
+		{Name: "puppetlabs-apt", Replacement: "puppet-apt"},
+		{Name: "puppetlabs-ntp", Replacement: "puppetlabs-stdlib", Removed: true},
+		{Name: "puppetlabs/firewall", Replacement: "puppet-firewall"},
+	}
+	if fmt.Sprint(rewrites) != fmt.Sprint(expected) {
+		t.Errorf("Expected rewrites %v, got %v", expected, rewrites)
+	}
+	if len(asked) != 0 {
+		t.Errorf("Expected no versions to be looked up for unpinned modules, got %v", asked)
+	}
+	if m := puppetfile.GetModule("puppet-apt"); m == nil || m.GetPropertyValue("version") != ":latest" {
+		t.Errorf("Expected puppet-apt to replace puppetlabs-apt on :latest, got %v", m)
+	}
+	if m := puppetfile.GetModule("puppet-firewall"); m == nil || m.GetPropertyValue("version") != "" {
+		t.Errorf("Expected puppet-firewall to replace puppetlabs/firewall unpinned, got %v", m)
+	}
+	for _, name := range []string{"puppetlabs-apt", "puppetlabs/firewall", "puppetlabs-ntp"} {
+		if puppetfile.GetModule(name) != nil {
+			t.Errorf("Expected %s to be removed from the Puppetfile", name)
+		}
+	}
+	if m := puppetfile.GetModule("puppetlabs-translate"); m == nil {
+		t.Errorf("Expected puppetlabs-translate, which has no replacement, to be kept")
+	}
+}
+
+func TestRewriteDeprecatedPinned(t *testing.T) {
+	puppetfile, err := ast.Parse("mod 'puppetlabs-firewall', '2.8.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	latest := func(slug string) (string, error) {
+		if slug != "puppet-firewall" {
+			return "", fmt.Errorf("unexpected slug %s", slug)
+		}
+		return "3.0.0", nil
+	}
+	rewrites, err := RewriteDeprecated(puppetfile, DeprecatedModules(puppetfile, deprecatedForge), latest)
+	if err != nil {
+		t.Fatalf("Failed to rewrite deprecated modules with error: %v", err)
+	}
+	if len(rewrites) != 1 || rewrites[0].Version != "3.0.0" {
+		t.Errorf("Expected puppetlabs-firewall to be rewritten at 3.0.0, got %v", rewrites)
+	}
+	if m := puppetfile.GetModule("puppet-firewall"); m == nil || m.GetPropertyValue("version") != "3.0.0" {
+		t.Errorf("Expected puppet-firewall to be pinned to 3.0.0, got %v", m)
+	}
+
+	failing := func(string) (string, error) { return "", fmt.Errorf("offline") }
+	puppetfile, _ = ast.Parse("mod 'puppetlabs-firewall', '2.8.0'\n")
+	if _, err := RewriteDeprecated(puppetfile, DeprecatedModules(puppetfile, deprecatedForge), failing); err == nil {
+		t.Errorf("Expected an error when the replacement's version can't be found")
+	}
+}
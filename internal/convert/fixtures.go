@@ -0,0 +1,214 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hsnodgrass/pufctl/internal/moduledir"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// SourceDir is the rspec-puppet fixtures path of the module under test
+const SourceDir = "#{source_dir}"
+
+// FixturesOptions holds options used when generating .fixtures.yml files
+type FixturesOptions struct {
+	// Modules limits the fixtures to the dependency closure of these
+	// modules, including the modules themselves. If empty, all modules
+	// in the Puppetfile are fixtures.
+	Modules []string
+	// Symlink adds a symlink to the source directory for each of Modules,
+	// instead of fetching them, for testing the modules themselves.
+	Symlink bool
+	// Dependencies returns the names of the dependencies of a module. It
+	// is required if Modules is set.
+	Dependencies func(m *ast.Module) ([]string, error)
+}
+
+// FixturesResult holds a generated .fixtures.yml file
+type FixturesResult struct {
+	Fixtures yaml.MapSlice
+	// Missing holds dependencies that aren't in the Puppetfile
+	Missing []string
+	// Skipped holds modules whose source can't be used as a fixture
+	Skipped []string
+}
+
+// Fixtures returns the rspec-puppet .fixtures.yml for the Puppetfile.
+// Forge modules are added to forge_modules with their pinned version as
+// the ref. Git modules are added to repositories with their :commit,
+// :tag, or :ref as the ref, or their :branch as the branch. Fixtures are
+// keyed by the name of the directory the module is installed into, and
+// sorted by it.
+func Fixtures(puppetfile *ast.Puppetfile, opts FixturesOptions) (FixturesResult, error) {
+	var result FixturesResult
+	mods := map[string]*ast.Module{}
+	for _, s := range puppetfile.Statements {
+		if s.Module != nil {
+			mods[ast.NormalizeName(s.Module.Name)] = s.Module
+		}
+	}
+	selected := mods
+	if len(opts.Modules) > 0 {
+		var err error
+		selected, result.Missing, err = closure(mods, opts.Modules, opts.Dependencies)
+		if err != nil {
+			return result, err
+		}
+	}
+	var forgeMods, repos, symlinks yaml.MapSlice
+	rootNames := map[string]bool{}
+	if opts.Symlink {
+		for _, name := range opts.Modules {
+			rootNames[moduledir.DirName(name)] = true
+			symlinks = append(symlinks, yaml.MapItem{Key: moduledir.DirName(name), Value: SourceDir})
+		}
+	}
+	for _, name := range sortedModuleNames(selected) {
+		m := selected[name]
+		dir := moduledir.DirName(m.Name)
+		if rootNames[dir] {
+			continue
+		}
+		switch diff.Source(m) {
+		case diff.Forge:
+			forgeMods = append(forgeMods, yaml.MapItem{Key: dir, Value: forgeFixture(m)})
+		case diff.Git:
+			repos = append(repos, yaml.MapItem{Key: dir, Value: gitFixture(m)})
+		default:
+			result.Skipped = append(result.Skipped, m.Name)
+		}
+	}
+	sortMapSlice(forgeMods)
+	sortMapSlice(repos)
+	sortMapSlice(symlinks)
+	var fixtures yaml.MapSlice
+	if len(forgeMods) > 0 {
+		fixtures = append(fixtures, yaml.MapItem{Key: "forge_modules", Value: forgeMods})
+	}
+	if len(repos) > 0 {
+		fixtures = append(fixtures, yaml.MapItem{Key: "repositories", Value: repos})
+	}
+	if len(symlinks) > 0 {
+		fixtures = append(fixtures, yaml.MapItem{Key: "symlinks", Value: symlinks})
+	}
+	result.Fixtures = yaml.MapSlice{{Key: "fixtures", Value: fixtures}}
+	return result, nil
+}
+
+// YAML returns the .fixtures.yml file contents
+func (r FixturesResult) YAML() ([]byte, error) {
+	out, err := yaml.Marshal(r.Fixtures)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("---\n"), out...), nil
+}
+
+func forgeFixture(m *ast.Module) interface{} {
+	repo := strings.Replace(ast.NormalizeName(m.Name), "-", "/", 1)
+	version := m.GetPropertyValue("version")
+	if version == "" || version == ":latest" {
+		return repo
+	}
+	return yaml.MapSlice{{Key: "repo", Value: repo}, {Key: "ref", Value: version}}
+}
+
+func gitFixture(m *ast.Module) interface{} {
+	repo := m.GetPropertyValue(":git")
+	for _, k := range []string{":commit", ":tag", ":ref"} {
+		if v := m.GetPropertyValue(k); v != "" {
+			return yaml.MapSlice{{Key: "repo", Value: repo}, {Key: "ref", Value: v}}
+		}
+	}
+	for _, k := range []string{":branch", ":default_branch"} {
+		if v := m.GetPropertyValue(k); v != "" {
+			return yaml.MapSlice{{Key: "repo", Value: repo}, {Key: "branch", Value: v}}
+		}
+	}
+	return repo
+}
+
+// closure returns the modules in roots and all of their dependencies,
+// found by name in mods. Dependencies that aren't in mods are returned
+// as missing. Roots that aren't in mods are looked up by name only, and
+// aren't included.
+func closure(mods map[string]*ast.Module, roots []string, deps func(m *ast.Module) ([]string, error)) (map[string]*ast.Module, []string, error) {
+	if deps == nil {
+		return nil, nil, fmt.Errorf("A dependency lookup is required to find the dependency closure")
+	}
+	selected := map[string]*ast.Module{}
+	var missing []string
+	seenMissing := map[string]bool{}
+	var queue []string
+	for _, r := range roots {
+		if _, m := findByName(mods, r); m != nil {
+			queue = append(queue, r)
+			continue
+		}
+		// The module under test doesn't have to be in the Puppetfile
+		names, err := deps(&ast.Module{Name: ast.NormalizeName(r)})
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get dependencies of %s with error: %w", r, err)
+		}
+		queue = append(queue, names...)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		key, m := findByName(mods, name)
+		if m == nil {
+			if !seenMissing[name] {
+				seenMissing[name] = true
+				missing = append(missing, ast.NormalizeName(name))
+			}
+			continue
+		}
+		if _, ok := selected[key]; ok {
+			continue
+		}
+		selected[key] = m
+		names, err := deps(m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get dependencies of %s with error: %w", m.Name, err)
+		}
+		queue = append(queue, names...)
+	}
+	sort.Strings(missing)
+	return selected, missing, nil
+}
+
+// findByName finds a module by its full name, or by the name of its
+// directory for modules without an owner, such as most Git modules
+func findByName(mods map[string]*ast.Module, name string) (string, *ast.Module) {
+	key := ast.NormalizeName(name)
+	if m, ok := mods[key]; ok {
+		return key, m
+	}
+	for k, m := range mods {
+		if strings.Contains(k, "-") && strings.Contains(key, "-") {
+			continue
+		}
+		if moduledir.DirName(k) == moduledir.DirName(key) {
+			return k, m
+		}
+	}
+	return "", nil
+}
+
+func sortedModuleNames(mods map[string]*ast.Module) []string {
+	names := make([]string, 0, len(mods))
+	for n := range mods {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortMapSlice(s yaml.MapSlice) {
+	sort.SliceStable(s, func(i, j int) bool { return fmt.Sprint(s[i].Key) < fmt.Sprint(s[j].Key) })
+}
@@ -0,0 +1,123 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+const fixturesPuppetfile = `
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '4.1.0'
+
+mod 'puppetlabs-apache', :latest
+
+mod 'profile',
+  :git => 'https://github.com/fakeorg/profile.git',
+  :branch => 'main'
+
+mod 'fakeorg-ntp',
+  :git => 'https://github.com/fakeorg/ntp.git',
+  :tag => 'v1.0.0'
+`
+
+var fixturesDependencies = map[string][]string{
+	"profile":           {"puppetlabs/concat", "fakeorg/ntp"},
+	"puppetlabs-concat": {"puppetlabs/stdlib", "puppetlabs/translate"},
+}
+
+func fixturesDeps(m *ast.Module) ([]string, error) {
+	return fixturesDependencies[m.Name], nil
+}
+
+func TestFixtures(t *testing.T) {
+	puppetfile, err := ast.Parse(fixturesPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	result, err := Fixtures(puppetfile, FixturesOptions{})
+	if err != nil {
+		t.Fatalf("Failed to generate fixtures with error: %v", err)
+	}
+	out, err := result.YAML()
+	if err != nil {
+		t.Fatalf("Failed to marshal fixtures with error: %v", err)
+	}
+	expected := `---
+fixtures:
+  forge_modules:
+    apache: puppetlabs/apache
+    concat:
+      repo: puppetlabs/concat
+      ref: 4.1.0
+    stdlib:
+      repo: puppetlabs/stdlib
+      ref: 6.0.0
+  repositories:
+    ntp:
+      repo: https://github.com/fakeorg/ntp.git
+      ref: v1.0.0
+    profile:
+      repo: https://github.com/fakeorg/profile.git
+      branch: main
+`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestFixturesClosure(t *testing.T) {
+	puppetfile, err := ast.Parse(fixturesPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	result, err := Fixtures(puppetfile, FixturesOptions{Modules: []string{"profile"}, Symlink: true, Dependencies: fixturesDeps})
+	if err != nil {
+		t.Fatalf("Failed to generate fixtures with error: %v", err)
+	}
+	out, err := result.YAML()
+	if err != nil {
+		t.Fatalf("Failed to marshal fixtures with error: %v", err)
+	}
+	if strings.Contains(string(out), "apache") {
+		t.Errorf("Expected modules outside of the dependency closure to be left out, got:\n%s", out)
+	}
+	for _, e := range []string{"concat:", "stdlib:", "ntp:", "symlinks:\n    profile: '#{source_dir}'"} {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("Expected fixtures to contain %s, got:\n%s", e, out)
+		}
+	}
+	if strings.Contains(string(out), "repo: https://github.com/fakeorg/profile.git") {
+		t.Errorf("Expected the symlinked module not to be fetched")
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != "puppetlabs-translate" {
+		t.Errorf("Expected puppetlabs-translate to be missing, got: %v", result.Missing)
+	}
+}
+
+func TestFixturesModuleUnderTest(t *testing.T) {
+	puppetfile, err := ast.Parse(fixturesPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	deps := func(m *ast.Module) ([]string, error) {
+		if m.Name == "fakeorg-mymodule" {
+			return []string{"puppetlabs/stdlib"}, nil
+		}
+		return fixturesDeps(m)
+	}
+	result, err := Fixtures(puppetfile, FixturesOptions{Modules: []string{"fakeorg/mymodule"}, Symlink: true, Dependencies: deps})
+	if err != nil {
+		t.Fatalf("Failed to generate fixtures with error: %v", err)
+	}
+	out, err := result.YAML()
+	if err != nil {
+		t.Fatalf("Failed to marshal fixtures with error: %v", err)
+	}
+	expected := "---\nfixtures:\n  forge_modules:\n    stdlib:\n      repo: puppetlabs/stdlib\n      ref: 6.0.0\n  symlinks:\n    mymodule: '#{source_dir}'\n"
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
@@ -0,0 +1,270 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+// DependencyChange is a change made to a dependency while syncing a
+// metadata.json with a Puppetfile
+type DependencyChange struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// DependencyMismatch is a dependency whose version in the Puppetfile
+// doesn't satisfy its version_requirement in metadata.json
+type DependencyMismatch struct {
+	Name        string
+	Version     string
+	Requirement string
+}
+
+// ParseMetadata parses the contents of a metadata.json file
+func ParseMetadata(data []byte) (forgeapi.ModuleMetadata, error) {
+	var meta forgeapi.ModuleMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("Failed to parse metadata.json with error: %w", err)
+	}
+	return meta, nil
+}
+
+// PinnedVersion returns the version a module is pinned to in a Puppetfile.
+// Forge modules are pinned by their version, and Git modules by a :tag
+// that is a semantic version. Modules on :latest or a branch aren't pinned.
+func PinnedVersion(m *ast.Module) (semver.SemVer, bool) {
+	var version string
+	switch diff.Source(m) {
+	case diff.Forge:
+		version = m.GetPropertyValue("version")
+	case diff.Git:
+		version = m.GetPropertyValue(":tag")
+	}
+	sv, err := semver.Make(version)
+	if err != nil {
+		return semver.SemVer{}, false
+	}
+	return sv, true
+}
+
+// AddDependencies adds a Forge module to the Puppetfile for each dependency
+// in the metadata that isn't in it already. The version of each added
+// module is chosen by resolve, which is given the dependency's name and
+// version_requirement. Dependencies that are in the Puppetfile, but at a
+// version that doesn't satisfy their version_requirement, are returned as
+// mismatched and left as they are.
+func AddDependencies(puppetfile *ast.Puppetfile, meta forgeapi.ModuleMetadata, resolve func(name string, r semver.Range) (string, error)) ([]DependencyChange, []DependencyMismatch, error) {
+	var added []DependencyChange
+	var mismatched []DependencyMismatch
+	for _, d := range meta.Dependencies {
+		name := ast.NormalizeName(d.Name)
+		r, err := semver.ParseRange(d.VersionRequirement)
+		if err != nil {
+			return added, mismatched, fmt.Errorf("Dependency %s has an invalid version_requirement: %w", d.Name, err)
+		}
+		if m := puppetfile.GetModule(name); m != nil {
+			if v, ok := PinnedVersion(m); ok && !r.Contains(v) {
+				mismatched = append(mismatched, DependencyMismatch{Name: m.Name, Version: v.String(), Requirement: d.VersionRequirement})
+			}
+			continue
+		}
+		version, err := resolve(name, r)
+		if err != nil {
+			return added, mismatched, fmt.Errorf("Failed to resolve a version of %s with error: %w", name, err)
+		}
+		if err := puppetfile.AddModule(name, []string{version}); err != nil {
+			return added, mismatched, err
+		}
+		added = append(added, DependencyChange{Name: name, New: version})
+	}
+	return added, mismatched, nil
+}
+
+// UpdateRequirements sets the version_requirement of each dependency in a
+// metadata.json that is pinned in the Puppetfile to allow versions from the
+// pinned version up to the next major version. Only the version_requirement
+// values are rewritten, the rest of the file is kept byte for byte.
+func UpdateRequirements(data []byte, puppetfile *ast.Puppetfile) ([]byte, []DependencyChange, error) {
+	deps, err := dependencySpans(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse metadata.json with error: %w", err)
+	}
+	var changes []DependencyChange
+	var edits []jsonEdit
+	for _, d := range deps {
+		m := puppetfile.GetModule(d.name)
+		if m == nil {
+			continue
+		}
+		v, ok := PinnedVersion(m)
+		if !ok {
+			continue
+		}
+		req := semver.MajorRange(v)
+		if req == d.requirement {
+			continue
+		}
+		value, err := jsonString(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case d.reqStart >= 0:
+			edits = append(edits, jsonEdit{start: d.reqStart, end: d.reqEnd, text: value})
+		case d.empty:
+			edits = append(edits, jsonEdit{start: d.insertAt, end: d.insertAt, text: fmt.Sprintf(`"version_requirement": %s`, value)})
+		default:
+			edits = append(edits, jsonEdit{start: d.insertAt, end: d.insertAt, text: fmt.Sprintf(`, "version_requirement": %s`, value)})
+		}
+		changes = append(changes, DependencyChange{Name: d.name, Old: d.requirement, New: req})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte{}, data...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out, changes, nil
+}
+
+type jsonEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// jsonString encodes a string as JSON without escaping < and >, which are
+// common in version requirements
+func jsonString(s string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// dependencySpan is the location of a dependency in a metadata.json file.
+// reqStart and reqEnd hold the offsets of the version_requirement value,
+// or -1 if the dependency doesn't have one. insertAt is the offset after
+// the last value of the dependency.
+type dependencySpan struct {
+	name        string
+	requirement string
+	reqStart    int
+	reqEnd      int
+	insertAt    int
+	empty       bool
+}
+
+func dependencySpans(data []byte) ([]dependencySpan, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	var deps []dependencySpan
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "dependencies" {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			d, err := dependency(data, dec)
+			if err != nil {
+				return nil, err
+			}
+			deps = append(deps, d)
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+	return deps, expectDelim(dec, '}')
+}
+
+func dependency(data []byte, dec *json.Decoder) (dependencySpan, error) {
+	d := dependencySpan{reqStart: -1, reqEnd: -1, empty: true}
+	if err := expectDelim(dec, '{'); err != nil {
+		return d, err
+	}
+	d.insertAt = int(dec.InputOffset())
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return d, err
+		}
+		start := int(dec.InputOffset())
+		switch key {
+		case "name", "version_requirement":
+			value, err := dec.Token()
+			if err != nil {
+				return d, err
+			}
+			s, _ := value.(string)
+			if key == "name" {
+				d.name = s
+				break
+			}
+			d.requirement = s
+			d.reqEnd = int(dec.InputOffset())
+			// Skip the separator between the key and the value
+			d.reqStart = start + len(data[start:d.reqEnd]) - len(bytes.TrimLeft(data[start:d.reqEnd], ": \t\r\n"))
+		default:
+			if err := skipValue(dec); err != nil {
+				return d, err
+			}
+		}
+		d.insertAt = int(dec.InputOffset())
+		d.empty = false
+	}
+	return d, expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+	return nil
+}
+
+// skipValue reads the next value, including all values nested in it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
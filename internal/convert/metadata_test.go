@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+const syncMetadata = `{
+  "name": "fakeorg-profile",
+  "version": "1.0.0",
+  "summary": "Keeps <this> as is",
+  "dependencies": [
+    {
+      "name": "puppetlabs/stdlib",
+      "version_requirement": ">= 4.13.1 < 7.0.0"
+    },
+    { "name": "puppetlabs/concat" },
+    {
+      "name": "fakeorg/ntp",
+      "version_requirement": ">= 1.0.0 < 2.0.0"
+    },
+    {
+      "name": "puppetlabs/translate",
+      "version_requirement": ">= 1.0.0 < 2.0.0"
+    }
+  ],
+  "tags": ["profile"]
+}
+`
+
+const syncPuppetfile = `
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '4.1.0'
+
+mod 'fakeorg-ntp',
+  :git => 'https://github.com/fakeorg/ntp.git',
+  :tag => 'v1.2.0'
+`
+
+func TestUpdateRequirements(t *testing.T) {
+	puppetfile, err := ast.Parse(syncPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	out, changes, err := UpdateRequirements([]byte(syncMetadata), puppetfile)
+	if err != nil {
+		t.Fatalf("Failed to update requirements with error: %v", err)
+	}
+	expected := `{
+  "name": "fakeorg-profile",
+  "version": "1.0.0",
+  "summary": "Keeps <this> as is",
+  "dependencies": [
+    {
+      "name": "puppetlabs/stdlib",
+      "version_requirement": ">= 6.0.0 < 7.0.0"
+    },
+    { "name": "puppetlabs/concat", "version_requirement": ">= 4.1.0 < 5.0.0" },
+    {
+      "name": "fakeorg/ntp",
+      "version_requirement": ">= 1.2.0 < 2.0.0"
+    },
+    {
+      "name": "puppetlabs/translate",
+      "version_requirement": ">= 1.0.0 < 2.0.0"
+    }
+  ],
+  "tags": ["profile"]
+}
+`
+	if string(out) != expected {
+		t.Errorf("Expected metadata.json:\n%s\nGot:\n%s", expected, out)
+	}
+	if len(changes) != 3 || changes[1].Old != "" || changes[1].New != ">= 4.1.0 < 5.0.0" {
+		t.Errorf("Expected changes to stdlib, concat, and ntp, got: %+v", changes)
+	}
+	if _, err := ParseMetadata(out); err != nil {
+		t.Errorf("Expected valid JSON, got error: %v", err)
+	}
+}
+
+func TestAddDependencies(t *testing.T) {
+	puppetfile, err := ast.Parse("mod 'puppetlabs-stdlib', '4.0.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	meta, err := ParseMetadata([]byte(syncMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(name string, r semver.Range) (string, error) {
+		v, ok := r.Lowest()
+		if !ok {
+			return "", fmt.Errorf("no lower bound")
+		}
+		return v.String(), nil
+	}
+	_, _, err = AddDependencies(puppetfile, meta, resolve)
+	if err == nil {
+		t.Errorf("Expected an error for a dependency that can't be resolved")
+	}
+	puppetfile, _ = ast.Parse("mod 'puppetlabs-stdlib', '4.0.0'\n\nmod 'puppetlabs-concat', '4.1.0'\n")
+	added, mismatched, err := AddDependencies(puppetfile, meta, resolve)
+	if err != nil {
+		t.Fatalf("Failed to add dependencies with error: %v", err)
+	}
+	if len(added) != 2 || added[0].Name != "fakeorg-ntp" || added[1].New != "1.0.0" {
+		t.Errorf("Expected ntp and translate to be added, got: %+v", added)
+	}
+	if m := puppetfile.GetModule("puppetlabs-translate"); m == nil || m.GetPropertyValue("version") != "1.0.0" {
+		t.Errorf("Expected puppetlabs-translate 1.0.0 in the Puppetfile")
+	}
+	if len(mismatched) != 1 || mismatched[0].Name != "puppetlabs-stdlib" {
+		t.Errorf("Expected stdlib 4.0.0 to not satisfy its requirement, got: %+v", mismatched)
+	}
+}
@@ -0,0 +1,219 @@
+// Package convert converts between Puppetfiles and the module lists of
+// other Puppet tools.
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/internal/validators"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// ModulepathTag is the meta tag that records the modulepath an imported
+// module was found in
+const ModulepathTag = "modulepath"
+
+// GitSourceTag is the meta tag that records the Git source of an imported
+// module, taken from the source in the module's metadata
+const GitSourceTag = "git_source"
+
+var (
+	// Ex. puppetlabs-stdlib (v6.0.0)
+	reListedVersion = regexp.MustCompile(`^(\S+)\s*\(v?([^)\s]+)\)`)
+	// Ex. Module stdlib(/etc/puppetlabs/code/modules/stdlib)
+	reListedPath = regexp.MustCompile(`^Module\s+(\S+?)\((.*)\)$`)
+)
+
+// ListedModule is a module found in the output of puppet module list
+type ListedModule struct {
+	Name       string
+	Version    string
+	Path       string
+	Modulepath string
+	// GitSource is set if the source in the module's metadata is a Git URL
+	GitSource string
+}
+
+// listedModuleJSON holds the fields of a module object that are used
+type listedModuleJSON struct {
+	Name      string                   `json:"name"`
+	ForgeName string                   `json:"forge_name"`
+	Version   string                   `json:"version"`
+	Path      string                   `json:"path"`
+	Source    string                   `json:"source"`
+	Metadata  *forgeapi.ModuleMetadata `json:"metadata"`
+}
+
+// ParseModuleList parses the output of puppet module list --render-as json.
+// Modules are grouped by modulepath under modules_by_path, and are returned
+// in the order of the output. The parser is tolerant of the different ways
+// Puppet versions render modules: as strings, such as
+// "puppetlabs-stdlib (v6.0.0)", or as objects with name, forge_name,
+// version, path, and metadata fields. An object of modulepaths without
+// modules_by_path is also accepted.
+func ParseModuleList(data []byte) ([]ListedModule, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("Failed to decode module list with error: %w", err)
+	}
+	byPath := json.RawMessage(data)
+	if raw, ok := top["modules_by_path"]; ok {
+		byPath = raw
+	}
+	paths, err := orderedKeys(byPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode modules_by_path with error: %w", err)
+	}
+	var grouped map[string]json.RawMessage
+	if err := json.Unmarshal(byPath, &grouped); err != nil {
+		return nil, fmt.Errorf("Failed to decode modules_by_path with error: %w", err)
+	}
+	var mods []ListedModule
+	for _, p := range paths {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(grouped[p], &entries); err != nil {
+			// Not a modulepath, Ex. environment
+			continue
+		}
+		for _, e := range entries {
+			m, err := parseListedModule(e)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode module in %s with error: %w", p, err)
+			}
+			m.Modulepath = p
+			mods = append(mods, m)
+		}
+	}
+	return mods, nil
+}
+
+func parseListedModule(raw json.RawMessage) (ListedModule, error) {
+	var m ListedModule
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		if match := reListedVersion.FindStringSubmatch(str); match != nil {
+			m.Name, m.Version = match[1], match[2]
+		} else if match := reListedPath.FindStringSubmatch(str); match != nil {
+			m.Name, m.Path = match[1], match[2]
+		} else {
+			m.Name = strings.TrimSpace(str)
+		}
+		m.Name = ast.NormalizeName(m.Name)
+		return m, nil
+	}
+	var obj listedModuleJSON
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return m, err
+	}
+	m.Name, m.Version, m.Path = obj.ForgeName, obj.Version, obj.Path
+	source := obj.Source
+	if obj.Metadata != nil {
+		if m.Name == "" {
+			m.Name = obj.Metadata.Name
+		}
+		if m.Version == "" {
+			m.Version = obj.Metadata.Version
+		}
+		if source == "" {
+			source = obj.Metadata.Source
+		}
+	}
+	if m.Name == "" {
+		m.Name = obj.Name
+	}
+	if m.Name == "" {
+		return m, fmt.Errorf("module has no name")
+	}
+	m.Name = ast.NormalizeName(m.Name)
+	m.Version = strings.TrimPrefix(m.Version, "v")
+	if validators.IsGitURL(source) || validators.IsGitURLNoSuffix(source) {
+		m.GitSource = source
+	}
+	return m, nil
+}
+
+// ModuleListPuppetfile returns a sorted Puppetfile with the listed modules
+// pinned to their versions. Each module is tagged with its modulepath, and
+// with its Git source if it has one. If a module is found in more than one
+// modulepath, only the first is used, the same as Puppet. If modulepath is
+// not empty, only modules in that modulepath are added.
+func ModuleListPuppetfile(mods []ListedModule, modulepath string) (*ast.Puppetfile, []ListedModule, error) {
+	puppetfile := &ast.Puppetfile{}
+	var added, shadowed []ListedModule
+	seen := map[string]bool{}
+	for _, m := range mods {
+		if modulepath != "" && m.Modulepath != modulepath {
+			continue
+		}
+		key := strings.ToLower(m.Name)
+		if seen[key] {
+			shadowed = append(shadowed, m)
+			continue
+		}
+		seen[key] = true
+		var props []string
+		if m.Version != "" {
+			props = []string{m.Version}
+		}
+		if err := puppetfile.AddModule(m.Name, props); err != nil {
+			return nil, shadowed, err
+		}
+		added = append(added, m)
+	}
+	for _, m := range added {
+		if m.GitSource != "" {
+			if err := puppetfile.AddModuleMetadata(m.Name, GitSourceTag, m.GitSource); err != nil {
+				return nil, shadowed, err
+			}
+		}
+		if err := puppetfile.AddModuleMetadata(m.Name, ModulepathTag, m.Modulepath); err != nil {
+			return nil, shadowed, err
+		}
+	}
+	return puppetfile, shadowed, puppetfile.ParseMetadata()
+}
+
+// orderedKeys returns the keys of a JSON object in the order they appear
+func orderedKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := t.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a JSON object key")
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Modulepaths returns the unique modulepaths of the listed modules, sorted
+func Modulepaths(mods []ListedModule) []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, m := range mods {
+		if !seen[m.Modulepath] {
+			seen[m.Modulepath] = true
+			paths = append(paths, m.Modulepath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,115 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+const moduleListJSON = `{
+  "environment": "production",
+  "modules_by_path": {
+    "/etc/puppetlabs/code/environments/production/modules": [
+      "puppetlabs-stdlib (v6.0.0)",
+      {
+        "name": "concat",
+        "forge_name": "puppetlabs/concat",
+        "version": "4.1.0",
+        "path": "/etc/puppetlabs/code/environments/production/modules/concat",
+        "metadata": {"name": "puppetlabs-concat", "version": "4.1.0", "source": "git@github.com:fakeorg/concat.git"}
+      }
+    ],
+    "/etc/puppetlabs/code/modules": [
+      "puppetlabs-stdlib (v5.0.0)",
+      "Module unmanaged(/etc/puppetlabs/code/modules/unmanaged)"
+    ]
+  }
+}`
+
+func TestParseModuleList(t *testing.T) {
+	mods, err := ParseModuleList([]byte(moduleListJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse module list with error: %v", err)
+	}
+	expected := []ListedModule{
+		{Name: "puppetlabs-stdlib", Version: "6.0.0", Modulepath: "/etc/puppetlabs/code/environments/production/modules"},
+		{Name: "puppetlabs-concat", Version: "4.1.0", Path: "/etc/puppetlabs/code/environments/production/modules/concat", Modulepath: "/etc/puppetlabs/code/environments/production/modules", GitSource: "git@github.com:fakeorg/concat.git"},
+		{Name: "puppetlabs-stdlib", Version: "5.0.0", Modulepath: "/etc/puppetlabs/code/modules"},
+		{Name: "unmanaged", Path: "/etc/puppetlabs/code/modules/unmanaged", Modulepath: "/etc/puppetlabs/code/modules"},
+	}
+	if len(mods) != len(expected) {
+		t.Fatalf("Expected %d modules, got: %+v", len(expected), mods)
+	}
+	for i, e := range expected {
+		if mods[i] != e {
+			t.Errorf("Expected %+v, got %+v", e, mods[i])
+		}
+	}
+}
+
+func TestModuleListPuppetfile(t *testing.T) {
+	mods, err := ParseModuleList([]byte(moduleListJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse module list with error: %v", err)
+	}
+	puppetfile, shadowed, err := ModuleListPuppetfile(mods, "")
+	if err != nil {
+		t.Fatalf("Failed to convert module list with error: %v", err)
+	}
+	if len(shadowed) != 1 || shadowed[0].Version != "5.0.0" {
+		t.Errorf("Expected stdlib 5.0.0 to be shadowed, got: %+v", shadowed)
+	}
+	out := puppetfile.Sprint()
+	for _, e := range []string{
+		"# @git_source: git@github.com:fakeorg/concat.git\n# @modulepath: /etc/puppetlabs/code/environments/production/modules\nmod 'puppetlabs-concat',\n  '4.1.0'",
+		"mod 'puppetlabs-stdlib',\n  '6.0.0'",
+		"# @modulepath: /etc/puppetlabs/code/modules\nmod 'unmanaged'\n",
+	} {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected Puppetfile to contain:\n%s\ngot:\n%s", e, out)
+		}
+	}
+	puppetfile, _, err = ModuleListPuppetfile(mods, "/etc/puppetlabs/code/modules")
+	if err != nil {
+		t.Fatalf("Failed to convert module list with error: %v", err)
+	}
+	if ok, _ := puppetfile.HasModule("puppetlabs-concat"); ok {
+		t.Errorf("Expected modules outside of the modulepath to be filtered out")
+	}
+	if m := puppetfile.GetModule("puppetlabs-stdlib"); m == nil || m.GetVersionOrRef() != "5.0.0" {
+		t.Errorf("Expected stdlib 5.0.0 from the filtered modulepath")
+	}
+}
+
+func TestParseModuleListInvalid(t *testing.T) {
+	if _, err := ParseModuleList([]byte("not json")); err == nil {
+		t.Errorf("Expected an error for invalid JSON")
+	}
+	if _, err := ParseModuleList([]byte(`{"modules_by_path": {"/modules": [{"version": "1.0.0"}]}}`)); err == nil {
+		t.Errorf("Expected an error for a module without a name")
+	}
+}
+
+func TestParseModuleListGitSource(t *testing.T) {
+	list := `{"modules_by_path": {"/etc/puppetlabs/code/modules": [
+  {"forge_name": "puppetlabs/ntp", "version": "8.0.0", "metadata": {"source": "https://github.com/puppetlabs/puppetlabs-ntp"}},
+  {"forge_name": "puppetlabs/motd", "version": "4.0.0", "metadata": {"source": "https://github.com/puppetlabs/puppetlabs-motd.git"}},
+  {"forge_name": "fakeorg/local", "version": "1.0.0", "metadata": {"source": "UNKNOWN"}}
+]}}`
+	mods, err := ParseModuleList([]byte(list))
+	if err != nil {
+		t.Fatalf("Failed to parse module list with error: %v", err)
+	}
+	expected := map[string]string{
+		"puppetlabs-ntp":  "https://github.com/puppetlabs/puppetlabs-ntp",
+		"puppetlabs-motd": "https://github.com/puppetlabs/puppetlabs-motd.git",
+		"fakeorg-local":   "",
+	}
+	if len(mods) != len(expected) {
+		t.Fatalf("Expected %d modules, got: %+v", len(expected), mods)
+	}
+	for _, m := range mods {
+		if m.GitSource != expected[m.Name] {
+			t.Errorf("Expected %s to have Git source %q, got %q", m.Name, expected[m.Name], m.GitSource)
+		}
+	}
+}
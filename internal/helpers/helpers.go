@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing"
+
 	"github.com/hsnodgrass/pufctl/internal/sources/gitsource"
 
 	"github.com/hsnodgrass/pufctl/internal/auth"
@@ -131,6 +133,75 @@ func parseFromGit(url string, opts ParseOptions) (*ast.Puppetfile, error) {
 	return puppetfile, nil
 }
 
+// ParseRevision parses the Puppetfile at the given path as it exists at
+// the given revision of the local Git repository that contains it. The
+// Puppetfile is read directly from the repository's object database.
+func ParseRevision(path, rev string) (*ast.Puppetfile, error) {
+	repo, relPath, err := gitsource.OpenLocal(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := gitsource.ReadRevisionFile(repo, rev, relPath)
+	if err != nil {
+		return nil, err
+	}
+	puppetfile, err := ast.Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse Puppetfile text: %w", err)
+	}
+	logging.Debugf("Using Puppetfile %s at revision %s\n", relPath, rev)
+	return puppetfile, nil
+}
+
+// ParseStaged parses the Puppetfile at the given path as it exists in the
+// index (staging area) of the local Git repository that contains it.
+func ParseStaged(path string) (*ast.Puppetfile, error) {
+	repo, relPath, err := gitsource.OpenLocal(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := gitsource.ReadStagedFile(repo, relPath)
+	if err != nil {
+		return nil, err
+	}
+	puppetfile, err := ast.Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse Puppetfile text: %w", err)
+	}
+	logging.Debugf("Using staged Puppetfile %s\n", relPath)
+	return puppetfile, nil
+}
+
+// IsRevision returns true if rev can be resolved to a commit in the local
+// Git repository that contains the given path.
+func IsRevision(path, rev string) bool {
+	repo, _, err := gitsource.OpenLocal(path)
+	if err != nil {
+		return false
+	}
+	_, err = repo.ResolveRevision(plumbing.Revision(rev))
+	return err == nil
+}
+
+// IsRevisionRange returns true if arg is a "<rev>..<rev>" range and both
+// revisions can be resolved in the local Git repository that contains the
+// given path. An empty revision means HEAD, the same as git diff.
+func IsRevisionRange(path, arg string) bool {
+	parts := strings.SplitN(arg, "..", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	for _, rev := range parts {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		if !IsRevision(path, rev) {
+			return false
+		}
+	}
+	return true
+}
+
 // ParseFile reads a file and parses it using the Puppetfile AST
 func ParseFile(path string) (*ast.Puppetfile, error) {
 	text, err := ioutil.ReadFile(path)
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// puppetfileRepo returns the path of a Puppetfile in a temporary Git
+// repository, with a commit for each version of the Puppetfile
+func puppetfileRepo(t *testing.T, versions ...string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "helpers")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory with error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to initialize repository with error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	path := filepath.Join(dir, "Puppetfile")
+	for _, v := range versions {
+		stagePuppetfile(t, path, v)
+		sig := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+		if _, err := wt.Commit("Update Puppetfile", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+			t.Fatalf("Failed to commit Puppetfile with error: %v", err)
+		}
+	}
+	return path
+}
+
+// stagePuppetfile writes a Puppetfile that pins puppetlabs-stdlib to
+// version, and stages it
+func stagePuppetfile(t *testing.T, path, version string) {
+	t.Helper()
+	text := "mod 'puppetlabs-stdlib', '" + version + "'\n"
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatalf("Failed to write Puppetfile with error: %v", err)
+	}
+	repo, err := git.PlainOpen(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Failed to open repository with error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	if _, err := wt.Add("Puppetfile"); err != nil {
+		t.Fatalf("Failed to stage Puppetfile with error: %v", err)
+	}
+}
+
+func TestParseRevision(t *testing.T) {
+	path := puppetfileRepo(t, "5.0.0", "6.0.0")
+	for rev, expected := range map[string]string{"HEAD": "6.0.0", "HEAD~1": "5.0.0"} {
+		puppetfile, err := ParseRevision(path, rev)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile at %s with error: %v", rev, err)
+		}
+		if v := puppetfile.GetModule("puppetlabs-stdlib").GetPropertyValue("version"); v != expected {
+			t.Errorf("Expected puppetlabs-stdlib at %s to be %s, got %s", rev, expected, v)
+		}
+	}
+	if _, err := ParseRevision(path, "HEAD~5"); err == nil {
+		t.Errorf("Expected an error parsing a revision that doesn't exist")
+	}
+}
+
+func TestParseStaged(t *testing.T) {
+	path := puppetfileRepo(t, "5.0.0")
+	stagePuppetfile(t, path, "6.0.0")
+	puppetfile, err := ParseStaged(path)
+	if err != nil {
+		t.Fatalf("Failed to parse staged Puppetfile with error: %v", err)
+	}
+	if v := puppetfile.GetModule("puppetlabs-stdlib").GetPropertyValue("version"); v != "6.0.0" {
+		t.Errorf("Expected staged puppetlabs-stdlib to be 6.0.0, got %s", v)
+	}
+}
+
+func TestIsRevision(t *testing.T) {
+	path := puppetfileRepo(t, "5.0.0", "6.0.0")
+	cases := map[string]bool{
+		"HEAD":   true,
+		"HEAD~1": true,
+		"master": true,
+		"HEAD~2": false,
+		"nope":   false,
+	}
+	for rev, expected := range cases {
+		if actual := IsRevision(path, rev); actual != expected {
+			t.Errorf("Expected IsRevision(%s) to be %v, got %v", rev, expected, actual)
+		}
+	}
+	if IsRevision(filepath.Join(os.TempDir(), "not-a-repo", "Puppetfile"), "HEAD") {
+		t.Errorf("Expected a path outside of a repository to have no revisions")
+	}
+}
+
+func TestIsRevisionRange(t *testing.T) {
+	path := puppetfileRepo(t, "5.0.0", "6.0.0")
+	cases := map[string]bool{
+		"HEAD~1..HEAD":        true,
+		"HEAD~1..":            true,
+		"..HEAD~1":            true,
+		"HEAD~1":              false,
+		"HEAD..nope":          false,
+		"../prod/Puppetfile":  false,
+		"prod/../Puppetfile2": false,
+	}
+	for arg, expected := range cases {
+		if actual := IsRevisionRange(path, arg); actual != expected {
+			t.Errorf("Expected IsRevisionRange(%s) to be %v, got %v", arg, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Package moduledir installs the modules of a Puppetfile into a moduledir,
+// the same way r10k deploys a Puppetfile.
+package moduledir
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+	"github.com/hsnodgrass/pufctl/internal/sources/forgesource"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// DefaultModuledir is the moduledir used when neither the Puppetfile
+// nor the options specify one, relative to the base directory
+const DefaultModuledir = "modules"
+
+// Status is the outcome of installing a module
+type Status string
+
+const (
+	// Installed means the module was installed or updated
+	Installed Status = "installed"
+	// Skipped means the module was already installed at the right version,
+	// or is a local module that isn't installed by pufctl
+	Skipped Status = "skipped"
+	// Unsupported means the module's source can't be installed by pufctl
+	Unsupported Status = "unsupported"
+	// Failed means installing the module failed
+	Failed Status = "failed"
+)
+
+// Options holds options used when installing modules
+type Options struct {
+	// Basedir is the directory that relative paths are resolved against,
+	// normally the directory that holds the Puppetfile.
+	Basedir string
+	// Moduledir overrides the moduledir declared in the Puppetfile.
+	Moduledir string
+	// ForgeURL is the URL of the Forge API that Forge modules are
+	// downloaded from.
+	ForgeURL string
+	// UserAgent is sent with requests to the Forge API.
+	UserAgent string
+	// Jobs is the number of modules installed in parallel.
+	Jobs int
+	// GitAuth returns the authentication used for a Git URL. If it is nil,
+	// Git modules are cloned without authentication.
+	GitAuth func(url string) auth.Auth
+}
+
+// Result holds the outcome of installing a single module
+type Result struct {
+	Name    string
+	Path    string
+	Source  diff.SourceType
+	Version string
+	Status  Status
+	Err     error
+}
+
+// Install installs all modules of the Puppetfile in parallel and returns
+// a Result for each module, in the order of the Puppetfile. Modules that
+// are already installed at the right version are skipped.
+func Install(puppetfile *ast.Puppetfile, opts Options) []Result {
+	var mods []*ast.Module
+	for _, s := range puppetfile.Statements {
+		if s.Module != nil {
+			mods = append(mods, s.Module)
+		}
+	}
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]Result, len(mods))
+	var waitGroup sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < jobs; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for idx := range indexes {
+				results[idx] = InstallModule(mods[idx], ModulePath(puppetfile, mods[idx], opts), opts)
+			}
+		}()
+	}
+	for i := range mods {
+		indexes <- i
+	}
+	close(indexes)
+	waitGroup.Wait()
+	return results
+}
+
+// Path returns the moduledir of the Puppetfile. The moduledir in opts takes
+// precedence over the moduledir declared in the Puppetfile. Relative paths
+// are resolved against the base directory.
+func Path(puppetfile *ast.Puppetfile, opts Options) string {
+	dir := DefaultModuledir
+	if opts.Moduledir != "" {
+		dir = opts.Moduledir
+	} else if puppetfile.Moduledir != nil && puppetfile.Moduledir.Path != "" {
+		dir = puppetfile.Moduledir.Path
+	}
+	return resolve(opts.Basedir, dir)
+}
+
+// ModulePath returns the directory the module is installed into. Modules
+// with an :install_path property are installed there instead of the
+// moduledir, relative to the base directory.
+func ModulePath(puppetfile *ast.Puppetfile, m *ast.Module, opts Options) string {
+	dir := Path(puppetfile, opts)
+	if installPath := m.GetPropertyValue(":install_path"); installPath != "" {
+		dir = resolve(opts.Basedir, installPath)
+	}
+	return filepath.Join(dir, DirName(m.Name))
+}
+
+// DirName returns the name of the directory a module is installed into,
+// which is the module's name without its owner. Ex. puppetlabs-apache
+// and puppetlabs/apache are both installed into apache.
+func DirName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '/' })
+	if len(parts) == 0 {
+		return name
+	}
+	return parts[len(parts)-1]
+}
+
+// InstallModule installs a single module into path
+func InstallModule(m *ast.Module, path string, opts Options) Result {
+	res := Result{Name: m.Name, Path: path, Source: diff.Source(m)}
+	var err error
+	switch res.Source {
+	case diff.Forge:
+		res.Version, res.Status, err = installForge(m, path, opts)
+	case diff.Git:
+		res.Version, res.Status, err = installGit(m, path, opts)
+	case diff.Local:
+		res.Status = Skipped
+	default:
+		res.Status = Unsupported
+		err = fmt.Errorf("Installing modules from %s sources is not supported", res.Source)
+	}
+	if err != nil {
+		res.Err = err
+		if res.Status != Unsupported {
+			res.Status = Failed
+		}
+	}
+	return res
+}
+
+// InstalledVersion returns the version in the metadata.json file of the
+// module installed at path
+func InstalledVersion(path string) (string, error) {
+	meta, err := ReadMetadata(path)
+	if err != nil {
+		return "", err
+	}
+	return meta.Version, nil
+}
+
+// ReadMetadata reads the metadata.json file of the module installed at path
+func ReadMetadata(path string) (forgeapi.ModuleMetadata, error) {
+	var meta forgeapi.ModuleMetadata
+	data, err := ioutil.ReadFile(filepath.Join(path, "metadata.json"))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("Failed to decode %s with error: %w", filepath.Join(path, "metadata.json"), err)
+	}
+	return meta, nil
+}
+
+// GitRef returns the ref a Git module should be checked out at, which is
+// the first of its :commit, :tag, :ref, :branch, or :default_branch
+// properties found. An empty string means the remote's default branch.
+func GitRef(m *ast.Module) string {
+	for _, k := range []string{":commit", ":tag", ":ref", ":branch", ":default_branch"} {
+		if v := m.GetPropertyValue(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func installForge(m *ast.Module, path string, opts Options) (string, Status, error) {
+	slug := ast.NormalizeName(m.Name)
+	version := m.GetPropertyValue("version")
+	installed, _ := InstalledVersion(path)
+	var rel forgeapi.Release
+	if version == "" || version == ":latest" {
+		mod, err := forgesource.GetModule(slug, opts.ForgeURL, opts.UserAgent)
+		if err != nil {
+			return "", Failed, err
+		}
+		rel = mod.CurrentRelease
+		version = rel.Version
+		if installed == version {
+			return version, Skipped, nil
+		}
+	} else {
+		if installed == version {
+			return version, Skipped, nil
+		}
+		var err error
+		rel, err = forgeapi.FetchRelease(fmt.Sprintf("%s-%s", slug, version), opts.ForgeURL, opts.UserAgent)
+		if err != nil {
+			return version, Failed, err
+		}
+	}
+	logging.Debugf("Downloading %s-%s from %s\n", slug, version, rel.FileURI)
+	data, err := forgeapi.FetchReleaseFile(rel, opts.ForgeURL, opts.UserAgent)
+	if err != nil {
+		return version, Failed, err
+	}
+	if err := extract(data, path); err != nil {
+		return version, Failed, err
+	}
+	return version, Installed, nil
+}
+
+// extract unpacks a gzipped release tarball into path, replacing anything
+// already there. The top-level directory of the tarball is stripped.
+func extract(data []byte, path string) error {
+	parent := filepath.Dir(path)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(parent, ".pufctl-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("Failed to read release tarball with error: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read release tarball with error: %w", err)
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		parts := strings.SplitN(name, string(filepath.Separator), 2)
+		if len(parts) < 2 {
+			continue
+		}
+		rel := parts[1]
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("Release tarball contains an invalid path: %s", hdr.Name)
+		}
+		target := filepath.Join(tmp, rel)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode).Perm()|0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			logging.Debugf("Skipping %s in release tarball, unsupported file type\n", hdr.Name)
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func installGit(m *ast.Module, path string, opts Options) (string, Status, error) {
+	url := m.GetPropertyValue(":git")
+	ref := GitRef(m)
+	var modauth auth.Auth
+	if opts.GitAuth != nil {
+		modauth = opts.GitAuth(url)
+	}
+	target, err := RemoteHash(url, ref, modauth)
+	if err != nil {
+		return ref, Failed, err
+	}
+	version := ref
+	if version == "" {
+		version = target.String()[:7]
+	}
+	repo, err := git.PlainOpen(path)
+	if err == nil {
+		if head, err := repo.Head(); err == nil {
+			if commit, err := peel(repo, target); err == nil && commit == head.Hash() {
+				return version, Skipped, nil
+			}
+		}
+		err = repo.Fetch(&git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			Auth:       modauth.Method,
+			Tags:       git.AllTags,
+			RefSpecs:   []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return version, Failed, fmt.Errorf("Failed to fetch %s with error: %w", url, err)
+		}
+	} else {
+		if err := os.RemoveAll(path); err != nil {
+			return version, Failed, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return version, Failed, err
+		}
+		repo, err = git.PlainClone(path, false, &git.CloneOptions{URL: url, Auth: modauth.Method, NoCheckout: true})
+		if err != nil {
+			return version, Failed, fmt.Errorf("Failed to clone %s with error: %w", url, err)
+		}
+	}
+	commit, err := peel(repo, target)
+	if err != nil {
+		return version, Failed, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return version, Failed, err
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Hash: commit, Force: true}); err != nil {
+		return version, Failed, fmt.Errorf("Failed to check out %s with error: %w", commit, err)
+	}
+	logging.Debugf("Checked out %s at %s into %s\n", url, commit, path)
+	return version, Installed, nil
+}
+
+// RemoteHash returns the hash that ref points to in the remote repository
+// without cloning it. Branches are preferred over tags, and an empty ref
+// resolves to the remote's HEAD. A full commit hash is returned as is.
+func RemoteHash(url, ref string, modauth auth.Auth) (plumbing.Hash, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}})
+	refs, err := remote.List(&git.ListOptions{Auth: modauth.Method})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Failed to list refs of %s with error: %w", url, err)
+	}
+	byName := map[plumbing.ReferenceName]*plumbing.Reference{}
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+	candidates := []plumbing.ReferenceName{plumbing.HEAD}
+	if ref != "" {
+		candidates = []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)}
+	}
+	for _, c := range candidates {
+		r, ok := byName[c]
+		// Follow symbolic references, such as HEAD
+		for i := 0; ok && r.Type() == plumbing.SymbolicReference && i < 5; i++ {
+			r, ok = byName[r.Target()]
+		}
+		if ok {
+			return r.Hash(), nil
+		}
+	}
+	if len(ref) == 40 && plumbing.IsHash(ref) {
+		return plumbing.NewHash(ref), nil
+	}
+	return plumbing.ZeroHash, fmt.Errorf("Ref %s could not be found in %s", ref, url)
+}
+
+// peel returns the commit that hash points to, following annotated tags
+func peel(repo *git.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return commit.Hash, nil
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Commit %s could not be found with error: %w", hash, err)
+	}
+	return commit.Hash, nil
+}
+
+func resolve(basedir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(basedir, path)
+}
@@ -0,0 +1,185 @@
+package moduledir
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+func releaseTarball(t *testing.T, slug, version string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		"metadata.json":     fmt.Sprintf(`{"name": "%s", "version": "%s"}`, slug, version),
+		"manifests/init.pp": "class stdlib {}\n",
+	}
+	sums := map[string]string{}
+	for name, body := range files {
+		sum := md5.Sum([]byte(body))
+		sums[name] = hex.EncodeToString(sum[:])
+	}
+	checksums, err := json.Marshal(sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["checksums.json"] = string(checksums)
+	top := fmt.Sprintf("%s-%s/", slug, version)
+	if err := tw.WriteHeader(&tar.Header{Name: top, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		hdr := &tar.Header{Name: top + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(body))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// standInForge serves a single release of puppetlabs-stdlib. If tamper is
+// set, the tarball doesn't match the release's checksums.
+func standInForge(t *testing.T, tamper bool) *httptest.Server {
+	tarball := releaseTarball(t, "puppetlabs-stdlib", "6.0.0")
+	sha := sha256.Sum256(tarball)
+	md := md5.Sum(tarball)
+	release := fmt.Sprintf(`{"slug": "puppetlabs-stdlib-6.0.0", "version": "6.0.0", "file_uri": "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz", "file_sha256": "%s", "file_md5": "%s"}`, hex.EncodeToString(sha[:]), hex.EncodeToString(md[:]))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/releases/puppetlabs-stdlib-6.0.0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, release)
+	})
+	mux.HandleFunc("/v3/files/puppetlabs-stdlib-6.0.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		if tamper {
+			w.Write(releaseTarball(t, "puppetlabs-stdlib", "6.0.1"))
+			return
+		}
+		w.Write(tarball)
+	})
+	return httptest.NewServer(mux)
+}
+
+// localGitModule creates a Git repository with a single commit tagged v1.0.0
+func localGitModule(t *testing.T, dir string) string {
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "metadata.json"), []byte(`{"name": "fake-gitmod", "version": "1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("metadata.json"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	hash, err := wt.Commit("initial", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", hash, nil); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("file://%s", dir)
+}
+
+func TestInstall(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pufctl-moduledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	forge := standInForge(t, false)
+	defer forge.Close()
+	gitURL := localGitModule(t, filepath.Join(tmp, "gitmod-src"))
+	puppetfile, err := ast.Parse(fmt.Sprintf(`
+moduledir 'env/modules'
+
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'gitmod',
+  :git => '%s',
+  :tag => 'v1.0.0',
+  :install_path => 'env/site'
+
+mod 'localmod', :local => true
+`, gitURL))
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	opts := Options{Basedir: tmp, ForgeURL: forge.URL, UserAgent: "test/0.0.0", Jobs: 2}
+	results := Install(puppetfile, opts)
+	expected := map[string]Status{"puppetlabs-stdlib": Installed, "gitmod": Installed, "localmod": Skipped}
+	for _, r := range results {
+		if r.Status != expected[r.Name] || r.Err != nil {
+			t.Errorf("Expected module %s to be %s, got %s with error: %v", r.Name, expected[r.Name], r.Status, r.Err)
+		}
+	}
+	if v, err := InstalledVersion(filepath.Join(tmp, "env", "modules", "stdlib")); err != nil || v != "6.0.0" {
+		t.Errorf("Expected stdlib 6.0.0 in the moduledir, got %s with error: %v", v, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "env", "modules", "stdlib", "manifests", "init.pp")); err != nil {
+		t.Errorf("Expected the release tarball to be extracted: %v", err)
+	}
+	if v, err := InstalledVersion(filepath.Join(tmp, "env", "site", "gitmod")); err != nil || v != "1.0.0" {
+		t.Errorf("Expected gitmod in its install path, got %s with error: %v", v, err)
+	}
+	for _, r := range Install(puppetfile, opts) {
+		if r.Status != Skipped {
+			t.Errorf("Expected module %s to be skipped when already installed, got %s with error: %v", r.Name, r.Status, r.Err)
+		}
+	}
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pufctl-moduledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	forge := standInForge(t, true)
+	defer forge.Close()
+	puppetfile, err := ast.Parse("mod 'puppetlabs-stdlib', '6.0.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	results := Install(puppetfile, Options{Basedir: tmp, ForgeURL: forge.URL})
+	if len(results) != 1 || results[0].Status != Failed {
+		t.Fatalf("Expected the install to fail, got: %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, DefaultModuledir, "stdlib")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be extracted from a tarball that failed verification")
+	}
+}
+
+func TestDirName(t *testing.T) {
+	for name, expected := range map[string]string{"puppetlabs-apache": "apache", "puppetlabs/apache": "apache", "apache": "apache"} {
+		if d := DirName(name); d != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, name, d)
+		}
+	}
+}
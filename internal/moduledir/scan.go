@@ -0,0 +1,161 @@
+package moduledir
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// UnclassifiedTag is the meta tag added to modules in a bootstrapped
+// Puppetfile that could not be classified as Forge or Git modules
+const UnclassifiedTag = "unclassified"
+
+// ScannedModule is a module found in an existing moduledir. Modules that
+// can't be classified as Forge or Git modules have the Local source and
+// hold the reason in Unclassified.
+type ScannedModule struct {
+	Name    string
+	Path    string
+	Source  diff.SourceType
+	Version string
+	// GitURL and GitRef are set for Git modules. GitRefKey is the property
+	// the ref is set with, either :tag or :commit.
+	GitURL       string
+	GitRef       string
+	GitRefKey    string
+	Unclassified string
+}
+
+// Scan classifies each subdirectory of dir. Git clones with an origin
+// remote are Git modules, checked out at the tag pointing to HEAD or at
+// the commit of HEAD. Other directories with a metadata.json that has a
+// name and version are Forge modules.
+func Scan(dir string) ([]ScannedModule, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var mods []ScannedModule
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		mods = append(mods, scanModule(filepath.Join(dir, e.Name())))
+	}
+	return mods, nil
+}
+
+func scanModule(path string) ScannedModule {
+	name := filepath.Base(path)
+	mod := ScannedModule{Name: name, Path: path, Source: diff.Local}
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return scanGit(mod)
+	}
+	meta, err := ReadMetadata(path)
+	switch {
+	case os.IsNotExist(err):
+		mod.Unclassified = "no metadata.json or Git clone"
+	case err != nil:
+		mod.Unclassified = "metadata.json could not be read"
+	case meta.Name == "" || meta.Version == "":
+		mod.Unclassified = "metadata.json has no name or version"
+	case DirName(meta.Name) != name:
+		mod.Unclassified = fmt.Sprintf("directory name doesn't match %s in metadata.json", meta.Name)
+	default:
+		mod.Name = ast.NormalizeName(meta.Name)
+		mod.Source = diff.Forge
+		mod.Version = meta.Version
+	}
+	return mod
+}
+
+func scanGit(mod ScannedModule) ScannedModule {
+	repo, err := git.PlainOpen(mod.Path)
+	if err != nil {
+		mod.Unclassified = "Git clone could not be opened"
+		return mod
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil || len(remote.Config().URLs) == 0 {
+		mod.Unclassified = "Git clone has no origin remote"
+		return mod
+	}
+	head, err := repo.Head()
+	if err != nil {
+		mod.Unclassified = "Git clone has no HEAD"
+		return mod
+	}
+	mod.Source = diff.Git
+	mod.GitURL = remote.Config().URLs[0]
+	mod.GitRef = head.Hash().String()
+	mod.GitRefKey = ":commit"
+	if tag := headTag(repo, head.Hash()); tag != "" {
+		mod.GitRef = tag
+		mod.GitRefKey = ":tag"
+	}
+	if meta, err := ReadMetadata(mod.Path); err == nil {
+		mod.Version = meta.Version
+	}
+	return mod
+}
+
+// headTag returns the name of a tag that points to the commit, or an
+// empty string if there isn't one
+func headTag(repo *git.Repository, commit plumbing.Hash) string {
+	tags, err := repo.Tags()
+	if err != nil {
+		return ""
+	}
+	var found string
+	tags.ForEach(func(r *plumbing.Reference) error {
+		if c, err := peel(repo, r.Hash()); err == nil && c == commit && found == "" {
+			found = r.Name().Short()
+		}
+		return nil
+	})
+	return found
+}
+
+// Bootstrap returns a sorted Puppetfile with an entry for each scanned
+// module. Unclassified modules are added with :local => true and tagged
+// with the reason they could not be classified.
+func Bootstrap(mods []ScannedModule) (*ast.Puppetfile, error) {
+	puppetfile := &ast.Puppetfile{}
+	for _, m := range mods {
+		var props []string
+		switch m.Source {
+		case diff.Forge:
+			props = []string{m.Version}
+		case diff.Git:
+			props = []string{fmt.Sprintf(":git=>%s", m.GitURL), fmt.Sprintf("%s=>%s", m.GitRefKey, m.GitRef)}
+		}
+		if err := puppetfile.AddModule(m.Name, props); err != nil {
+			return nil, err
+		}
+		if m.Source == diff.Local {
+			mod := puppetfile.GetModule(m.Name)
+			mod.Properties = append(mod.Properties, &ast.Property{
+				Pos:   ast.DummyPos(),
+				Key:   &ast.Value{Pos: ast.DummyPos(), Ident: ":local"},
+				Value: &ast.Value{Pos: ast.DummyPos(), Ident: "true"},
+			})
+		}
+	}
+	for _, m := range mods {
+		if m.Unclassified == "" {
+			continue
+		}
+		if err := puppetfile.AddModuleMetadata(m.Name, UnclassifiedTag, m.Unclassified); err != nil {
+			return nil, err
+		}
+	}
+	return puppetfile, puppetfile.ParseMetadata()
+}
@@ -0,0 +1,61 @@
+package moduledir
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+func TestScanAndBootstrap(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pufctl-moduledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	gitURL := localGitModule(t, filepath.Join(tmp, "gitmod-src"))
+	puppetfile, err := ast.Parse(fmt.Sprintf("mod 'gitmod',\n  :git => '%s',\n  :tag => 'v1.0.0'\n", gitURL))
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	for _, r := range Install(puppetfile, Options{Basedir: tmp}) {
+		if r.Err != nil {
+			t.Fatalf("Failed to install module %s with error: %v", r.Name, r.Err)
+		}
+	}
+	modules := filepath.Join(tmp, DefaultModuledir)
+	if err := os.MkdirAll(filepath.Join(modules, "stdlib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(modules, "stdlib", "metadata.json"), []byte(`{"name": "puppetlabs/stdlib", "version": "6.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(modules, "handmade"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	scanned, err := Scan(modules)
+	if err != nil {
+		t.Fatalf("Scan failed with error: %v", err)
+	}
+	bootstrapped, err := Bootstrap(scanned)
+	if err != nil {
+		t.Fatalf("Bootstrap failed with error: %v", err)
+	}
+	out := bootstrapped.Sprint()
+	for _, expected := range []string{
+		fmt.Sprintf("mod 'gitmod',\n  :git => '%s',\n  :tag => 'v1.0.0',", gitURL),
+		"# @unclassified: no metadata.json or Git clone\nmod 'handmade',\n  :local => true,",
+		"mod 'puppetlabs-stdlib',\n  '6.0.0'",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected bootstrapped Puppetfile to contain:\n%s\ngot:\n%s", expected, out)
+		}
+	}
+	if _, err := ast.Parse(out); err != nil {
+		t.Errorf("Failed to parse bootstrapped Puppetfile with error: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package moduledir
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// DriftType is the way an installed module differs from the Puppetfile
+type DriftType string
+
+const (
+	// Missing means the module is in the Puppetfile but isn't installed
+	Missing DriftType = "missing"
+	// Extra means the module is installed but isn't in the Puppetfile
+	Extra DriftType = "extra"
+	// WrongVersion means the module is installed at a different version
+	// or commit than the Puppetfile specifies
+	WrongVersion DriftType = "wrong_version"
+	// Modified means files of the installed module were changed locally
+	Modified DriftType = "modified"
+)
+
+// Drift is a difference between a Puppetfile and an installed module.
+// Files holds the locally modified files of a Modified module.
+type Drift struct {
+	Name     string
+	Path     string
+	Type     DriftType
+	Expected string
+	Actual   string
+	Files    []string
+}
+
+// Verify compares the modules installed in the moduledir with the
+// Puppetfile and returns the drift, sorted by module name. Forge modules
+// are checked against the version in their metadata.json and the file
+// checksums in their checksums.json. Git modules are checked against the
+// commit their ref points to in the local clone, and against the status
+// of the clone. Modules without a version, or with :latest, are only
+// checked for local modifications. Verify doesn't access the network.
+func Verify(puppetfile *ast.Puppetfile, opts Options) ([]Drift, error) {
+	var drift []Drift
+	expected := map[string]bool{}
+	for _, s := range puppetfile.Statements {
+		if s.Module == nil {
+			continue
+		}
+		path := ModulePath(puppetfile, s.Module, opts)
+		expected[path] = true
+		switch diff.Source(s.Module) {
+		case diff.Forge:
+			drift = append(drift, verifyForge(s.Module, path)...)
+		case diff.Git:
+			drift = append(drift, verifyGit(s.Module, path)...)
+		}
+	}
+	dir := Path(puppetfile, opts)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return drift, err
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || expected[path] {
+			continue
+		}
+		actual, _ := InstalledVersion(path)
+		drift = append(drift, Drift{Name: e.Name(), Path: path, Type: Extra, Actual: actual})
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Name < drift[j].Name })
+	return drift, nil
+}
+
+func verifyForge(m *ast.Module, path string) []Drift {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []Drift{{Name: m.Name, Path: path, Type: Missing, Expected: m.GetPropertyValue("version")}}
+	}
+	var drift []Drift
+	version := m.GetPropertyValue("version")
+	actual, err := InstalledVersion(path)
+	if err != nil {
+		actual = ""
+	}
+	if version != "" && version != ":latest" && version != actual {
+		drift = append(drift, Drift{Name: m.Name, Path: path, Type: WrongVersion, Expected: version, Actual: actual})
+	}
+	if files := modifiedFiles(path); len(files) > 0 {
+		drift = append(drift, Drift{Name: m.Name, Path: path, Type: Modified, Actual: actual, Files: files})
+	}
+	return drift
+}
+
+// modifiedFiles returns the files of a Forge module that don't match the
+// module's checksums.json, which lists the MD5 checksum of each file in
+// the release. Modules without a checksums.json are not checked.
+func modifiedFiles(path string) []string {
+	data, err := ioutil.ReadFile(filepath.Join(path, "checksums.json"))
+	if err != nil {
+		return nil
+	}
+	sums := map[string]string{}
+	if err := json.Unmarshal(data, &sums); err != nil {
+		return nil
+	}
+	var files []string
+	for name, sum := range sums {
+		contents, err := ioutil.ReadFile(filepath.Join(path, filepath.FromSlash(name)))
+		if err != nil {
+			files = append(files, name)
+			continue
+		}
+		actual := md5.Sum(contents)
+		if !strings.EqualFold(hex.EncodeToString(actual[:]), sum) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func verifyGit(m *ast.Module, path string) []Drift {
+	ref := GitRef(m)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []Drift{{Name: m.Name, Path: path, Type: Missing, Expected: ref}}
+	}
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return []Drift{{Name: m.Name, Path: path, Type: WrongVersion, Expected: ref, Actual: "(not a Git clone)"}}
+	}
+	var drift []Drift
+	head, err := repo.Head()
+	if err != nil {
+		return []Drift{{Name: m.Name, Path: path, Type: WrongVersion, Expected: ref, Actual: "(no HEAD)"}}
+	}
+	actual := head.Hash().String()[:7]
+	if ref != "" {
+		commit, err := localHash(repo, ref)
+		if err != nil || commit != head.Hash() {
+			drift = append(drift, Drift{Name: m.Name, Path: path, Type: WrongVersion, Expected: ref, Actual: actual})
+		}
+	}
+	if wt, err := repo.Worktree(); err == nil {
+		if status, err := wt.Status(); err == nil && !status.IsClean() {
+			var files []string
+			for name := range status {
+				files = append(files, name)
+			}
+			sort.Strings(files)
+			drift = append(drift, Drift{Name: m.Name, Path: path, Type: Modified, Actual: actual, Files: files})
+		}
+	}
+	return drift
+}
+
+// localHash returns the commit that ref points to in a local clone, looking
+// at remote branches, tags, and local branches, in that order. A full
+// commit hash is returned as is.
+func localHash(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	if len(ref) == 40 && plumbing.IsHash(ref) {
+		return plumbing.NewHash(ref), nil
+	}
+	names := []plumbing.ReferenceName{
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref),
+		plumbing.NewTagReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+	}
+	var err error
+	for _, n := range names {
+		var r *plumbing.Reference
+		r, err = repo.Reference(n, true)
+		if err == nil {
+			return peel(repo, r.Hash())
+		}
+	}
+	return plumbing.ZeroHash, err
+}
@@ -0,0 +1,83 @@
+package moduledir
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+func TestVerify(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "pufctl-moduledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	forge := standInForge(t, false)
+	defer forge.Close()
+	gitURL := localGitModule(t, filepath.Join(tmp, "gitmod-src"))
+	text := fmt.Sprintf(`
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'gitmod',
+  :git => '%s',
+  :tag => 'v1.0.0'
+`, gitURL)
+	puppetfile, err := ast.Parse(text)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	opts := Options{Basedir: tmp, ForgeURL: forge.URL}
+	for _, r := range Install(puppetfile, opts) {
+		if r.Err != nil {
+			t.Fatalf("Failed to install module %s with error: %v", r.Name, r.Err)
+		}
+	}
+	drift, err := Verify(puppetfile, opts)
+	if err != nil || len(drift) != 0 {
+		t.Fatalf("Expected no drift after install, got %+v with error: %v", drift, err)
+	}
+	modules := filepath.Join(tmp, DefaultModuledir)
+	if err := ioutil.WriteFile(filepath.Join(modules, "stdlib", "manifests", "init.pp"), []byte("class stdlib { notify { 'hi': } }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(modules, "gitmod", "metadata.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(modules, "handmade"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	puppetfile, err = ast.Parse(text + "\nmod 'puppetlabs-concat', '6.0.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	puppetfile.GetModule("puppetlabs-stdlib").EditProperty("bare", "6.1.0")
+	drift, err = Verify(puppetfile, opts)
+	if err != nil {
+		t.Fatalf("Verify failed with error: %v", err)
+	}
+	expected := []struct {
+		name string
+		typ  DriftType
+	}{
+		{"gitmod", Modified},
+		{"handmade", Extra},
+		{"puppetlabs-concat", Missing},
+		{"puppetlabs-stdlib", WrongVersion},
+		{"puppetlabs-stdlib", Modified},
+	}
+	if len(drift) != len(expected) {
+		t.Fatalf("Expected %d drifted modules, got: %+v", len(expected), drift)
+	}
+	for i, e := range expected {
+		if drift[i].Name != e.name || drift[i].Type != e.typ {
+			t.Errorf("Expected %s to be %s, got %s %s", e.name, e.typ, drift[i].Name, drift[i].Type)
+		}
+	}
+	if files := drift[4].Files; len(files) != 1 || files[0] != "manifests/init.pp" {
+		t.Errorf("Expected manifests/init.pp to be modified, got: %v", files)
+	}
+}
@@ -1,6 +1,7 @@
 package forgesource
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -19,12 +20,27 @@ func GetModule(nameslug, url, agent string) (forgeapi.Module, error) {
 	return outMod, outErr
 }
 
+// GetModules returns Puppet Forge Module objects for the named modules,
+// keyed by slug. Modules that can't be fetched are logged and left out.
+func GetModules(slugs []string, url, agent string) map[string]forgeapi.Module {
+	logging.Debugln("Fetching", len(slugs), "modules")
+	mods := make(map[string]forgeapi.Module)
+	for _, r := range forgeapi.NewClient(url, agent).FetchModules(context.Background(), slugs) {
+		if r.Err != nil {
+			logging.Warnln("Failed to get a module:", r.Err)
+			continue
+		}
+		mods[r.Slug] = r.Module
+	}
+	return mods
+}
+
 // GetModuleDependencies returns Module objects for specified dependencies in the given module
 func GetModuleDependencies(mod forgeapi.Module, forge, agent string) ([]forgeapi.Module, error) {
 	mods, errs := forgeapi.FetchModuleDependencies(mod, forge, agent)
 	if len(errs) > 0 {
 		for _, e := range errs {
-			logging.Warnf("Failed to get a dependency: %#v", e)
+			logging.Warnln("Failed to get a dependency:", e)
 		}
 	}
 	logging.Debugln("Successfully fetched module dependencies")
@@ -39,6 +55,9 @@ func ResolveDeps(mod forgeapi.Module, url, agent string, puppetfile *ast.Puppetf
 		logging.Errorln("Failed to get module dependencies with error: ", err)
 	}
 	for _, d := range deps {
+		if d.Deprecated() {
+			logging.Warnln(d.DeprecationMessage())
+		}
 		modProp := []string{d.CurrentRelease.Version}
 		err = puppetfile.AddModule(d.Slug, modProp)
 		if err != nil {
@@ -61,3 +80,17 @@ func Search(url, agent string, opts forgeapi.ListModulesOpts) ([]forgeapi.Module
 	}
 	return result.Results, nil
 }
+
+// SearchAll implements searching for modules via the Puppet Forge, following
+// the pages of results until there are no more or max modules are found
+func SearchAll(url, agent string, opts forgeapi.ListModulesOpts, max int) ([]forgeapi.Module, error) {
+	var mods []forgeapi.Module
+	it := forgeapi.IterateModules(url, agent, opts, max)
+	for it.Next() {
+		mods = append(mods, it.Module())
+	}
+	if err := it.Err(); err != nil {
+		return mods, fmt.Errorf("Search failed: %w", err)
+	}
+	return mods, nil
+}
@@ -0,0 +1,68 @@
+package gitsource
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+	"github.com/hsnodgrass/pufctl/internal/logging"
+)
+
+// CloneWorktree clones a single branch of a Git repository into memory
+// with a working tree, so files can be changed, committed, and pushed back.
+func CloneWorktree(url, branch string, modauth auth.Auth) (*git.Repository, error) {
+	opts := &git.CloneOptions{
+		URL:          url,
+		Auth:         modauth.Method,
+		SingleBranch: true,
+	}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+	}
+	repo, err := git.Clone(memory.NewStorage(), memfs.New(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to clone repository with error: %w", err)
+	}
+	logging.Debugln("Cloned git repository with working tree into memory:", url)
+	return repo, nil
+}
+
+// CommitFile writes data to the file at path, relative to the root of the
+// repository's working tree, stages it, and commits the index with the
+// given message. Anything else that was already staged is committed too,
+// which is nothing for repositories cloned with CloneWorktree. The commit
+// author is read from the Git config.
+func CommitFile(repo *git.Repository, path string, data []byte, message string) (plumbing.Hash, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Failed to get worktree of repository with error: %w", err)
+	}
+	err = util.WriteFile(wt.Filesystem, path, data, os.FileMode(0644))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Failed to write %s with error: %w", path, err)
+	}
+	if _, err := wt.Add(path); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Failed to stage %s with error: %w", path, err)
+	}
+	hash, err := wt.Commit(message, &git.CommitOptions{})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("Failed to commit %s with error: %w", path, err)
+	}
+	logging.Debugf("Committed %s as %s\n", path, hash)
+	return hash, nil
+}
+
+// Push pushes the branches of the repository to the origin remote
+func Push(repo *git.Repository, modauth auth.Auth) error {
+	err := repo.Push(&git.PushOptions{Auth: modauth.Method})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("Failed to push to origin with error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package gitsource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/internal/auth"
+)
+
+func TestCloneWorktreeCommitFile(t *testing.T) {
+	origin, dir := testRepo(t)
+	commitFile(t, origin, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", time.Now())
+	commitFile(t, origin, dir, "README.md", "Control repo\n", time.Now())
+
+	repo, err := CloneWorktree(dir, "master", auth.Auth{})
+	if err != nil {
+		t.Fatalf("Failed to clone repository with error: %v", err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("Failed to read repository config with error: %v", err)
+	}
+	cfg.User.Name, cfg.User.Email = "Test", "test@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("Failed to set repository config with error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	if _, err := wt.Filesystem.Stat("README.md"); err != nil {
+		t.Errorf("Expected the clone to have a working tree with README.md, got error: %v", err)
+	}
+
+	hash, err := CommitFile(repo, "Puppetfile", []byte("mod 'puppetlabs-stdlib', '6.0.0'\n"), "Promote puppetlabs-stdlib")
+	if err != nil {
+		t.Fatalf("Failed to commit Puppetfile with error: %v", err)
+	}
+	data, err := ReadRevisionFile(repo, hash.String(), "Puppetfile")
+	if err != nil {
+		t.Fatalf("Failed to read committed Puppetfile with error: %v", err)
+	}
+	if expected := "mod 'puppetlabs-stdlib', '6.0.0'\n"; string(data) != expected {
+		t.Errorf("Expected committed Puppetfile %q, got %q", expected, data)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("Failed to get commit with error: %v", err)
+	}
+	if commit.Message != "Promote puppetlabs-stdlib" || commit.Author.Name != "Test" {
+		t.Errorf("Expected the commit message and author to be set, got %q by %s", commit.Message, commit.Author.Name)
+	}
+	if commit.NumParents() != 1 {
+		t.Errorf("Expected the commit to have the cloned branch as its parent, got %d parents", commit.NumParents())
+	}
+	// Files that weren't changed are kept as they were
+	if data, err := ReadRevisionFile(repo, hash.String(), "README.md"); err != nil || string(data) != "Control repo\n" {
+		t.Errorf("Expected README.md to be unchanged, got %q with error: %v", data, err)
+	}
+}
+
+func TestCloneWorktreeMissingBranch(t *testing.T) {
+	origin, dir := testRepo(t)
+	commitFile(t, origin, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", time.Now())
+	if _, err := CloneWorktree(dir, "nope", auth.Auth{}); err == nil {
+		t.Errorf("Expected an error cloning a branch that doesn't exist")
+	}
+}
@@ -47,6 +47,25 @@ func CloneFile(url, filepath, branch string, modauth auth.Auth) (billy.File, str
 	return f, defBranch, nil
 }
 
+// CloneRepo clones a Git repository into in-memory storage without checking
+// out a working tree. If branch is empty, all branches are fetched.
+func CloneRepo(url, branch string, modauth auth.Auth) (*git.Repository, error) {
+	opts := &git.CloneOptions{
+		URL:  url,
+		Auth: modauth.Method,
+	}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		opts.SingleBranch = true
+	}
+	repo, err := git.Clone(memory.NewStorage(), nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to clone repository with error: %w", err)
+	}
+	logging.Debugln("Cloned git repository into in-memory storage:", url)
+	return repo, nil
+}
+
 // GetModuleMeta parses a module's git repo for the metadata.json file,
 // unmarshalls it into a forgeapi.ModuleMetadata struct, and returns the struct
 func GetModuleMeta(url string, modauth auth.Auth) (string, *forgeapi.ModuleMetadata) {
@@ -66,10 +85,10 @@ func GetModuleMeta(url string, modauth auth.Auth) (string, *forgeapi.ModuleMetad
 // ResolveDeps resolves Git module dependencies
 func ResolveDeps(meta *forgeapi.ModuleMetadata, puppetfile *ast.Puppetfile) bool {
 	changes := false
-	metaSlug := strings.Replace(meta.Name, "/", "-", 1)
+	metaSlug := ast.NormalizeName(meta.Name)
 	for _, d := range meta.Dependencies {
 		modProp := []string{":latest"}
-		slug := strings.Replace(d.Name, "/", "-", 1)
+		slug := ast.NormalizeName(d.Name)
 		err := puppetfile.AddModule(slug, modProp)
 		if err != nil {
 			logging.Warnln("Failed to add module to Puppetfile with error: ", err)
@@ -0,0 +1,125 @@
+package gitsource
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// FileRevision holds the contents of a file at a commit that changed it,
+// along with the contents of the file at the commit's first parent.
+// Contents is nil if the commit removed the file and ParentContents is
+// nil if the file did not exist before the commit.
+type FileRevision struct {
+	Hash           string
+	Author         string
+	Email          string
+	When           time.Time
+	Message        string
+	Contents       []byte
+	ParentContents []byte
+}
+
+// FileHistoryOptions holds options used to limit the commits walked by FileHistory
+type FileHistoryOptions struct {
+	Rev   string
+	Since *time.Time
+	Until *time.Time
+}
+
+// ParseDate parses a date given as YYYY-MM-DD, in local time, or as RFC3339.
+// A date without a time is the start of that day, or the end of it if end is
+// true, so that a range ending on the date includes the whole day.
+func ParseDate(input string, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", input, time.Local); err == nil {
+		if end {
+			return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, input); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("Date %s is not valid, please use YYYY-MM-DD or RFC3339", input)
+}
+
+// FileHistory walks the commits reachable from opts.Rev (HEAD by default)
+// that changed the file at the given path and returns a FileRevision for
+// each of them, ordered from oldest to newest. Commits are limited by their
+// committer time, and merge commits are compared with their first parent
+// only, so a merge shows the changes it brought into the branch.
+func FileHistory(repo *git.Repository, path string, opts FileHistoryOptions) ([]FileRevision, error) {
+	rev := opts.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve revision %s with error: %w", rev, err)
+	}
+	iter, err := repo.Log(&git.LogOptions{
+		From:     *hash,
+		Order:    git.LogOrderCommitterTime,
+		FileName: &path,
+		Since:    opts.Since,
+		Until:    opts.Until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to walk commits for %s with error: %w", path, err)
+	}
+	defer iter.Close()
+	var revs []FileRevision
+	err = iter.ForEach(func(c *object.Commit) error {
+		contents, err := commitFileContents(c, path)
+		if err != nil {
+			return err
+		}
+		var parentContents []byte
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return fmt.Errorf("Failed to get parent of commit %s with error: %w", c.Hash, err)
+			}
+			parentContents, err = commitFileContents(parent, path)
+			if err != nil {
+				return err
+			}
+		}
+		revs = append(revs, FileRevision{
+			Hash:           c.Hash.String(),
+			Author:         c.Author.Name,
+			Email:          c.Author.Email,
+			When:           c.Author.When,
+			Message:        c.Message,
+			Contents:       contents,
+			ParentContents: parentContents,
+		})
+		return nil
+	})
+	if err != nil && err != storer.ErrStop {
+		return nil, err
+	}
+	for i, j := 0, len(revs)-1; i < j; i, j = i+1, j-1 {
+		revs[i], revs[j] = revs[j], revs[i]
+	}
+	return revs, nil
+}
+
+func commitFileContents(c *object.Commit, path string) ([]byte, error) {
+	f, err := c.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find %s in commit %s with error: %w", path, c.Hash, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s in commit %s with error: %w", path, c.Hash, err)
+	}
+	return []byte(contents), nil
+}
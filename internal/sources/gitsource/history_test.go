@@ -0,0 +1,142 @@
+package gitsource
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		input    string
+		end      bool
+		expected time.Time
+	}{
+		{"2020-05-01", false, time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local)},
+		{"2020-05-01", true, time.Date(2020, 5, 1, 23, 59, 59, 999999999, time.Local)},
+		{"2020-05-01T12:30:00Z", false, time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)},
+		{"2020-05-01T12:30:00Z", true, time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		actual, err := ParseDate(c.input, c.end)
+		if err != nil {
+			t.Fatalf("Failed to parse date %s with error: %v", c.input, err)
+		}
+		if !actual.Equal(c.expected) {
+			t.Errorf("Expected %s (end %v) to be %s, got %s", c.input, c.end, c.expected, actual)
+		}
+	}
+	if _, err := ParseDate("05/01/2020", false); err == nil {
+		t.Errorf("Expected an error parsing an invalid date")
+	}
+}
+
+func TestFileHistoryDateBounds(t *testing.T) {
+	repo, dir := testRepo(t)
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '4.0.0'\n", time.Date(2020, 4, 30, 12, 0, 0, 0, time.Local))
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", time.Date(2020, 5, 1, 15, 0, 0, 0, time.Local))
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '6.0.0'\n", time.Date(2020, 5, 2, 9, 0, 0, 0, time.Local))
+	commitFile(t, repo, dir, "README.md", "Not the Puppetfile\n", time.Date(2020, 5, 3, 9, 0, 0, 0, time.Local))
+	date := func(input string, end bool) *time.Time {
+		d, err := ParseDate(input, end)
+		if err != nil {
+			t.Fatalf("Failed to parse date %s with error: %v", input, err)
+		}
+		return &d
+	}
+	cases := []struct {
+		name     string
+		opts     FileHistoryOptions
+		expected []string
+	}{
+		{"all", FileHistoryOptions{}, []string{"4.0.0", "5.0.0", "6.0.0"}},
+		{"until includes the whole day", FileHistoryOptions{Until: date("2020-05-01", true)}, []string{"4.0.0", "5.0.0"}},
+		{"since includes the whole day", FileHistoryOptions{Since: date("2020-05-01", false)}, []string{"5.0.0", "6.0.0"}},
+		{"since and until the same day", FileHistoryOptions{Since: date("2020-05-01", false), Until: date("2020-05-01", true)}, []string{"5.0.0"}},
+		{"rev", FileHistoryOptions{Rev: "HEAD~2"}, []string{"4.0.0", "5.0.0"}},
+	}
+	for _, c := range cases {
+		revs, err := FileHistory(repo, "Puppetfile", c.opts)
+		if err != nil {
+			t.Fatalf("%s: Failed to get history with error: %v", c.name, err)
+		}
+		var versions []string
+		for _, r := range revs {
+			versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(string(r.Contents), "mod 'puppetlabs-stdlib', '"), "'\n"))
+		}
+		if len(versions) != len(c.expected) {
+			t.Errorf("%s: Expected versions %v, got %v", c.name, c.expected, versions)
+			continue
+		}
+		for i := range versions {
+			if versions[i] != c.expected[i] {
+				t.Errorf("%s: Expected versions %v, got %v", c.name, c.expected, versions)
+				break
+			}
+		}
+	}
+	revs, _ := FileHistory(repo, "Puppetfile", FileHistoryOptions{})
+	if revs[0].ParentContents != nil {
+		t.Errorf("Expected the commit that added the Puppetfile to have no parent contents, got %q", revs[0].ParentContents)
+	}
+	if string(revs[1].ParentContents) != string(revs[0].Contents) {
+		t.Errorf("Expected parent contents %q, got %q", revs[0].Contents, revs[1].ParentContents)
+	}
+}
+
+func TestFileHistoryMerge(t *testing.T) {
+	repo, dir := testRepo(t)
+	base := time.Date(2020, 5, 1, 12, 0, 0, 0, time.Local)
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", base)
+	first, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD with error: %v", err)
+	}
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-apache', '5.0.0'\nmod 'puppetlabs-stdlib', '5.0.0'\n", base.Add(time.Hour))
+	master, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD with error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Hash: first.Hash(), Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatalf("Failed to create branch with error: %v", err)
+	}
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '6.0.0'\n", base.Add(2*time.Hour))
+	feature, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD with error: %v", err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: master.Name()}); err != nil {
+		t.Fatalf("Failed to check out %s with error: %v", master.Name(), err)
+	}
+	merged := "mod 'puppetlabs-apache', '5.0.0'\nmod 'puppetlabs-stdlib', '6.0.0'\n"
+	writeFile(t, repo, dir, "Puppetfile", merged)
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: base.Add(3 * time.Hour)}
+	if _, err := wt.Commit("Merge feature", &git.CommitOptions{Author: sig, Committer: sig, Parents: []plumbing.Hash{master.Hash(), feature.Hash()}}); err != nil {
+		t.Fatalf("Failed to commit merge with error: %v", err)
+	}
+
+	revs, err := FileHistory(repo, "Puppetfile", FileHistoryOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get history with error: %v", err)
+	}
+	if len(revs) != 4 {
+		t.Fatalf("Expected 4 revisions including both branches and the merge, got %d", len(revs))
+	}
+	merge := revs[len(revs)-1]
+	if merge.Message != "Merge feature" || string(merge.Contents) != merged {
+		t.Fatalf("Expected the merge to be the newest revision, got %q", merge.Message)
+	}
+	// The merge is compared with its first parent, the tip of master, so it
+	// shows the feature branch's change and not master's
+	if expected := "mod 'puppetlabs-apache', '5.0.0'\nmod 'puppetlabs-stdlib', '5.0.0'\n"; string(merge.ParentContents) != expected {
+		t.Errorf("Expected the merge's parent contents to be its first parent's %q, got %q", expected, merge.ParentContents)
+	}
+}
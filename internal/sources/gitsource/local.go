@@ -0,0 +1,197 @@
+package gitsource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// OpenLocal opens the Git repository that contains the given path without
+// cloning it. It returns the repository and the path of the file relative
+// to the root of the repository's working tree.
+func OpenLocal(path string) (*git.Repository, string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get absolute path of %s with error: %w", path, err)
+	}
+	dir, file := filepath.Split(abs)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to open Git repository containing %s with error: %w", path, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get worktree of repository with error: %w", err)
+	}
+	root := wt.Filesystem.Root()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, filepath.Join(dir, file))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, "", fmt.Errorf("Path %s is not inside the repository at %s", path, root)
+	}
+	return repo, filepath.ToSlash(rel), nil
+}
+
+// ReadRevisionFile returns the contents of a file as it exists at the given
+// revision of the repository. The revision can be anything go-git can
+// resolve, such as a branch, a tag, a commit hash, or HEAD~2.
+func ReadRevisionFile(repo *git.Repository, rev, path string) ([]byte, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve revision %s with error: %w", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get commit %s with error: %w", hash, err)
+	}
+	f, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find %s at revision %s with error: %w", path, rev, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s at revision %s with error: %w", path, rev, err)
+	}
+	return []byte(contents), nil
+}
+
+// ReadStagedFile returns the contents of a file as it exists in the index
+// (staging area) of the repository.
+func ReadStagedFile(repo *git.Repository, path string) ([]byte, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read repository index with error: %w", err)
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find %s in the index with error: %w", path, err)
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get staged blob for %s with error: %w", path, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read staged blob for %s with error: %w", path, err)
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// ListBranches returns a map of branch names to the commit hashes they point
+// to. Both local branches and branches of the origin remote are included, so
+// in-memory clones and local working copies can be handled the same way. If a
+// branch exists both locally and on origin, the local branch wins.
+func ListBranches(repo *git.Repository) (map[string]plumbing.Hash, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list repository references with error: %w", err)
+	}
+	branches := map[string]plumbing.Hash{}
+	remotes := map[string]plumbing.Hash{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name()
+		switch {
+		case name.IsBranch():
+			branches[name.Short()] = ref.Hash()
+		case name.IsRemote() && strings.HasPrefix(name.String(), "refs/remotes/origin/"):
+			short := strings.TrimPrefix(name.String(), "refs/remotes/origin/")
+			if short != "HEAD" {
+				remotes[short] = ref.Hash()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list repository references with error: %w", err)
+	}
+	for name, hash := range remotes {
+		if _, ok := branches[name]; !ok {
+			branches[name] = hash
+		}
+	}
+	return branches, nil
+}
+
+// FilterBranches returns the branches whose names match any of the glob
+// patterns, or all branches if there are no patterns. Unlike path.Match,
+// "*" also matches "/", so release/* matches release/2020/x as it does for
+// git branch --list. "?" matches any one character, and [...] matches a
+// character class, negated with [!...] or [^...].
+func FilterBranches(branches map[string]plumbing.Hash, patterns []string) (map[string]plumbing.Hash, error) {
+	if len(patterns) < 1 {
+		return branches, nil
+	}
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := branchPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	filtered := map[string]plumbing.Hash{}
+	for name, hash := range branches {
+		for _, re := range res {
+			if re.MatchString(name) {
+				filtered[name] = hash
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// branchPattern compiles a branch glob pattern to an anchored regular
+// expression
+func branchPattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			} else {
+				b.WriteString(regexp.QuoteMeta("\\"))
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("Branch pattern %s is not valid: unterminated [", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("Branch pattern %s is not valid: %w", pattern, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,166 @@
+package gitsource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testRepo initializes a Git repository in a temporary directory that is
+// removed when the test is done
+func testRepo(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gitsource")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory with error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to initialize repository with error: %v", err)
+	}
+	return repo, dir
+}
+
+// writeFile writes a file in the repository's working tree and stages it
+func writeFile(t *testing.T, repo *git.Repository, dir, path, contents string) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s with error: %v", path, err)
+	}
+	if err := ioutil.WriteFile(full, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write %s with error: %v", path, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	if _, err := wt.Add(path); err != nil {
+		t.Fatalf("Failed to stage %s with error: %v", path, err)
+	}
+}
+
+// commitFile writes and stages a file, and commits it at the given time
+func commitFile(t *testing.T, repo *git.Repository, dir, path, contents string, when time.Time) {
+	t.Helper()
+	writeFile(t, repo, dir, path, contents)
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree with error: %v", err)
+	}
+	sig := &object.Signature{Name: "Test", Email: "test@example.com", When: when}
+	if _, err := wt.Commit("Update "+path, &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Failed to commit %s with error: %v", path, err)
+	}
+}
+
+func TestOpenLocal(t *testing.T) {
+	repo, dir := testRepo(t)
+	commitFile(t, repo, dir, "control/Puppetfile", "mod 'puppetlabs-stdlib', '6.0.0'\n", time.Now())
+	_, rel, err := OpenLocal(filepath.Join(dir, "control", "Puppetfile"))
+	if err != nil {
+		t.Fatalf("Failed to open repository with error: %v", err)
+	}
+	if rel != "control/Puppetfile" {
+		t.Errorf("Expected path relative to the repository to be control/Puppetfile, got %s", rel)
+	}
+	outside, err := ioutil.TempDir("", "gitsource")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory with error: %v", err)
+	}
+	defer os.RemoveAll(outside)
+	if _, _, err := OpenLocal(filepath.Join(outside, "Puppetfile")); err == nil {
+		t.Errorf("Expected an error opening a path outside of a repository")
+	}
+}
+
+func TestReadRevisionFile(t *testing.T) {
+	repo, dir := testRepo(t)
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", time.Now())
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '6.0.0'\n", time.Now())
+	cases := map[string]string{
+		"HEAD":   "mod 'puppetlabs-stdlib', '6.0.0'\n",
+		"HEAD~1": "mod 'puppetlabs-stdlib', '5.0.0'\n",
+	}
+	for rev, expected := range cases {
+		data, err := ReadRevisionFile(repo, rev, "Puppetfile")
+		if err != nil {
+			t.Fatalf("Failed to read Puppetfile at %s with error: %v", rev, err)
+		}
+		if string(data) != expected {
+			t.Errorf("Expected Puppetfile at %s to be %q, got %q", rev, expected, data)
+		}
+	}
+	if _, err := ReadRevisionFile(repo, "nope", "Puppetfile"); err == nil {
+		t.Errorf("Expected an error reading an unknown revision")
+	}
+	if _, err := ReadRevisionFile(repo, "HEAD", "Missing"); err == nil {
+		t.Errorf("Expected an error reading a file that isn't in the revision")
+	}
+}
+
+func TestReadStagedFile(t *testing.T) {
+	repo, dir := testRepo(t)
+	commitFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '5.0.0'\n", time.Now())
+	writeFile(t, repo, dir, "Puppetfile", "mod 'puppetlabs-stdlib', '6.0.0'\n")
+	// Unstaged changes in the working tree aren't read
+	if err := ioutil.WriteFile(filepath.Join(dir, "Puppetfile"), []byte("mod 'puppetlabs-stdlib', '7.0.0'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write Puppetfile with error: %v", err)
+	}
+	data, err := ReadStagedFile(repo, "Puppetfile")
+	if err != nil {
+		t.Fatalf("Failed to read staged Puppetfile with error: %v", err)
+	}
+	if expected := "mod 'puppetlabs-stdlib', '6.0.0'\n"; string(data) != expected {
+		t.Errorf("Expected staged Puppetfile to be %q, got %q", expected, data)
+	}
+	if _, err := ReadStagedFile(repo, "Missing"); err == nil {
+		t.Errorf("Expected an error reading a file that isn't staged")
+	}
+}
+
+func TestFilterBranches(t *testing.T) {
+	branches := map[string]plumbing.Hash{}
+	for _, name := range []string{"production", "development", "feature_a", "release/2020", "release/2020/x", "hotfix-1", "hotfix-12"} {
+		branches[name] = plumbing.ZeroHash
+	}
+	cases := map[string][]string{
+		"":                          {"development", "feature_a", "hotfix-1", "hotfix-12", "production", "release/2020", "release/2020/x"},
+		"production,dev*":           {"development", "production"},
+		"release/*":                 {"release/2020", "release/2020/x"},
+		"hotfix-?":                  {"hotfix-1"},
+		"[pd]*":                     {"development", "production"},
+		"[!pdr]*":                   {"feature_a", "hotfix-1", "hotfix-12"},
+		"feature_a,feature_a,nope*": {"feature_a"},
+	}
+	for patterns, expected := range cases {
+		var globs []string
+		if patterns != "" {
+			globs = strings.Split(patterns, ",")
+		}
+		filtered, err := FilterBranches(branches, globs)
+		if err != nil {
+			t.Fatalf("Failed to filter branches with %s with error: %v", patterns, err)
+		}
+		var names []string
+		for name := range filtered {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected %s to match %v, got %v", patterns, expected, names)
+		}
+	}
+	if _, err := FilterBranches(branches, []string{"release/[2020"}); err == nil {
+		t.Errorf("Expected an error filtering with an invalid pattern")
+	}
+}
@@ -61,6 +61,10 @@ format (<namespace>-<modulename>) if it is a Forge module. You can also specify
 a URL to a git repository to add non-Forge modules. 
 
 Flags can be passed to add properties and metadata to the module entry.
+
+Adding a Forge module that is deprecated logs a warning with the reason and
+the module that supersedes it, if any. Use --deprecated refuse, or set
+forge.deprecated_modules to refuse in the config file, to fail instead.
 `
 
 // AddMetaUse is the usage description for the pufctl add meta command
@@ -93,7 +97,7 @@ $ pufctl add meta top -m '# Production Puppetfile'
 `
 
 // DiffUse is the usage description for the pufctl diff command
-const DiffUse = "diff [Puppetfile] [<optional> Puppetfile]"
+const DiffUse = "diff [Puppetfile|revision] [<optional> Puppetfile]..."
 
 // DiffShort is the short description for the pufctl diff command
 const DiffShort = "diff finds the difference between two Puppetfiles"
@@ -105,6 +109,49 @@ find differences in them. You must supply the path to at least one Puppetfile.
 If only one Puppetfile is specified, the configured default Puppetfile will
 be used as the first Puppetfile in the comparison.
 
+Puppetfiles can be paths or Git URLs. Git URLs can include the branch to use
+after a "#", otherwise the branch is set with --branch-one and --branch-two:
+
+$ pufctl diff git@github.com:fakeorg/control-repo.git#production git@github.com:fakeorg/control-repo.git#test -s
+
+Modules are compared property by property. For each changed module, the diff
+shows added, removed, and changed properties, whether a version change is an
+upgrade or a downgrade (and if it is a major, minor, or patch change), and if
+the module's source changed, such as from the Forge to Git. Use --diff-meta to
+include changes to module meta tags.
+
+The diff is printed with --show (-s) or when an output format is selected with
+--format (-f):
+
+text:     the default human readable layout
+unified:  a unified diff, like "diff -u", of the normalized Puppetfile renders
+json:     a stable JSON schema for bots and scripts
+markdown: tables of added, removed, and changed modules for merge request comments
+junit:    a JUnit XML report where each differing module is a failed test case
+
+When run inside a local Git working copy, you can also diff the configured
+Puppetfile against revisions of the repository. The Puppetfile is read directly
+from the repository, no cloning required:
+
+$ pufctl diff HEAD~3          (revision vs. working tree)
+
+$ pufctl diff v1.2..v1.3      (revision vs. revision)
+
+$ pufctl diff --staged        (HEAD vs. index)
+
+$ pufctl diff --staged v1.2   (revision vs. index)
+
+More than two Puppetfiles can be diffed at once to get a consolidated report,
+such as for the Puppetfiles of many environments. The report lists modules that
+are missing from some of the Puppetfiles and modules with differing versions,
+along with the majority version of each module. Git URLs without a branch use
+the configured Puppetfile branch:
+
+$ pufctl diff prod/Puppetfile dev/Puppetfile git@github.com:fakeorg/control-repo.git#test -s
+
+The text, json, and markdown formats are supported when diffing more than two
+Puppetfiles.
+
 Meaningful exit codes have been added to the diff command to assist with
 programatic implementations of this command (such as use in CI/CD systems).
 
@@ -117,6 +164,184 @@ Exit Codes:
 4: Only the second Puppetfile has differences
 
 5: Differences in both Puppetfiles
+
+When diffing more than two Puppetfiles, the exit codes are extended as follows:
+
+3: Only the first Puppetfile has modules that the others are missing
+
+4: Only the other Puppetfiles have modules that the first is missing
+
+5: Any other difference, such as differing versions
+`
+
+// HistoryUse is the usage description for the pufctl history command
+const HistoryUse = "history"
+
+// HistoryShort is the short description for the pufctl history command
+const HistoryShort = "history shows when and by whom module versions changed"
+
+// HistoryLong is the long description for the pufctl history command
+const HistoryLong = `
+The pufctl history command walks the Git commits that changed the Puppetfile,
+parses each version of the Puppetfile, and reports a timeline of version and
+ref changes for each module. Each change shows the commit, author, date, and
+the old and new version or ref of the module.
+
+The Puppetfile can be a path inside a local Git working copy or a Git URL. If
+a Git URL is used, the branch set by --puppetfile-branch is cloned into memory.
+
+Use the --module (-m) flag to limit the history to specific modules and the
+--since and --until flags to limit the history to a date range.
+
+Examples:
+
+$ pufctl history -m puppetlabs-apache,puppetlabs-stdlib
+
+$ pufctl history --since 2020-01-01 --until 2020-06-30 --format json
+`
+
+// MatrixUse is the usage description for the pufctl matrix command
+const MatrixUse = "matrix [repo]"
+
+// MatrixShort is the short description for the pufctl matrix command
+const MatrixShort = "matrix shows module versions across all branches of a control repo"
+
+// MatrixLong is the long description for the pufctl matrix command
+const MatrixLong = `
+The pufctl matrix command builds a module x environment table of module versions
+from a control repo that has one branch per Puppet environment. The repo can be
+a Git URL or a path to a local working copy.
+
+Remote repos are cloned into memory once and the Puppetfile of every branch is
+read from that single clone. Puppetfiles are parsed in parallel, and the number
+of parallel workers can be set with the --jobs (-j) flag.
+
+Use the --branches (-b) flag to only include branches matching glob patterns.
+In patterns, * also matches /, so 'release/*' matches release/2020/x.
+Modules that are missing from an environment, or that have different versions
+across environments, are highlighted as drift.
+
+Output formats: text, csv, json, and markdown.
+
+Examples:
+
+$ pufctl matrix git@github.com:fakeorg/control-repo.git -b 'production,development,feature_*'
+
+$ pufctl matrix ./control-repo --format markdown --drift-only
+`
+
+// MergeUse is the usage description for the pufctl merge command
+const MergeUse = "merge [base Puppetfile] [ours Puppetfile] [theirs Puppetfile]"
+
+// MergeShort is the short description for the pufctl merge command
+const MergeShort = "three-way merge Puppetfiles module by module"
+
+// MergeLong is the long description for the pufctl merge command
+const MergeLong = `
+The pufctl merge command performs a three-way merge of Puppetfiles. Instead of
+merging lines of text, each module is merged on its own using the parsed
+Puppetfiles, together with the comments and meta tags above it. If only one
+side changed a module, or both sides made the same change, the change is taken
+automatically. Only modules that were changed differently on both sides are
+conflicts, and they are surrounded by conflict markers in the merged
+Puppetfile. The forge and moduledir directives, and the comments at the top and
+bottom of the Puppetfile, are merged the same way.
+
+Use --prefer-higher to resolve modules whose version was changed on both sides
+by choosing the higher semantic version. Modules changed to the same version
+with other differences, such as their source, are still conflicts. This can
+also be enabled with the merge.prefer_higher config setting.
+
+The merged Puppetfile is printed, or written to the file given with --out-file (-o).
+The exit code is 1 if there are conflicts.
+
+pufctl merge can also be used as a Git merge driver with the --driver flag. In
+this mode, the merged Puppetfile is written to the ours Puppetfile (%A), as Git
+expects. To install the merge driver, add the following to your Git config:
+
+[merge "pufctl"]
+    name = Puppetfile merge driver
+    driver = pufctl merge --driver --marker-size %L %O %A %B
+
+And the following to the .gitattributes file of your control repo:
+
+Puppetfile merge=pufctl
+
+Examples:
+
+$ pufctl merge base/Puppetfile ours/Puppetfile theirs/Puppetfile -o Puppetfile
+
+$ pufctl merge --prefer-higher base/Puppetfile ours/Puppetfile theirs/Puppetfile
+`
+
+// PromoteUse is the usage description for the pufctl promote command
+const PromoteUse = "promote [source Puppetfile] [target Puppetfile]"
+
+// PromoteShort is the short description for the pufctl promote command
+const PromoteShort = "promote module versions from one Puppetfile to another"
+
+// PromoteLong is the long description for the pufctl promote command
+const PromoteLong = `
+The pufctl promote command copies module versions, properties, and meta tags
+from a source Puppetfile to a target Puppetfile, such as from the development
+environment to the production environment. Modules are promoted the same way
+pufctl sync syncs them. Both the source and target can be a path to a
+Puppetfile or a Git URL. Use --source-branch and --target-branch to select the
+branches of Git URLs.
+
+Modules to promote are selected with one or more of the following:
+  --modules (-m): a comma-separated list of module names
+  --tag (-t): all modules with the meta tag in the source Puppetfile
+  --all (-a): all modules that differ between the source and target
+
+Selected modules that are missing from the target are added to it. A summary of
+the promoted changes is printed once the modules have been promoted.
+
+The promoted Puppetfile can be written with --write-in-place (-w) or --out-file (-o),
+or committed with --commit. If the target is a Git URL, --commit commits the
+Puppetfile to the target branch and pushes it.
+
+Examples:
+
+$ pufctl promote ./dev/Puppetfile ./prod/Puppetfile -m puppetlabs-stdlib,puppetlabs-concat -w
+
+$ pufctl promote git@github.com:fakeorg/control-repo.git git@github.com:fakeorg/control-repo.git \
+    --source-branch development --target-branch production --all --commit
+`
+
+// SyncUse is the usage description for the pufctl sync command
+const SyncUse = "sync"
+
+// SyncShort is the short description for the pufctl sync command
+const SyncShort = "sync modules from a reference Puppetfile"
+
+// SyncLong is the long description for the pufctl sync command
+const SyncLong = `
+The pufctl sync command makes modules in the Puppetfile match a reference
+Puppetfile, given with --from. The reference can be a path to a Puppetfile or
+a Git URL. Module definitions, including properties, versions, and meta tags,
+are copied from the reference. Selected modules missing from the Puppetfile
+are added to it.
+
+Modules to sync are selected with one or more of the following:
+  --modules (-m): a comma-separated list of module names
+  --tag (-t): all modules with the meta tag in the reference Puppetfile
+  --all (-a): all modules in the reference Puppetfile
+
+With --prune, selected modules that are absent from the reference are removed.
+With --tag, modules that have the meta tag in the Puppetfile but are absent
+from the reference are removed too. With --all, every module absent from the
+reference is removed.
+
+Use --dry-run to preview the changes as a unified diff without writing them.
+
+Examples:
+
+$ pufctl sync --from golden.Puppetfile --modules puppetlabs-stdlib,puppetlabs-concat -w
+
+$ pufctl sync --from golden.Puppetfile --tag @platform --dry-run
+
+$ pufctl sync --from git@github.com:fakeorg/golden.git --from-branch main --all --prune -w
 `
 
 // SearchUse is the usage description for the pufctl search command
@@ -142,6 +367,17 @@ The pufctl search forge command allows you to search for modules on the Puppet F
 
 Search queries are passed as command args, and the search results can be fine-tuned
 with flags.
+
+By default, a single page of --limit search hits is returned. Use --all to follow
+the pages of results until all search hits are returned, or until --max search
+hits are returned. With --all, --limit sets the number of search hits requested
+at a time, and defaults to 100.
+
+Examples:
+
+$ pufctl search forge apache --limit 10
+
+$ pufctl search forge ntp --owner puppetlabs --all --max 250
 `
 
 // BumpUse is the usage description of the pufctl bump command
@@ -285,31 +521,377 @@ You can use the --out-file flag to specify where the config file will be generat
 If --out-file is not used, the config file will be generated at $HOME/.pufctl.yaml.
 `
 
-// NewUse is the usage description of the pufctl new command
-const NewUse = "new [subcommand]"
+// GenerateUse is the usage description of the pufctl generate command
+const GenerateUse = "generate [type]"
+
+// GenerateShort is the the short description of the pufctl generate command
+const GenerateShort = "generate files such as .fixtures.yml from the Puppetfile"
+
+// GenerateLong is the long description of the pufctl generate command
+const GenerateLong = `
+The pufctl generate command generates files for other tools from the Puppetfile`
+
+// GenerateFixturesUse is the usage description of the pufctl generate fixtures command
+const GenerateFixturesUse = "fixtures"
+
+// GenerateFixturesShort is the short description of the pufctl generate fixtures command
+const GenerateFixturesShort = "generate a .fixtures.yml file for rspec-puppet"
+
+// GenerateFixturesLong is the long description of the pufctl generate fixtures command
+const GenerateFixturesLong = `
+The pufctl generate fixtures command generates a .fixtures.yml file for
+rspec-puppet from the modules in the Puppetfile.
 
-// NewShort is the the short description of the pufctl new command
-const NewShort = "new allows you to create new Puppetfiles or .fixtures.yml files"
+Forge modules are added to forge_modules with their pinned version as the ref.
+Git modules are added to repositories with their :commit, :tag, or :ref as the
+ref, or their :branch as the branch. Local, SVN, and tarball modules are
+skipped.
 
-// NewLong is the long description of the pufctl new command
-const NewLong = `
-Create a new Puppetfile or .fixtures.yml file.
+Use --module (-m) to only add the module and its dependency closure. The
+dependencies of Forge modules are fetched from the Forge API, and the
+dependencies of Git modules are read from the metadata.json in their repo.
+The module doesn't have to be in the Puppetfile if it is the module in the
+current directory, in which case its metadata.json is used. Dependencies that
+aren't in the Puppetfile are reported as warnings.
 
-NOT CURRENTLY IMPLEMENTED
+With --symlink, the module given with --module is added to symlinks, pointing
+to #{source_dir}, instead of being fetched. Use this to test the module itself.
+
+The .fixtures.yml is printed, or written to the path given with --out-file.
+
+Examples:
+
+$ pufctl generate fixtures -o .fixtures.yml
+
+$ pufctl generate fixtures --puppetfile ../control-repo/Puppetfile -m fakeorg-mymodule --symlink
 `
 
-// NewFixturesUse is the usage description of the pufctl new fixtures command
-const NewFixturesUse = "fixtures [nameslug|URL]"
+// InstallUse is the usage description for the pufctl install command
+const InstallUse = "install"
+
+// InstallShort is the short description for the pufctl install command
+const InstallShort = "install the modules in the Puppetfile into a moduledir"
+
+// InstallLong is the long description for the pufctl install command
+const InstallLong = `
+The pufctl install command deploys the modules in the Puppetfile into a
+moduledir, the same way r10k does, so environments can be tested locally.
+
+Forge modules are downloaded from the Forge API given with --forge-api, and
+their tarballs are verified against the release's SHA256 and MD5 checksums
+before being extracted. Modules without a version, or with :latest, are
+installed at the current release. Git modules are cloned and checked out at
+their :commit, :tag, :ref, or :branch, or at the remote's default branch.
+Modules with :local => true are left alone. SVN and tarball modules are not
+supported.
+
+Modules are installed into the moduledir given with --moduledir, or declared
+in the Puppetfile with moduledir 'path', or into "modules". Relative paths,
+including the :install_path of a module, are relative to the directory of the
+Puppetfile. Modules that are already installed at the right version are
+skipped, so running install again only changes what has changed.
+
+Exits 1 if any module fails to install.
+
+Examples:
 
-// NewFixturesShort is the short description of the pufctl new fixtures command
-const NewFixturesShort = "fixtures allows you to create a new .fixtures.yml file"
+$ pufctl install
 
-// NewFixturesLong is the long description of the pufctl new fixtures command
-const NewFixturesLong = `
-Create a new .fixtures.yml file from a module name slug (<org>-<module>) or a Git URL.
+$ pufctl install --puppetfile ./control-repo/Puppetfile --moduledir /tmp/modules --jobs 8
+
+$ pufctl install --forge-api http://localhost:8080
+`
+
+// VerifyUse is the usage description for the pufctl verify command
+const VerifyUse = "verify"
+
+// VerifyShort is the short description for the pufctl verify command
+const VerifyShort = "report drift between the Puppetfile and the installed modules"
+
+// VerifyLong is the long description for the pufctl verify command
+const VerifyLong = `
+The pufctl verify command compares the modules installed in a moduledir with
+the Puppetfile and reports drift. It doesn't access the network, so it can be
+run on compile masters to catch hand-edited modules.
+
+Drift is reported as one of:
+  missing: the module is in the Puppetfile but isn't installed
+  extra: a module is installed in the moduledir but isn't in the Puppetfile
+  wrong_version: the version in the module's metadata.json, or the commit
+    checked out in a Git module, doesn't match the Puppetfile
+  modified: files of the module were changed locally. Forge modules are
+    checked against their checksums.json, and Git modules against git status
+
+Modules without a version, or with :latest, are only checked for local
+modifications. Modules with :local => true are not checked.
+
+The moduledir is found the same way as the install command: --moduledir, the
+moduledir declared in the Puppetfile, or "modules", relative to the directory
+of the Puppetfile.
+
+Exits 0 if there is no drift, or 2 if there is drift.
+
+Examples:
+
+$ pufctl verify
+
+$ pufctl verify --puppetfile /etc/puppetlabs/code/environments/production/Puppetfile
+`
+
+// InitUse is the usage description for the pufctl init command
+const InitUse = "init"
+
+// InitShort is the short description for the pufctl init command
+const InitShort = "bootstrap a Puppetfile from an existing modules directory"
+
+// InitLong is the long description for the pufctl init command
+const InitLong = `
+The pufctl init command generates a sorted Puppetfile from a hand-populated
+modules directory, given with --from-moduledir.
+
+Each subdirectory is classified as one of:
+  Git module: a Git clone with an origin remote. The module is pinned with
+    :tag if a tag points to the checked out commit, or with :commit otherwise.
+  Forge module: a directory with a metadata.json that has a name and version.
+    The name in metadata.json must match the directory name.
+
+Modules that can't be classified are added with :local => true and tagged
+with the reason, Ex. # @unclassified: no metadata.json or Git clone. Search
+for them with the @unclassified meta tag.
+
+The Puppetfile is printed, or written to the path given with --out-file.
+
+Examples:
+
+$ pufctl init --from-moduledir /etc/puppetlabs/code/environments/production/modules
+
+$ pufctl init --from-moduledir ./modules -o Puppetfile
+`
+
+// ImportUse is the usage description for the pufctl import command
+const ImportUse = "import [type]"
+
+// ImportShort is the short description for the pufctl import command
+const ImportShort = "import modules from the module lists of other tools"
+
+// ImportLong is the long description for the pufctl import command
+const ImportLong = `
+The pufctl import command generates a Puppetfile from the module lists of other Puppet tools`
+
+// ImportModuleListUse is the usage description for the pufctl import puppet-module-list command
+const ImportModuleListUse = "puppet-module-list [file]"
+
+// ImportModuleListShort is the short description for the pufctl import puppet-module-list command
+const ImportModuleListShort = "import the output of puppet module list --render-as json"
+
+// ImportModuleListLong is the long description for the pufctl import puppet-module-list command
+const ImportModuleListLong = `
+The pufctl import puppet-module-list command generates a sorted Puppetfile from
+the output of puppet module list --render-as json. Use - as the file to read
+from stdin.
+
+Modules are pinned to their listed versions and tagged with the modulepath
+they were found in, Ex. # @modulepath: /etc/puppetlabs/code/modules. Modules
+whose metadata has a Git URL as their source are also tagged with it, Ex.
+# @git_source: git@github.com:fakeorg/concat.git. If a module is found in more
+than one modulepath, only the first is imported, the same as Puppet.
+
+Use --modulepath to only import the modules in one modulepath.
+
+The Puppetfile is printed, or written to the path given with --out-file.
+
+Examples:
+
+$ puppet module list --render-as json | pufctl import puppet-module-list -
+
+$ pufctl import puppet-module-list modules.json --modulepath /etc/puppetlabs/code/environments/production/modules -o Puppetfile
+`
+
+// MetadataUse is the usage description for the pufctl metadata command
+const MetadataUse = "metadata"
+
+// MetadataShort is the short description for the pufctl metadata command
+const MetadataShort = "work with the metadata.json of a module"
+
+// MetadataLong is the long description for the pufctl metadata command
+const MetadataLong = `
+The pufctl metadata command works with the metadata.json of the module in the
+current directory`
+
+// MetadataSyncUse is the usage description for the pufctl metadata sync command
+const MetadataSyncUse = "sync"
+
+// MetadataSyncShort is the short description for the pufctl metadata sync command
+const MetadataSyncShort = "sync metadata.json dependencies with the Puppetfile"
+
+// MetadataSyncLong is the long description for the pufctl metadata sync command
+const MetadataSyncLong = `
+The pufctl metadata sync command keeps the dependencies in a module's
+metadata.json in sync with a Puppetfile, such as the one used to test it.
+
+By default (--to puppetfile), a Forge module is added to the Puppetfile for
+each dependency that isn't in it yet, pinned to the highest release on the
+Forge that satisfies the dependency's version_requirement. Dependencies that
+are in the Puppetfile at a version that doesn't satisfy their
+version_requirement are reported as warnings.
+
+With --to metadata, the version_requirement of each dependency that is pinned
+in the Puppetfile is set to allow versions from the pinned version up to the
+next major version, Ex. ">= 6.0.0 < 7.0.0". Forge modules are pinned by their
+version, and Git modules by a :tag that is a semantic version. The rest of the
+metadata.json is left as it is. The updated metadata.json is printed, written
+in place with --write-in-place, or written to the path given with --out-file.
+
+Examples:
+
+$ pufctl metadata sync -p spec/fixtures/Puppetfile -w
+
+$ pufctl metadata sync --to metadata -p spec/fixtures/Puppetfile -w
+`
+
+// BoltUse is the usage description for the pufctl bolt command
+const BoltUse = "bolt"
+
+// BoltShort is the short description for the pufctl bolt command
+const BoltShort = "move modules between a Bolt project and a Puppetfile"
+
+// BoltLong is the long description for the pufctl bolt command
+const BoltLong = `
+The pufctl bolt command moves the module list of a Bolt project, declared
+under modules in bolt-project.yaml, to and from a Puppetfile. Use --project to
+set the path to the bolt-project.yaml.`
+
+// BoltImportUse is the usage description for the pufctl bolt import command
+const BoltImportUse = "import"
+
+// BoltImportShort is the short description for the pufctl bolt import command
+const BoltImportShort = "generate a Puppetfile from the modules in bolt-project.yaml"
+
+// BoltImportLong is the long description for the pufctl bolt import command
+const BoltImportLong = `
+The pufctl bolt import command generates a sorted Puppetfile from the modules
+declared in a bolt-project.yaml.
+
+Forge modules with an exact version_requirement are pinned to it. Forge
+modules with a version range are pinned to the highest release on the Forge
+that satisfies it, and tagged with the range, Ex.
+# @version_requirement: >= 4.0.0 < 7.0.0, so it can be exported again. Forge
+modules without a version_requirement are added without a version. Git
+modules are added with their ref as :ref. Modules with resolve: false are
+tagged with # @bolt_resolve: false.
+
+Unlike the Puppetfile Bolt manages, the generated Puppetfile doesn't include
+the dependencies of the modules.
+
+The Puppetfile is printed, or written to the path given with --out-file.
+
+Examples:
+
+$ pufctl bolt import -o Puppetfile
+
+$ pufctl bolt import --project ../myproject/bolt-project.yaml
+`
+
+// BoltExportUse is the usage description for the pufctl bolt export command
+const BoltExportUse = "export"
+
+// BoltExportShort is the short description for the pufctl bolt export command
+const BoltExportShort = "write the modules in the Puppetfile to bolt-project.yaml"
+
+// BoltExportLong is the long description for the pufctl bolt export command
+const BoltExportLong = `
+The pufctl bolt export command sets the modules in a bolt-project.yaml to the
+modules in the Puppetfile. Other keys in the bolt-project.yaml are kept in
+their order, but comments are not. If the bolt-project.yaml doesn't exist, a
+new one with only modules is generated.
+
+Forge modules keep the version_requirement they were imported with, as long as
+their pinned version satisfies it, and otherwise require their pinned version.
+Git modules are exported with their :commit, :tag, :ref, or :branch as the
+ref. Git modules without a ref, and local, SVN, and tarball modules, are
+skipped with a warning.
+
+The bolt-project.yaml is printed, written in place with --write-in-place, or
+written to the path given with --out-file.
+
+Examples:
+
+$ pufctl bolt export -w
+
+$ pufctl bolt export -p ../control-repo/Puppetfile -o bolt-project.yaml
+`
+
+// ForgeUse is the usage description for the pufctl forge command
+const ForgeUse = "forge"
+
+// ForgeShort is the short description for the pufctl forge command
+const ForgeShort = "run a local Puppet Forge"
+
+// ForgeLong is the long description for the pufctl forge command
+const ForgeLong = `
+The pufctl forge command runs a local Puppet Forge, for air-gapped sites and
+for testing.`
+
+// ForgeServeUse is the usage description for the pufctl forge serve command
+const ForgeServeUse = "serve [directory]"
+
+// ForgeServeShort is the short description for the pufctl forge serve command
+const ForgeServeShort = "serve a Forge API from a directory of module tarballs"
+
+// ForgeServeLong is the long description for the pufctl forge serve command
+const ForgeServeLong = `
+The pufctl forge serve command serves the modules, releases, and files
+endpoints of the Forge v3 API from the module release tarballs (*.tar.gz) in a
+directory, which defaults to the current directory. Module and release
+information is generated from the metadata.json in each tarball, and file
+checksums are computed, so other pufctl commands, r10k, and puppet module
+install can use it like the Puppet Forge. The list endpoints support the
+query, owner, tag, and slugs parameters for modules, and the module and owner
+parameters for releases.
+
+Tarballs are loaded when the server starts. Use --reload to load them again
+periodically, Ex. after new tarballs are copied into the directory.
+
+Examples:
+
+$ pufctl forge serve /srv/forge --listen 0.0.0.0:8080
+
+$ pufctl add module puppetlabs-stdlib --forge-api http://localhost:8080 -w
+`
+
+// AuditUse is the usage description for the pufctl audit command
+const AuditUse = "audit"
+
+// AuditShort is the short description for the pufctl audit command
+const AuditShort = "report problems with the modules in the Puppetfile"
+
+// AuditLong is the long description for the pufctl audit command
+const AuditLong = `
+The pufctl audit command reports problems with the modules in the Puppetfile.`
+
+// AuditDeprecatedUse is the usage description for the pufctl audit deprecated command
+const AuditDeprecatedUse = "deprecated"
+
+// AuditDeprecatedShort is the short description for the pufctl audit deprecated command
+const AuditDeprecatedShort = "report Forge modules in the Puppetfile that are deprecated"
+
+// AuditDeprecatedLong is the long description for the pufctl audit deprecated command
+const AuditDeprecatedLong = `
+The pufctl audit deprecated command looks up the Forge modules in the
+Puppetfile on the Forge, and lists the ones that are deprecated with when and
+why they were deprecated, and the module that supersedes them, if any. It
+exits with status 2 if any modules are deprecated, so it can be used in CI.
+
+Use --rewrite to replace each deprecated module that has a replacement with
+it. Pinned modules are pinned to the latest version of their replacement, and
+unpinned modules, including those on :latest, are left as they are. If the
+replacement is in the Puppetfile already, the deprecated module is removed.
+Deprecated modules without a replacement are left as they are. --rewrite needs
+one of --show (-s), --write-in-place (-w), or --out-file (-o).
+
+Examples:
 
-If you pass in a Git URL, the module must have a metadata.json file so that the dependencies
-can be properly parsed.
+$ pufctl audit deprecated
 
-NOT CURRENTLY IMPLEMENTED
+$ pufctl audit deprecated --rewrite -w
 `
@@ -0,0 +1,180 @@
+package forgeapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached response is used before it is
+// revalidated with the Forge
+const DefaultCacheTTL = time.Hour
+
+// CacheHeader is set on responses served by a Cache, with a value of "hit"
+// for fresh responses and "revalidated" for responses the Forge confirmed
+// are unchanged
+const CacheHeader = "X-Pufctl-Cache"
+
+// Cache is an HTTPClient that caches the module and release responses of a
+// Forge API on disk, keyed by URL. Cached responses are used without a
+// request until they are older than TTL, and are then revalidated with
+// If-None-Match and If-Modified-Since, so an unchanged response costs the
+// Forge a 304. When Offline is set, responses are only served from the
+// cache, however old, and requests for anything else fail with a
+// CacheMissError.
+type Cache struct {
+	Dir        string
+	TTL        time.Duration
+	Offline    bool
+	HTTPClient HTTPClient
+}
+
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Stored       time.Time `json:"stored"`
+	Body         []byte    `json:"body"`
+}
+
+// NewCache returns a Cache in dir that makes requests with client
+func NewCache(dir string, ttl time.Duration, client HTTPClient) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, HTTPClient: client}
+}
+
+// Do makes an HTTP request, serving module and release GET requests from
+// the cache when possible
+func (c *Cache) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || !cacheable(req.URL.Path) {
+		if c.Offline {
+			return nil, &CacheMissError{URL: req.URL.String()}
+		}
+		return c.HTTPClient.Do(req)
+	}
+	url := req.URL.String()
+	entry, cached := c.load(url)
+	if cached && (c.Offline || time.Since(entry.Stored) < c.TTL) {
+		return entry.response(req, "hit"), nil
+	}
+	if c.Offline {
+		return nil, &CacheMissError{URL: url}
+	}
+	if cached {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		entry.Stored = time.Now()
+		c.store(entry)
+		return entry.response(req, "revalidated"), nil
+	case resp.StatusCode == http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		c.store(cacheEntry{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Stored:       time.Now(),
+			Body:         body,
+		})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// Clear removes all cached responses
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// cacheable reports whether responses from the path are cached, which
+// is true for the module and release endpoints, including release files
+func cacheable(path string) bool {
+	for _, endpoint := range []string{V3ModulesEndpoint, V3ReleasesEndpoint, V3FilesEndpoint} {
+		if strings.Contains(path, endpoint+"/") || strings.HasSuffix(path, endpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) load(url string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := ioutil.ReadFile(c.path(url))
+	if err != nil || json.Unmarshal(data, &entry) != nil || entry.URL != url {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes the entry to a temporary file that is renamed into place,
+// so concurrent requests never read a partly written entry. Failing to
+// cache a response doesn't fail the request.
+func (c *Cache) store(entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(c.Dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || os.Rename(tmp.Name(), c.path(entry.URL)) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (e cacheEntry) response(req *http.Request, status string) *http.Response {
+	header := http.Header{}
+	header.Set(CacheHeader, status)
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("Last-Modified", e.LastModified)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
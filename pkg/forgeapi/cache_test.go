@@ -0,0 +1,127 @@
+package forgeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const cacheModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// etagForge serves modules with an ETag and Last-Modified header, and
+// answers conditional requests that match either with a 304
+func etagForge(etag string) (*httptest.Server, *int32, *int32) {
+	var requests, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if (etag != "" && r.Header.Get("If-None-Match") == etag) || r.Header.Get("If-Modified-Since") == cacheModified {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Last-Modified", cacheModified)
+		fmt.Fprintf(w, `{"slug": "%s"}`, r.URL.Path[len(V3ModulesEndpoint)+1:])
+	}))
+	return srv, &requests, &notModified
+}
+
+func cacheClient(t *testing.T, url string, ttl time.Duration) (*Client, *Cache, func()) {
+	dir, err := ioutil.TempDir("", "forgecache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCache(dir, ttl, http.DefaultClient)
+	c := testClient(url)
+	c.HTTPClient = cache
+	return c, cache, func() { os.RemoveAll(dir) }
+}
+
+func TestCacheFresh(t *testing.T) {
+	srv, requests, _ := etagForge(`"v1"`)
+	defer srv.Close()
+	c, _, cleanup := cacheClient(t, srv.URL, time.Hour)
+	defer cleanup()
+	for i := 0; i < 3; i++ {
+		mod, err := c.FetchModule(context.Background(), "puppetlabs-apache")
+		if err != nil || mod.Slug != "puppetlabs-apache" {
+			t.Fatalf("Expected puppetlabs-apache, got %s and error: %v", mod.Slug, err)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("Expected a fresh cached response to be used without a request, got %d requests", *requests)
+	}
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	for _, etag := range []string{`"v1"`, ""} {
+		srv, requests, notModified := etagForge(etag)
+		c, cache, cleanup := cacheClient(t, srv.URL, 0)
+		for i := 0; i < 3; i++ {
+			mod, err := c.FetchModule(context.Background(), "puppetlabs-apache")
+			if err != nil || mod.Slug != "puppetlabs-apache" {
+				t.Errorf("Expected puppetlabs-apache, got %s and error: %v", mod.Slug, err)
+			}
+		}
+		if *requests != 3 || *notModified != 2 {
+			t.Errorf("Expected stale responses to be revalidated with ETag %q, got %d requests and %d 304s", etag, *requests, *notModified)
+		}
+		req, _ := http.NewRequest("GET", srv.URL+V3ModulesEndpoint+"/puppetlabs-apache", nil)
+		resp, err := cache.Do(req)
+		if err != nil || resp.Header.Get(CacheHeader) != "revalidated" {
+			t.Errorf("Expected a revalidated response, got %v and error: %v", resp.Header, err)
+		}
+		srv.Close()
+		cleanup()
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	srv, requests, _ := etagForge(`"v1"`)
+	defer srv.Close()
+	c, cache, cleanup := cacheClient(t, srv.URL, 0)
+	defer cleanup()
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil {
+		t.Fatalf("Failed to fetch module with error: %v", err)
+	}
+	cache.Offline = true
+	if mod, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil || mod.Slug != "puppetlabs-apache" {
+		t.Errorf("Expected a stale cached response offline, got %s and error: %v", mod.Slug, err)
+	}
+	_, err := c.FetchModule(context.Background(), "puppetlabs-stdlib")
+	var miss *CacheMissError
+	if !errors.As(err, &miss) {
+		t.Errorf("Expected a CacheMissError offline, got: %v", err)
+	}
+	if _, err := c.FetchUser(context.Background(), "puppetlabs"); !errors.As(err, &miss) {
+		t.Errorf("Expected a CacheMissError for an uncached endpoint offline, got: %v", err)
+	}
+	if *requests != 1 {
+		t.Errorf("Expected no requests offline, got %d requests", *requests-1)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	tests := map[string]bool{
+		"/v3/modules/puppetlabs-apache":              true,
+		"/v3/modules":                                true,
+		"/forge/v3/releases/puppetlabs-apache-4.0.0": true,
+		"/v3/files/puppetlabs-apache-4.0.0.tar.gz":   true,
+		"/v3/users/puppetlabs":                       false,
+		"/v3/modulesx":                               false,
+	}
+	for path, expected := range tests {
+		if cacheable(path) != expected {
+			t.Errorf("Expected cacheable(%s) to be %t", path, expected)
+		}
+	}
+}
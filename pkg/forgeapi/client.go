@@ -0,0 +1,400 @@
+package forgeapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRetries is the number of times a Client retries a failed request
+const DefaultMaxRetries = 3
+
+// DefaultRetryWaitMin is the wait before the first retry of a Client
+const DefaultRetryWaitMin = 500 * time.Millisecond
+
+// DefaultRetryWaitMax is the longest a Client waits between retries,
+// including waits requested by a Retry-After header
+const DefaultRetryWaitMax = 30 * time.Second
+
+// DefaultConcurrency is the number of requests a Client makes at once when
+// fetching many modules
+const DefaultConcurrency = 8
+
+// maxErrorBody is the most of a response body kept in a GetNon200Error
+const maxErrorBody = 64 * 1024
+
+// Logger is used by a Client to log retries. It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Client makes requests to a Forge API. Failed requests are retried with
+// exponential backoff and jitter if the connection failed, or if the Forge
+// responded with 429 Too Many Requests or a 5xx status code. Waits
+// requested by a Retry-After header are honoured, up to RetryWaitMax.
+type Client struct {
+	// BaseURL is the URL of the Forge, or one of "default", "ipv6", or
+	// "ipv4" for the Puppet Forge
+	BaseURL   string
+	UserAgent string
+	// Token is sent as a bearer token with each request to the Forge, if
+	// set. Otherwise Username and Password are sent with basic auth, if
+	// Username is set.
+	Token      string
+	Username   string
+	Password   string
+	HTTPClient HTTPClient
+	// DownloadHTTPClient downloads release tarballs. HTTPClient is used
+	// instead if it is nil.
+	DownloadHTTPClient HTTPClient
+	// Logger logs retries, if set
+	Logger       Logger
+	MaxRetries   int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	// Concurrency is the number of requests made at once by FetchModules
+	Concurrency int
+}
+
+// NewClient returns a Client for the Forge at url that uses the
+// DefaultHTTPClient and DefaultDownloadHTTPClient, the default retry
+// settings, and the credentials set for url with SetCredentials
+func NewClient(url, agent string) *Client {
+	creds := CredentialsFor(url)
+	return &Client{
+		BaseURL:            url,
+		UserAgent:          agent,
+		Token:              creds.Token,
+		Username:           creds.Username,
+		Password:           creds.Password,
+		HTTPClient:         DefaultHTTPClient,
+		DownloadHTTPClient: DefaultDownloadHTTPClient,
+		MaxRetries:         DefaultMaxRetries,
+		RetryWaitMin:       DefaultRetryWaitMin,
+		RetryWaitMax:       DefaultRetryWaitMax,
+		Concurrency:        DefaultConcurrency,
+	}
+}
+
+// Get makes an HTTP Get request, retrying it if it fails with an error
+// that may be temporary. It returns the response on an HTTP response code
+// of 200, or the error of the last attempt otherwise.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.getOnce(ctx, url)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= c.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		wait := c.backoff(attempt, err)
+		c.logf("forgeapi: retrying GET %s in %s after attempt %d failed", url, wait, attempt+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &GetError{Err: ctx.Err(), URL: url, Headers: map[string]string{}}
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) getOnce(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, &GetError{Err: err, URL: url, Headers: map[string]string{}}
+	}
+	req = req.WithContext(ctx)
+	header, value := userAgent(c.UserAgent)
+	req.Header.Add(header, value)
+	if c.authenticates(url) {
+		if c.Token != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+		} else if c.Username != "" {
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		hdrs := map[string]string{header: value}
+		return nil, &GetError{Err: err, URL: url, Headers: hdrs}
+	}
+	if resp.StatusCode != 200 {
+		rerr := &GetNon200Error{URL: url, StatusCode: resp.StatusCode}
+		if resp.Body != nil {
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+			resp.Body.Close()
+			rerr.Body = string(body)
+		}
+		if resp.Header != nil {
+			rerr.RetryAfter = retryAfter(resp.Header.Get("Retry-After"))
+		}
+		return nil, rerr
+	}
+	return resp, nil
+}
+
+// authenticates reports whether credentials are sent with a request to
+// url, which is only true for URLs of the Client's Forge, so they aren't
+// leaked to other hosts, Ex. a CDN serving release files
+func (c *Client) authenticates(url string) bool {
+	base, err := requestBaseURL(c.BaseURL, "")
+	return err == nil && (url == base || strings.HasPrefix(url, base+"/") || strings.HasPrefix(url, base+"?"))
+}
+
+// retryable reports whether a failed request may succeed if retried
+func retryable(err error) bool {
+	switch e := err.(type) {
+	case *GetError:
+		var miss *CacheMissError
+		return !errors.As(e.Err, &miss)
+	case *GetNon200Error:
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before the next attempt. The wait
+// doubles with each attempt, with a random jitter of up to half of it.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	if e, ok := err.(*GetNon200Error); ok && e.RetryAfter > 0 {
+		if c.RetryWaitMax > 0 && e.RetryAfter > c.RetryWaitMax {
+			return c.RetryWaitMax
+		}
+		return e.RetryAfter
+	}
+	wait := c.RetryWaitMin << uint(attempt)
+	if c.RetryWaitMax > 0 && (wait > c.RetryWaitMax || wait <= 0) {
+		wait = c.RetryWaitMax
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait = time.Duration(half + rand.Int63n(half+1))
+	}
+	return wait
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
+}
+
+// getJSON makes an HTTP Get request and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, finalURL string, v interface{}) error {
+	resp, err := c.Get(ctx, finalURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &JSONDecodeError{Err: err}
+	}
+	return nil
+}
+
+// FetchModule performs a Forge API get request for the named module
+func (c *Client) FetchModule(ctx context.Context, nameslug string) (Module, error) {
+	var mod Module
+	baseURL, err := requestBaseURL(c.BaseURL, "modules")
+	if err != nil {
+		return mod, &FetchError{Err: err}
+	}
+	finalURL := fmt.Sprintf("%s/%s", baseURL, nameslug)
+	if err := c.getJSON(ctx, finalURL, &mod); err != nil {
+		return Module{}, &FetchError{Err: err}
+	}
+	return mod, nil
+}
+
+// FetchModuleDependencies returns the Modules that are marked as
+// dependencies of the given module, in the order of its metadata. Modules
+// that could not be fetched are left out and returned as ModuleFetchErrors.
+func (c *Client) FetchModuleDependencies(ctx context.Context, mod Module) ([]Module, []error) {
+	var slugs []string
+	for _, d := range mod.CurrentRelease.Metadata.Dependencies {
+		slugs = append(slugs, d.Name)
+	}
+	var deps []Module
+	var errs []error
+	for _, r := range c.FetchModules(ctx, slugs) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+			continue
+		}
+		deps = append(deps, r.Module)
+	}
+	return deps, errs
+}
+
+// FetchRelease performs a Forge API get request for the named release. The
+// slug is the module's owner, name, and version, Ex. puppetlabs-apache-4.0.0
+func (c *Client) FetchRelease(ctx context.Context, slug string) (Release, error) {
+	var rel Release
+	baseURL, err := requestBaseURL(c.BaseURL, "releases")
+	if err != nil {
+		return rel, &FetchError{Err: err}
+	}
+	finalURL := fmt.Sprintf("%s/%s", baseURL, slug)
+	if err := c.getJSON(ctx, finalURL, &rel); err != nil {
+		return Release{}, &FetchError{Err: err}
+	}
+	return rel, nil
+}
+
+// FetchReleaseFile downloads the tarball of the release from its FileURI,
+// which is relative to the Forge URL, with the DownloadHTTPClient, and
+// verifies the tarball's checksums.
+func (c *Client) FetchReleaseFile(ctx context.Context, rel Release) ([]byte, error) {
+	fileURL := rel.FileURI
+	if !strings.HasPrefix(fileURL, "http://") && !strings.HasPrefix(fileURL, "https://") {
+		baseURL, err := requestBaseURL(c.BaseURL, "")
+		if err != nil {
+			return nil, &FetchError{Err: err}
+		}
+		fileURL = fmt.Sprintf("%s/%s", baseURL, strings.TrimPrefix(fileURL, "/"))
+	}
+	dl := *c
+	if c.DownloadHTTPClient != nil {
+		dl.HTTPClient = c.DownloadHTTPClient
+	}
+	resp, err := dl.Get(ctx, fileURL)
+	if err != nil {
+		return nil, &FetchError{Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &FetchError{Err: err}
+	}
+	if err := VerifyReleaseFile(rel, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// FetchReleasePlan performs a Forge API get request for the named plan of
+// a release, Ex. the plan facts::info of the release puppetlabs-facts-1.0.0
+func (c *Client) FetchReleasePlan(ctx context.Context, slug, plan string) (ReleasePlan, error) {
+	var rp ReleasePlan
+	baseURL, err := requestBaseURL(c.BaseURL, "releases")
+	if err != nil {
+		return rp, &FetchError{Err: err}
+	}
+	finalURL := fmt.Sprintf("%s/%s/plans/%s", baseURL, slug, plan)
+	if err := c.getJSON(ctx, finalURL, &rp); err != nil {
+		return ReleasePlan{}, &FetchError{Err: err}
+	}
+	return rp, nil
+}
+
+// FetchUser performs a Forge API get request for the named user
+func (c *Client) FetchUser(ctx context.Context, slug string) (User, error) {
+	var user User
+	baseURL, err := requestBaseURL(c.BaseURL, "users")
+	if err != nil {
+		return user, &FetchError{Err: err}
+	}
+	finalURL := fmt.Sprintf("%s/%s", baseURL, slug)
+	if err := c.getJSON(ctx, finalURL, &user); err != nil {
+		return User{}, &FetchError{Err: err}
+	}
+	return user, nil
+}
+
+// list requests a page of a list endpoint and decodes it into listRes
+func (c *Client) list(ctx context.Context, endpoint string, opts, listRes interface{}) error {
+	finalURL, err := listURL(c.BaseURL, endpoint, opts)
+	if err != nil {
+		return &ListError{Err: err}
+	}
+	if err := c.getJSON(ctx, finalURL, listRes); err != nil {
+		return &ListError{Err: err}
+	}
+	return nil
+}
+
+// ListModules returns a page of Modules based on the search
+// criteria outlined in the opts param.
+func (c *Client) ListModules(ctx context.Context, opts ListModulesOpts) (ListResults, error) {
+	var listRes ListResults
+	err := c.list(ctx, "modules", opts, &listRes)
+	return listRes, err
+}
+
+// ListReleases returns a page of Releases based on the search
+// criteria outlined in the opts param.
+func (c *Client) ListReleases(ctx context.Context, opts ListReleasesOpts) (ListReleasesResults, error) {
+	var listRes ListReleasesResults
+	err := c.list(ctx, "releases", opts, &listRes)
+	return listRes, err
+}
+
+// ListUsers returns a page of Users based on the search
+// criteria outlined in the opts param.
+func (c *Client) ListUsers(ctx context.Context, opts ListUsersOpts) (ListUsersResults, error) {
+	var listRes ListUsersResults
+	err := c.list(ctx, "users", opts, &listRes)
+	return listRes, err
+}
+
+// ListReleasePlans returns the plans of the named release
+func (c *Client) ListReleasePlans(ctx context.Context, slug string) (ListReleasePlansResults, error) {
+	var listRes ListReleasePlansResults
+	baseURL, err := requestBaseURL(c.BaseURL, "releases")
+	if err != nil {
+		return listRes, &ListError{Err: err}
+	}
+	finalURL := fmt.Sprintf("%s/%s/plans", baseURL, slug)
+	if err := c.getJSON(ctx, finalURL, &listRes); err != nil {
+		return listRes, &ListError{Err: err}
+	}
+	return listRes, nil
+}
+
+// IterateModules returns an iterator over the Modules found with opts. The
+// Limit of opts is the size of each page. If max is greater than 0, at most
+// max Modules are returned.
+func (c *Client) IterateModules(ctx context.Context, opts ListModulesOpts, max int) *ModuleIterator {
+	return &ModuleIterator{pager: newPager(ctx, c, "modules", opts, max)}
+}
+
+// IterateReleases returns an iterator over the Releases found with opts.
+// The Limit of opts is the size of each page. If max is greater than 0, at
+// most max Releases are returned.
+func (c *Client) IterateReleases(ctx context.Context, opts ListReleasesOpts, max int) *ReleaseIterator {
+	return &ReleaseIterator{pager: newPager(ctx, c, "releases", opts, max)}
+}
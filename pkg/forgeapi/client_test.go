@@ -0,0 +1,241 @@
+package forgeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi/utils/mocks/responses"
+)
+
+// flakyForge fails the first failures requests to each path with status,
+// then serves the module fixture
+func flakyForge(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := atomic.AddInt32(&requests, 1); n <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"message": "failure %d", "errors": ["try again"]}`, n)
+			return
+		}
+		if r.Header.Get("Authorization") != "" && r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, responses.ModuleJSONBody200)
+	}))
+	return srv, &requests
+}
+
+func testClient(url string) *Client {
+	c := NewClient(url, fakeUAStr)
+	c.HTTPClient = http.DefaultClient
+	c.RetryWaitMin = time.Millisecond
+	c.RetryWaitMax = 10 * time.Millisecond
+	return c
+}
+
+func TestClientRetry(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv, requests := flakyForge(2, status, "")
+		mod, err := testClient(srv.URL).FetchModule(context.Background(), "puppetlabs-apache")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Expected a %d to be retried, got error: %v", status, err)
+		}
+		if mod.Name != "apache" || *requests != 3 {
+			t.Errorf("Expected the module after 3 requests, got %s after %d requests", mod.Name, *requests)
+		}
+	}
+}
+
+func TestClientRetryExhausted(t *testing.T) {
+	srv, requests := flakyForge(10, http.StatusServiceUnavailable, "")
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.MaxRetries = 2
+	_, err := c.FetchModule(context.Background(), "puppetlabs-apache")
+	var non200 *GetNon200Error
+	if !errors.As(err, &non200) {
+		t.Fatalf("Expected a GetNon200Error, got: %v", err)
+	}
+	if non200.StatusCode != http.StatusServiceUnavailable || non200.Body != `{"message": "failure 3", "errors": ["try again"]}` {
+		t.Errorf("Expected the status code and body of the last attempt, got %d and %s", non200.StatusCode, non200.Body)
+	}
+	if *requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", *requests)
+	}
+}
+
+func TestClientNoRetry(t *testing.T) {
+	srv, requests := flakyForge(1, http.StatusNotFound, "")
+	defer srv.Close()
+	_, err := testClient(srv.URL).FetchModule(context.Background(), "puppetlabs-apache")
+	if err == nil || *requests != 1 {
+		t.Errorf("Expected a 404 to fail without a retry, got %d requests and error: %v", *requests, err)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	srv, _ := flakyForge(1, http.StatusTooManyRequests, "1")
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.RetryWaitMax = 2 * time.Second
+	start := time.Now()
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil {
+		t.Fatalf("Expected the request to succeed after the Retry-After wait, got error: %v", err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("Expected to wait for the Retry-After of 1s, waited %s", waited)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	srv, requests := flakyForge(10, http.StatusTooManyRequests, "5")
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.RetryWaitMax = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.FetchModule(ctx, "puppetlabs-apache")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline to stop the retries, got: %v", err)
+	}
+	if time.Since(start) > time.Second || *requests != 1 {
+		t.Errorf("Expected to stop waiting when the context is done, took %s and %d requests", time.Since(start), *requests)
+	}
+}
+
+func TestClientDownloadHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, responses.ReleaseFileBody200)
+	}))
+	defer srv.Close()
+	rel := Release{
+		FileURI:    "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz",
+		FileSHA256: "e22fab9fa152b689b7b242d780a17339ff068d39cc4317367a10f2d14e28134b",
+	}
+	c := testClient(srv.URL)
+	c.MaxRetries = 0
+	c.HTTPClient = &http.Client{Timeout: 20 * time.Millisecond}
+	c.DownloadHTTPClient = http.DefaultClient
+	if _, err := c.FetchReleaseFile(context.Background(), rel); err != nil {
+		t.Errorf("Expected the release file to be downloaded with the DownloadHTTPClient, got error: %v", err)
+	}
+	c.DownloadHTTPClient = nil
+	if _, err := c.FetchReleaseFile(context.Background(), rel); err == nil {
+		t.Errorf("Expected the HTTPClient timeout without a DownloadHTTPClient")
+	}
+}
+
+func TestClientToken(t *testing.T) {
+	srv, _ := flakyForge(0, 0, "")
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.Token = "secret"
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil {
+		t.Errorf("Expected the token to be sent as a bearer token, got error: %v", err)
+	}
+	c.Token = "wrong"
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err == nil {
+		t.Errorf("Expected a wrong token to be rejected")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("120"); d != 2*time.Minute {
+		t.Errorf("Expected 2m, got %s", d)
+	}
+	if d := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Expected about an hour, got %s", d)
+	}
+	if d := retryAfter("soon"); d != 0 {
+		t.Errorf("Expected no wait for an invalid Retry-After, got %s", d)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{RetryWaitMin: 100 * time.Millisecond, RetryWaitMax: time.Second}
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		wait := c.backoff(attempt, &GetError{Err: errors.New("connection refused")})
+		if wait < max/2 || wait > max {
+			t.Errorf("Expected a wait between %s and %s for attempt %d, got %s", max/2, max, attempt, wait)
+		}
+	}
+	if wait := c.backoff(0, &GetNon200Error{StatusCode: 429, RetryAfter: time.Hour}); wait != time.Second {
+		t.Errorf("Expected Retry-After to be capped at RetryWaitMax, got %s", wait)
+	}
+}
+
+// authForge records the Authorization header of each request
+func authForge() (*httptest.Server, *[]string) {
+	var mutex sync.Mutex
+	var headers []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		headers = append(headers, r.Header.Get("Authorization"))
+		mutex.Unlock()
+		fmt.Fprint(w, responses.ModuleJSONBody200)
+	}))
+	return srv, &headers
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	srv, headers := authForge()
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.Username, c.Password = "user", "pass"
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil {
+		t.Fatalf("Failed to fetch module with error: %v", err)
+	}
+	c.Token = "secret"
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-apache"); err != nil {
+		t.Fatalf("Failed to fetch module with error: %v", err)
+	}
+	expected := []string{"Basic dXNlcjpwYXNz", "Bearer secret"}
+	for i, h := range *headers {
+		if h != expected[i] {
+			t.Errorf("Expected Authorization %s for request %d, got %s", expected[i], i+1, h)
+		}
+	}
+}
+
+func TestClientAuthOtherHost(t *testing.T) {
+	srv, headers := authForge()
+	defer srv.Close()
+	c := testClient("https://forge.example.com")
+	c.Token = "secret"
+	if _, err := c.Get(context.Background(), srv.URL+"/v3/files/puppetlabs-apache-4.0.0.tar.gz"); err != nil {
+		t.Fatalf("Failed to get file with error: %v", err)
+	}
+	if (*headers)[0] != "" {
+		t.Errorf("Expected no credentials to be sent to another host, got %s", (*headers)[0])
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	SetCredentials("https://forge.example.com/", Credentials{Token: "secret"})
+	SetCredentials("https://forge.puppet.com", Credentials{Username: "user", Password: "pass"})
+	if c := NewClient("https://Forge.example.com", fakeUAStr); c.Token != "secret" {
+		t.Errorf("Expected the credentials of https://forge.example.com, got token %s", c.Token)
+	}
+	if c := NewClient("default", fakeUAStr); c.Username != "user" || c.Password != "pass" {
+		t.Errorf("Expected the credentials of the Puppet Forge, got %s:%s", c.Username, c.Password)
+	}
+	if c := NewClient(fakeURLStr, fakeUAStr); c.Token != "" || c.Username != "" {
+		t.Errorf("Expected no credentials for %s", fakeURLStr)
+	}
+	SetCredentials("https://forge.example.com", Credentials{})
+	SetCredentials("https://forge.puppet.com", Credentials{})
+}
@@ -15,3 +15,10 @@ const V3ModulesEndpoint = "/v3/modules"
 
 // V3ReleasesEndpoint is the v3 Forge API endpoint for releases
 const V3ReleasesEndpoint = "/v3/releases"
+
+// MaxListLimit is the maximum number of results the Forge API returns per
+// page of a list endpoint
+const MaxListLimit = 100
+
+// V3FilesEndpoint is the v3 Forge API endpoint for release files
+const V3FilesEndpoint = "/v3/files"
@@ -0,0 +1,42 @@
+package forgeapi
+
+import (
+	"strings"
+	"sync"
+)
+
+// Credentials authenticate requests to a private Forge, either with a
+// bearer token or with basic auth
+type Credentials struct {
+	Token    string
+	Username string
+	Password string
+}
+
+var (
+	credentials      = map[string]Credentials{}
+	credentialsMutex sync.RWMutex
+)
+
+// credentialsKey normalizes a Forge URL, so the aliases of the Puppet
+// Forge and URLs with a trailing slash share credentials
+func credentialsKey(url string) string {
+	base, _ := forgeURL(url)
+	return strings.TrimSuffix(strings.ToLower(base), "/")
+}
+
+// SetCredentials sets the credentials used by Clients created with
+// NewClient for the Forge at url
+func SetCredentials(url string, creds Credentials) {
+	credentialsMutex.Lock()
+	defer credentialsMutex.Unlock()
+	credentials[credentialsKey(url)] = creds
+}
+
+// CredentialsFor returns the credentials set for the Forge at url, or
+// empty Credentials if none are set
+func CredentialsFor(url string) Credentials {
+	credentialsMutex.RLock()
+	defer credentialsMutex.RUnlock()
+	return credentials[credentialsKey(url)]
+}
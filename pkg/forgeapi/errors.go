@@ -2,6 +2,7 @@ package forgeapi
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -23,15 +24,41 @@ func (r *GetError) Error() string {
 	return fmt.Sprintf("%s HTTP GET request failed. URL: %s, Headers: %#v, Error: %s", prefix(), r.URL, r.Headers, r.Err.Error())
 }
 
+func (r *GetError) Unwrap() error {
+	return r.Err
+}
+
 // GetNon200Error provides an error wrapper for when
-// HTTP GET requests return a non-200 status code
+// HTTP GET requests return a non-200 status code. Body holds
+// the start of the response body, which usually explains the
+// error, and RetryAfter holds the wait requested by a
+// Retry-After header.
 type GetNon200Error struct {
 	URL        string
 	StatusCode int
+	Body       string
+	RetryAfter time.Duration
 }
 
 func (r *GetNon200Error) Error() string {
-	return fmt.Sprintf("%s HTTP GET request returned non-200 code. URL: %s, Code: %d", prefix(), r.URL, r.StatusCode)
+	msg := fmt.Sprintf("%s HTTP GET request returned non-200 code. URL: %s, Code: %d", prefix(), r.URL, r.StatusCode)
+	if body := strings.TrimSpace(r.Body); body != "" {
+		if len(body) > 512 {
+			body = body[:512] + "..."
+		}
+		msg = fmt.Sprintf("%s, Body: %s", msg, body)
+	}
+	return msg
+}
+
+// CacheMissError is returned by an offline Cache for a request
+// it has no cached response for
+type CacheMissError struct {
+	URL string
+}
+
+func (r *CacheMissError) Error() string {
+	return fmt.Sprintf("%s Offline and no cached response for URL: %s", prefix(), r.URL)
 }
 
 // JSONDecodeError provides wrapper for errors encountered
@@ -44,6 +71,10 @@ func (r *JSONDecodeError) Error() string {
 	return fmt.Sprintf("%s Failed to decode HTTP response body: %#v", prefix(), r.Err.Error())
 }
 
+func (r *JSONDecodeError) Unwrap() error {
+	return r.Err
+}
+
 // FetchError provides a wrapper for errors encountered
 // during Fetch requests
 type FetchError struct {
@@ -54,6 +85,25 @@ func (r *FetchError) Error() string {
 	return fmt.Sprintf("%s Fetch failed: %#v", prefix(), r.Err.Error())
 }
 
+func (r *FetchError) Unwrap() error {
+	return r.Err
+}
+
+// ModuleFetchError records which module failed to be fetched when
+// fetching many modules at once
+type ModuleFetchError struct {
+	Slug string
+	Err  error
+}
+
+func (r *ModuleFetchError) Error() string {
+	return fmt.Sprintf("%s Fetch of module %s failed: %#v", prefix(), r.Slug, r.Err.Error())
+}
+
+func (r *ModuleFetchError) Unwrap() error {
+	return r.Err
+}
+
 // ListError provides a wrapper for errors encountered
 // during List requests
 type ListError struct {
@@ -63,3 +113,20 @@ type ListError struct {
 func (r *ListError) Error() string {
 	return fmt.Sprintf("%s List failed: %#v", prefix(), r.Err.Error())
 }
+
+func (r *ListError) Unwrap() error {
+	return r.Err
+}
+
+// ChecksumError provides an error wrapper for when the checksum
+// of a downloaded release tarball doesn't match the release
+type ChecksumError struct {
+	File      string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (r *ChecksumError) Error() string {
+	return fmt.Sprintf("%s %s checksum mismatch. File: %s, Expected: %s, Actual: %s", prefix(), r.Algorithm, r.File, r.Expected, r.Actual)
+}
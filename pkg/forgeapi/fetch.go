@@ -0,0 +1,66 @@
+package forgeapi
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// ModuleResult is the result of fetching one module with FetchModules
+type ModuleResult struct {
+	Slug   string
+	Module Module
+	// Err is a *ModuleFetchError if the module could not be fetched
+	Err error
+}
+
+// FetchModules fetches the named modules with a pool of at most
+// c.Concurrency concurrent requests. Slugs may be in owner-name or
+// owner/name form, and each module is only fetched once however many
+// times it is named. The results are in the order the modules are first
+// named, regardless of the order the requests finish in.
+func (c *Client) FetchModules(ctx context.Context, slugs []string) []ModuleResult {
+	var results []ModuleResult
+	seen := make(map[string]bool)
+	for _, s := range slugs {
+		slug := ast.NormalizeName(strings.TrimSpace(s))
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		results = append(results, ModuleResult{Slug: slug})
+	}
+	workers := c.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(results) {
+		workers = len(results)
+	}
+	jobs := make(chan int)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			// Each worker only writes to the results of the indexes it
+			// receives, so the results need no locking
+			for i := range jobs {
+				mod, err := c.FetchModule(ctx, results[i].Slug)
+				if err != nil {
+					results[i].Err = &ModuleFetchError{Slug: results[i].Slug, Err: err}
+					continue
+				}
+				results[i].Module = mod
+			}
+		}()
+	}
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	waitGroup.Wait()
+	return results
+}
@@ -0,0 +1,100 @@
+package forgeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrentForge serves modules named after their slug, taking longer for
+// slugs earlier in the alphabet, and counts the requests for each slug and
+// the most requests served at once
+func concurrentForge(missing string) (*httptest.Server, map[string]int, *int32) {
+	var mutex sync.Mutex
+	requests := make(map[string]int)
+	var inFlight, maxInFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		slug := path.Base(r.URL.Path)
+		mutex.Lock()
+		requests[slug]++
+		mutex.Unlock()
+		time.Sleep(time.Duration('z'-slug[len(slug)-1]) * time.Millisecond)
+		if slug == missing {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"slug": "%s"}`, slug)
+	}))
+	return srv, requests, &maxInFlight
+}
+
+func TestFetchModules(t *testing.T) {
+	srv, requests, maxInFlight := concurrentForge("test-c")
+	defer srv.Close()
+	c := testClient(srv.URL)
+	c.Concurrency = 2
+	slugs := []string{"test-a", "test-b", "test/a", "test-c", "test-d", "test-e", "test-b", "test-f"}
+	results := c.FetchModules(context.Background(), slugs)
+	expected := []string{"test-a", "test-b", "test-c", "test-d", "test-e", "test-f"}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, r := range results {
+		if r.Slug != expected[i] {
+			t.Errorf("Expected result %d to be %s, got %s", i, expected[i], r.Slug)
+		}
+		if requests[r.Slug] != 1 {
+			t.Errorf("Expected %s to be requested once, got %d requests", r.Slug, requests[r.Slug])
+		}
+		if r.Slug == "test-c" {
+			var fetchErr *ModuleFetchError
+			if !errors.As(r.Err, &fetchErr) || fetchErr.Slug != "test-c" {
+				t.Errorf("Expected a ModuleFetchError for test-c, got: %v", r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.Module.Slug != r.Slug {
+			t.Errorf("Expected module %s, got %s and error: %v", r.Slug, r.Module.Slug, r.Err)
+		}
+	}
+	if *maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", *maxInFlight)
+	}
+}
+
+func TestFetchModuleDependencies(t *testing.T) {
+	srv, _, _ := concurrentForge("test-b")
+	defer srv.Close()
+	var mod Module
+	for _, name := range []string{"test/c", "test/b", "test/a", "test/c"} {
+		mod.CurrentRelease.Metadata.Dependencies = append(mod.CurrentRelease.Metadata.Dependencies, ModuleMetadataDependency{Name: name})
+	}
+	deps, errs := testClient(srv.URL).FetchModuleDependencies(context.Background(), mod)
+	if len(deps) != 2 || deps[0].Slug != "test-c" || deps[1].Slug != "test-a" {
+		t.Errorf("Expected dependencies test-c and test-a in order, got %v", deps)
+	}
+	if len(errs) != 1 || errs[0].(*ModuleFetchError).Slug != "test-b" {
+		t.Errorf("Expected one error for test-b, got %v", errs)
+	}
+}
+
+func TestFetchModulesEmpty(t *testing.T) {
+	if results := testClient(fakeURLStr).FetchModules(context.Background(), nil); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+}
@@ -2,6 +2,10 @@
 package forgeapi
 
 import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,8 +17,15 @@ import (
 )
 
 var (
-	// Client implements the HTTPClient interface for making HTTP rquests
-	Client HTTPClient
+	// DefaultHTTPClient implements the HTTPClient interface for making HTTP
+	// requests. It is used by Clients that don't set their own HTTPClient,
+	// and by the package-level functions.
+	DefaultHTTPClient HTTPClient
+	// DefaultDownloadHTTPClient implements the HTTPClient interface for
+	// downloading release tarballs, which can take much longer than the
+	// timeout of DefaultHTTPClient allows. It is used by Clients that don't
+	// set their own DownloadHTTPClient.
+	DefaultDownloadHTTPClient HTTPClient
 
 	transport = &http.Transport{
 		MaxIdleConns:       3,
@@ -30,10 +41,14 @@ type HTTPClient interface {
 }
 
 func init() {
-	Client = &http.Client{
+	DefaultHTTPClient = &http.Client{
 		Transport: transport,
 		Timeout:   5 * time.Second,
 	}
+	DefaultDownloadHTTPClient = &http.Client{
+		Transport: transport,
+		Timeout:   5 * time.Minute,
+	}
 }
 
 func userAgent(agent string) (string, string) {
@@ -41,10 +56,10 @@ func userAgent(agent string) (string, string) {
 }
 
 func forgeURL(url string) (string, bool) {
-	switch strings.ToLower(url) {
+	switch strings.TrimSuffix(strings.ToLower(url), "/") {
 	case "ipv4", ForgeURLIPv4Only:
 		return ForgeURLIPv4Only, false
-	case "default", "ipv6", ForgeURL:
+	case "default", "ipv6", ForgeURL, "https://forge.puppet.com", "https://forge.puppetlabs.com", "http://forge.puppetlabs.com":
 		return ForgeURL, false
 	default:
 		return url, true
@@ -54,15 +69,18 @@ func forgeURL(url string) (string, bool) {
 func requestBaseURL(url, endpoint string) (string, error) {
 	baseURL, custom := forgeURL(url)
 	if custom {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	if endpoint == "" {
 		return baseURL, nil
 	}
 	switch strings.ToLower(endpoint) {
 	case "users":
-		return fmt.Sprintf("%s%s", url, V3UsersEndpoint), nil
+		return fmt.Sprintf("%s%s", baseURL, V3UsersEndpoint), nil
 	case "modules":
-		return fmt.Sprintf("%s%s", url, V3ModulesEndpoint), nil
+		return fmt.Sprintf("%s%s", baseURL, V3ModulesEndpoint), nil
 	case "releases":
-		return fmt.Sprintf("%s%s", url, V3ReleasesEndpoint), nil
+		return fmt.Sprintf("%s%s", baseURL, V3ReleasesEndpoint), nil
 	default:
 		return "", fmt.Errorf("Specified endpoint %s is not a valid Forge API endpoint option", endpoint)
 	}
@@ -77,85 +95,122 @@ func decodeModule(resp *http.Response) (Module, error) {
 	return mod, nil
 }
 
-// GetRequest makes an HTTP Get request and returns a response
-// on an HTTP response code of 200, or an error otherwise.
-func GetRequest(url, agent string, client HTTPClient) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, &GetError{Err: err, URL: url, Headers: map[string]string{}}
-	}
-	header, value := userAgent(agent)
-	req.Header.Add(header, value)
-	resp, err := client.Do(req)
+func decodeRelease(resp *http.Response) (Release, error) {
+	var rel Release
+	err := json.NewDecoder(resp.Body).Decode(&rel)
 	if err != nil {
-		hdrs := map[string]string{header: value}
-		return nil, &GetError{Err: err, URL: url, Headers: hdrs}
-	}
-	if resp.StatusCode != 200 {
-		return nil, &GetNon200Error{URL: url, StatusCode: resp.StatusCode}
+		return rel, &JSONDecodeError{Err: err}
 	}
-	return resp, nil
+	return rel, nil
+}
+
+// GetRequest makes a single HTTP Get request and returns a response
+// on an HTTP response code of 200, or an error otherwise.
+func GetRequest(url, agent string, client HTTPClient) (*http.Response, error) {
+	c := &Client{UserAgent: agent, HTTPClient: client}
+	return c.getOnce(context.Background(), url)
 }
 
 // FetchModule performs a Forge API get request for the named module and sends the result to the specified channel
 func FetchModule(nameslug, url, agent string, wg *sync.WaitGroup) (Module, error) {
 	defer wg.Done()
-	baseURL, err := requestBaseURL(url, "modules")
-	if err != nil {
-		return Module{}, &FetchError{Err: err}
-	}
-	finalURL := fmt.Sprintf("%s/%s", baseURL, nameslug)
-	resp, err := GetRequest(finalURL, agent, Client)
-	if err != nil {
-		return Module{}, &FetchError{Err: err}
-	}
-	mod, err := decodeModule(resp)
-	if err != nil {
-		return Module{}, &FetchError{Err: err}
-	}
-	return mod, nil
+	return NewClient(url, agent).FetchModule(context.Background(), nameslug)
+}
+
+// FetchRelease performs a Forge API get request for the named release. The
+// slug is the module's owner, name, and version, Ex. puppetlabs-apache-4.0.0
+func FetchRelease(slug, url, agent string) (Release, error) {
+	return NewClient(url, agent).FetchRelease(context.Background(), slug)
+}
+
+// FetchReleaseFile downloads the tarball of the release from its FileURI,
+// which is relative to the Forge URL, and verifies the tarball's checksums.
+func FetchReleaseFile(rel Release, url, agent string) ([]byte, error) {
+	return NewClient(url, agent).FetchReleaseFile(context.Background(), rel)
+}
+
+// VerifyReleaseFile compares the checksums of a downloaded release tarball
+// with the FileSHA256 and FileMD5 of the release. Checksums the release
+// doesn't have are not verified.
+func VerifyReleaseFile(rel Release, data []byte) error {
+	if rel.FileSHA256 != "" {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, rel.FileSHA256) {
+			return &ChecksumError{File: rel.FileURI, Algorithm: "sha256", Expected: rel.FileSHA256, Actual: actual}
+		}
+	}
+	if rel.FileMD5 != "" {
+		sum := md5.Sum(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, rel.FileMD5) {
+			return &ChecksumError{File: rel.FileURI, Algorithm: "md5", Expected: rel.FileMD5, Actual: actual}
+		}
+	}
+	return nil
 }
 
 // FetchModuleDependencies returns a slice of Modules that are marked as dependencies of the given module
 func FetchModuleDependencies(mod Module, url, agent string) ([]Module, []error) {
-	var waitGroup sync.WaitGroup
-	var deps []Module
-	var errs []error
-	waitGroup.Add(len(mod.CurrentRelease.Metadata.Dependencies))
-	for _, d := range mod.CurrentRelease.Metadata.Dependencies {
-		go func(name, url, agent string, wg *sync.WaitGroup) {
-			_name := strings.Replace(name, "/", "-", 1)
-			mod, err := FetchModule(_name, url, agent, &waitGroup)
-			if err != nil {
-				errs = append(errs, err)
-			}
-			deps = append(deps, mod)
-		}(d.Name, url, agent, &waitGroup)
-	}
-	waitGroup.Wait()
-	return deps, errs
+	return NewClient(url, agent).FetchModuleDependencies(context.Background(), mod)
 }
 
 // ListModules returns an array of Modules based on the search
 // criterial outlined in the opts param.
 func ListModules(url, agent string, opts ListModulesOpts) (ListResults, error) {
-	var listRes ListResults
-	baseURL, err := requestBaseURL(url, "modules")
+	return NewClient(url, agent).ListModules(context.Background(), opts)
+}
+
+// listURL returns the URL of a list endpoint with the search parameters
+// in opts as the query string
+func listURL(url, endpoint string, opts interface{}) (string, error) {
+	baseURL, err := requestBaseURL(url, endpoint)
 	if err != nil {
-		return listRes, fmt.Errorf("forgeapi: List failed: %w", err)
+		return "", err
 	}
 	v, err := qstring.Values(opts)
 	if err != nil {
-		return listRes, fmt.Errorf("forgeapi: List failed: %w", err)
-	}
-	finalURL := fmt.Sprintf("%s?%s", baseURL, v.Encode())
-	resp, err := GetRequest(finalURL, agent, Client)
-	if err != nil {
-		return listRes, fmt.Errorf("forgeapi: List failed: %w", err)
-	}
-	err = json.NewDecoder(resp.Body).Decode(&listRes)
-	if err != nil {
-		return listRes, fmt.Errorf("forgeapi: List failed: %w", err)
+		return "", err
 	}
-	return listRes, nil
+	return fmt.Sprintf("%s?%s", baseURL, v.Encode()), nil
+}
+
+// ListReleases returns an array of Releases based on the search
+// criteria outlined in the opts param.
+func ListReleases(url, agent string, opts ListReleasesOpts) (ListReleasesResults, error) {
+	return NewClient(url, agent).ListReleases(context.Background(), opts)
+}
+
+// FetchReleasePlan performs a Forge API get request for the named plan of
+// a release, Ex. the plan facts::info of the release puppetlabs-facts-1.0.0
+func FetchReleasePlan(slug, plan, url, agent string) (ReleasePlan, error) {
+	return NewClient(url, agent).FetchReleasePlan(context.Background(), slug, plan)
+}
+
+// ListReleasePlans returns the plans of the named release
+func ListReleasePlans(slug, url, agent string) (ListReleasePlansResults, error) {
+	return NewClient(url, agent).ListReleasePlans(context.Background(), slug)
+}
+
+// FetchUser performs a Forge API get request for the named user
+func FetchUser(slug, url, agent string) (User, error) {
+	return NewClient(url, agent).FetchUser(context.Background(), slug)
+}
+
+// ListUsers returns an array of Users based on the search
+// criteria outlined in the opts param.
+func ListUsers(url, agent string, opts ListUsersOpts) (ListUsersResults, error) {
+	return NewClient(url, agent).ListUsers(context.Background(), opts)
+}
+
+// IterateModules returns an iterator over the Modules found with opts. The
+// Limit of opts is the size of each page. If max is greater than 0, at most
+// max Modules are returned.
+func IterateModules(url, agent string, opts ListModulesOpts, max int) *ModuleIterator {
+	return NewClient(url, agent).IterateModules(context.Background(), opts, max)
+}
+
+// IterateReleases returns an iterator over the Releases found with opts.
+// The Limit of opts is the size of each page. If max is greater than 0, at
+// most max Releases are returned.
+func IterateReleases(url, agent string, opts ListReleasesOpts, max int) *ReleaseIterator {
+	return NewClient(url, agent).IterateReleases(context.Background(), opts, max)
 }
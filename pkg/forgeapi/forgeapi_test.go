@@ -20,7 +20,8 @@ var (
 )
 
 func init() {
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
+	DefaultDownloadHTTPClient = DefaultHTTPClient
 	modJSONBody200 = ioutil.NopCloser(bytes.NewReader([]byte(responses.ModuleJSONBody200)))
 	modResponse200 = &http.Response{
 		StatusCode: 200,
@@ -32,7 +33,7 @@ func TestGetRequest(t *testing.T) {
 	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
 		return modResponse200, nil
 	}
-	response, err := GetRequest(fakeURLStr, fakeUAStr, Client)
+	response, err := GetRequest(fakeURLStr, fakeUAStr, DefaultHTTPClient)
 	if err != nil {
 		t.Errorf("Failed to get response with error: %w", err)
 	}
@@ -88,6 +89,10 @@ func TestForgeURL(t *testing.T) {
 	if custom6 {
 		t.Errorf("Failed to return false for custom bool IPv6 Forge URL")
 	}
+	webURL, customWeb := forgeURL("https://forge.puppet.com/")
+	if webURL != ForgeURL || customWeb {
+		t.Errorf("Failed to return the API URL for the Puppet Forge web URL")
+	}
 	customURL, customCustom := forgeURL(fakeURLStr)
 	if customURL != fakeURLStr {
 		t.Errorf("Failed to return proper URL for Custom Forge URL")
@@ -132,8 +137,202 @@ func TestRequestBaseURL(t *testing.T) {
 	if u != fakeURLStr {
 		t.Errorf("Expected %s, got %s", fakeURLStr, u)
 	}
+	u, err = requestBaseURL("default", "")
+	if err != nil || u != ForgeURL {
+		t.Errorf("Expected %s for the default base URL, got %s with error: %v", ForgeURL, u, err)
+	}
+	u, err = requestBaseURL(fakeURLStr+"/", "releases")
+	if err != nil {
+		t.Errorf("Custom base URL with endpoint failed with error: %v", err)
+	}
+	if u != fakeURLStr+V3ReleasesEndpoint {
+		t.Errorf("Expected %s, got %s", fakeURLStr+V3ReleasesEndpoint, u)
+	}
 	_, err = requestBaseURL(ForgeURL, "fake")
 	if err == nil {
 		t.Errorf("Invalid URL input did not return an error")
 	}
 }
+
+func TestFetchRelease(t *testing.T) {
+	var requested string
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		body := ioutil.NopCloser(bytes.NewReader([]byte(responses.ReleaseJSONBody200)))
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	}
+	rel, err := FetchRelease("puppetlabs-stdlib-6.0.0", fakeURLStr, fakeUAStr)
+	if err != nil {
+		t.Fatalf("Failed to fetch release with error: %v", err)
+	}
+	if expected := fakeURLStr + V3ReleasesEndpoint + "/puppetlabs-stdlib-6.0.0"; requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, requested)
+	}
+	if rel.Version != "6.0.0" || rel.FileURI != "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz" {
+		t.Errorf("Failed to parse Release, got version %s and file URI %s", rel.Version, rel.FileURI)
+	}
+}
+
+func TestFetchReleaseFile(t *testing.T) {
+	var requested string
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		body := ioutil.NopCloser(bytes.NewReader([]byte(responses.ReleaseFileBody200)))
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	}
+	rel := Release{
+		FileURI:    "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz",
+		FileMD5:    "5e3bbc4bec053b32ae28113d9e2d0397",
+		FileSHA256: "e22fab9fa152b689b7b242d780a17339ff068d39cc4317367a10f2d14e28134b",
+	}
+	data, err := FetchReleaseFile(rel, fakeURLStr, fakeUAStr)
+	if err != nil {
+		t.Fatalf("Failed to fetch release file with error: %v", err)
+	}
+	if expected := fakeURLStr + rel.FileURI; requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, requested)
+	}
+	if string(data) != responses.ReleaseFileBody200 {
+		t.Errorf("Unexpected release file contents: %s", data)
+	}
+}
+
+func TestVerifyReleaseFile(t *testing.T) {
+	rel := Release{
+		FileMD5:    "5e3bbc4bec053b32ae28113d9e2d0397",
+		FileSHA256: "e22fab9fa152b689b7b242d780a17339ff068d39cc4317367a10f2d14e28134b",
+	}
+	if err := VerifyReleaseFile(rel, []byte(responses.ReleaseFileBody200)); err != nil {
+		t.Errorf("Verifying a valid release file failed with error: %v", err)
+	}
+	err := VerifyReleaseFile(rel, []byte("tampered"))
+	if cerr, ok := err.(*ChecksumError); !ok || cerr.Algorithm != "sha256" {
+		t.Errorf("Expected a sha256 ChecksumError, got: %v", err)
+	}
+	rel.FileSHA256 = ""
+	err = VerifyReleaseFile(rel, []byte("tampered"))
+	if cerr, ok := err.(*ChecksumError); !ok || cerr.Algorithm != "md5" {
+		t.Errorf("Expected an md5 ChecksumError, got: %v", err)
+	}
+}
+
+// mockJSON makes the mock client respond to every request with body and
+// records the URL of the last request
+func mockJSON(body string) *string {
+	var requested string
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+	return &requested
+}
+
+func TestListReleases(t *testing.T) {
+	requested := mockJSON(responses.ReleasesListJSONBody200)
+	opts := ListReleasesOpts{Limit: 2, Module: "puppetlabs-stdlib", WithPDK: true, OperatingSystem: "RedHat", ShowDeleted: true, SortBy: "release_date"}
+	res, err := ListReleases(fakeURLStr, fakeUAStr, opts)
+	if err != nil {
+		t.Fatalf("Failed to list releases with error: %v", err)
+	}
+	expected := fakeURLStr + V3ReleasesEndpoint + "?limit=2&module=puppetlabs-stdlib&operatingsystem=RedHat&show_deleted=true&sort_by=release_date&with_pdk=true"
+	if *requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, *requested)
+	}
+	if len(res.Results) != 2 || res.Results[0].Version != "6.1.0" || !res.Results[1].PDK {
+		t.Errorf("Failed to parse releases, got: %+v", res.Results)
+	}
+	if res.Pagination.Total != 3 || res.Pagination.Next == "" {
+		t.Errorf("Failed to parse pagination, got: %+v", res.Pagination)
+	}
+}
+
+func TestFetchUser(t *testing.T) {
+	requested := mockJSON(responses.UserJSONBody200)
+	user, err := FetchUser("puppetlabs", fakeURLStr, fakeUAStr)
+	if err != nil {
+		t.Fatalf("Failed to fetch user with error: %v", err)
+	}
+	if expected := fakeURLStr + V3UsersEndpoint + "/puppetlabs"; *requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, *requested)
+	}
+	if user.DisplayName != "Puppet" || user.ModuleCount != 213 || user.ReleaseCount != 4926 {
+		t.Errorf("Failed to parse User, got: %+v", user)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	requested := mockJSON(responses.UsersListJSONBody200)
+	res, err := ListUsers(fakeURLStr, fakeUAStr, ListUsersOpts{Limit: 1, SortBy: "releases"})
+	if err != nil {
+		t.Fatalf("Failed to list users with error: %v", err)
+	}
+	if expected := fakeURLStr + V3UsersEndpoint + "?limit=1&sort_by=releases"; *requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, *requested)
+	}
+	if len(res.Results) != 1 || res.Results[0].Username != "puppetlabs" || res.Pagination.Next != "" {
+		t.Errorf("Failed to parse users, got: %+v", res)
+	}
+}
+
+func TestReleasePlans(t *testing.T) {
+	requested := mockJSON(responses.ReleasePlansJSONBody200)
+	res, err := ListReleasePlans("puppetlabs-facts-1.0.0", fakeURLStr, fakeUAStr)
+	if err != nil {
+		t.Fatalf("Failed to list release plans with error: %v", err)
+	}
+	if expected := fakeURLStr + V3ReleasesEndpoint + "/puppetlabs-facts-1.0.0/plans"; *requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, *requested)
+	}
+	if len(res.Results) != 1 || res.Results[0].PlanSchema.Parameters["targets"].Type != "TargetSpec" {
+		t.Errorf("Failed to parse release plans, got: %+v", res)
+	}
+	requested = mockJSON(responses.ReleasePlanJSONBody200)
+	plan, err := FetchReleasePlan("puppetlabs-facts-1.0.0", "facts::info", fakeURLStr, fakeUAStr)
+	if err != nil {
+		t.Fatalf("Failed to fetch release plan with error: %v", err)
+	}
+	if expected := fakeURLStr + V3ReleasesEndpoint + "/puppetlabs-facts-1.0.0/plans/facts::info"; *requested != expected {
+		t.Errorf("Expected request to %s, got %s", expected, *requested)
+	}
+	if plan.Name != "facts::info" || plan.PlanSchema.Description == "" {
+		t.Errorf("Failed to parse ReleasePlan, got: %+v", plan)
+	}
+}
+
+func TestListNon200(t *testing.T) {
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	if _, err := FetchUser("nobody", fakeURLStr, fakeUAStr); err == nil {
+		t.Errorf("Expected an error for a user that doesn't exist")
+	}
+	if _, err := ListReleases(fakeURLStr, fakeUAStr, ListReleasesOpts{}); err == nil {
+		t.Errorf("Expected an error for a failed list request")
+	}
+}
+
+func TestDeprecationMessage(t *testing.T) {
+	cases := []struct {
+		mod      Module
+		expected string
+	}{
+		{
+			Module{Slug: "puppetlabs-firewall", DeprecatedFor: "Moved to a new namespace.", SupersededBy: ModuleMinimal{Slug: "puppet/firewall"}},
+			"Module puppetlabs-firewall is deprecated: Moved to a new namespace. Use puppet-firewall instead.",
+		},
+		{
+			Module{Slug: "puppetlabs-translate", DeprecatedFor: "No longer maintained"},
+			"Module puppetlabs-translate is deprecated: No longer maintained.",
+		},
+		{
+			Module{Slug: "puppetlabs-ntp", SupersededBy: ModuleMinimal{Slug: "puppetlabs-chrony"}},
+			"Module puppetlabs-ntp is deprecated. Use puppetlabs-chrony instead.",
+		},
+		{Module{Slug: "puppetlabs-legacy"}, "Module puppetlabs-legacy is deprecated."},
+	}
+	for _, c := range cases {
+		if msg := c.mod.DeprecationMessage(); msg != c.expected {
+			t.Errorf("Expected message %q, got %q", c.expected, msg)
+		}
+	}
+}
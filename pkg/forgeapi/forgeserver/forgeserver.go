@@ -0,0 +1,254 @@
+// Package forgeserver serves a subset of the Forge v3 API from a directory
+// of module release tarballs, for air-gapped sites and for testing
+package forgeserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+// DefaultLimit is the number of results per page of a list endpoint when
+// the request doesn't set a limit
+const DefaultLimit = 20
+
+// timeFormat is the format of the Forge's timestamps
+const timeFormat = "2006-01-02 15:04:05 -0700"
+
+// Server serves the modules, releases, and files endpoints of the Forge
+// v3 API from the module release tarballs (*.tar.gz) in a directory. The
+// Module and Release objects are generated from the metadata.json of each
+// tarball. Endpoints that aren't served, such as users, return a 404.
+type Server struct {
+	// Dir is the directory of release tarballs
+	Dir string
+	// Logger logs tarballs that are skipped when loading, if set
+	Logger forgeapi.Logger
+
+	mutex sync.RWMutex
+	// modules and files are keyed by lowercase slug and file name, and
+	// slugs holds the keys of modules in order
+	modules map[string]*module
+	slugs   []string
+	files   map[string]*release
+}
+
+type module struct {
+	slug     string
+	owner    string
+	name     string
+	releases []*release
+}
+
+type release struct {
+	forgeapi.Release
+	semver  semver.SemVer
+	path    string
+	modTime time.Time
+}
+
+// New returns a Server for the tarballs in dir, which are loaded
+// immediately
+func New(dir string) (*Server, error) {
+	s := &Server{Dir: dir}
+	return s, s.Load()
+}
+
+// Load scans Dir for release tarballs, replacing the releases being served.
+// Tarballs without a valid metadata.json are skipped, as are tarballs of a
+// release that was already loaded. Load is safe to call while serving.
+func (s *Server) Load() error {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.tar.gz"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(s.Dir); err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	modules := make(map[string]*module)
+	files := make(map[string]*release)
+	for _, p := range paths {
+		rel, err := readRelease(p)
+		if err != nil {
+			s.logf("forgeserver: skipping %s: %v", p, err)
+			continue
+		}
+		file := strings.ToLower(path.Base(rel.FileURI))
+		if dup, ok := files[file]; ok {
+			s.logf("forgeserver: skipping %s: release %s was already loaded from %s", p, rel.Slug, dup.path)
+			continue
+		}
+		files[file] = rel
+		key := strings.ToLower(rel.Module.Slug)
+		m, ok := modules[key]
+		if !ok {
+			m = &module{slug: rel.Module.Slug, owner: rel.Module.Owner.Username, name: rel.Module.Name}
+			modules[key] = m
+		}
+		m.releases = append(m.releases, rel)
+	}
+	var slugs []string
+	for slug, m := range modules {
+		slugs = append(slugs, slug)
+		sort.Slice(m.releases, func(i, j int) bool {
+			return m.releases[i].semver.Compare(m.releases[j].semver) > 0
+		})
+	}
+	sort.Strings(slugs)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.modules, s.slugs, s.files = modules, slugs, files
+	return nil
+}
+
+// Count returns the number of modules and releases being served
+func (s *Server) Count() (int, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.modules), len(s.files)
+}
+
+func (s *Server) logf(format string, v ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, v...)
+	}
+}
+
+// readRelease reads the metadata.json of a release tarball, and returns
+// the Release it describes
+func readRelease(p string) (*release, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tarballMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+	var meta forgeapi.ModuleMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("invalid metadata.json: %w", err)
+	}
+	parts := strings.SplitN(ast.NormalizeName(meta.Name), "-", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid module name %q in metadata.json", meta.Name)
+	}
+	v, err := semver.Make(meta.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q in metadata.json: %w", meta.Version, err)
+	}
+	owner, name := parts[0], parts[1]
+	modSlug := fmt.Sprintf("%s-%s", owner, name)
+	slug := fmt.Sprintf("%s-%s", modSlug, meta.Version)
+	md5sum := md5.Sum(data)
+	sha256sum := sha256.Sum256(data)
+	created := info.ModTime().Format(timeFormat)
+	return &release{
+		Release: forgeapi.Release{
+			URI:  fmt.Sprintf("%s/%s", forgeapi.V3ReleasesEndpoint, slug),
+			Slug: slug,
+			Module: forgeapi.ModuleAbbreviated{
+				URI:  fmt.Sprintf("%s/%s", forgeapi.V3ModulesEndpoint, modSlug),
+				Slug: modSlug,
+				Name: name,
+				Owner: forgeapi.UserAbbreviated{
+					URI:      fmt.Sprintf("%s/%s", forgeapi.V3UsersEndpoint, owner),
+					Slug:     owner,
+					Username: owner,
+				},
+			},
+			Version:    meta.Version,
+			Metadata:   meta,
+			Tags:       meta.Tags,
+			License:    meta.License,
+			FileURI:    fmt.Sprintf("%s/%s.tar.gz", forgeapi.V3FilesEndpoint, slug),
+			FileSize:   len(data),
+			FileMD5:    hex.EncodeToString(md5sum[:]),
+			FileSHA256: hex.EncodeToString(sha256sum[:]),
+			CreatedAt:  created,
+			UpdatedAt:  created,
+		},
+		semver:  v,
+		path:    p,
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// tarballMetadata returns the metadata.json in the top-level directory of
+// a gzipped release tarball
+func tarballMetadata(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped tarball: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no metadata.json in tarball")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tarball: %w", err)
+		}
+		parts := strings.Split(strings.Trim(path.Clean(hdr.Name), "/"), "/")
+		if len(parts) == 2 && parts[1] == "metadata.json" && hdr.Typeflag != tar.TypeDir {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// moduleObject returns the Forge Module object of a module
+func (m *module) moduleObject() forgeapi.Module {
+	latest := m.releases[0]
+	mod := forgeapi.Module{
+		URI:            latest.Module.URI,
+		Slug:           m.slug,
+		Name:           m.name,
+		Owner:          latest.Module.Owner,
+		CurrentRelease: latest.Release,
+		HomepageURL:    latest.Metadata.ProjectPage,
+		IssuesURL:      latest.Metadata.IssuesURL,
+		UpdatedAt:      latest.CreatedAt,
+		CreatedAt:      latest.CreatedAt,
+	}
+	first := latest.modTime
+	for _, r := range m.releases {
+		if r.modTime.Before(first) {
+			first = r.modTime
+			mod.CreatedAt = r.CreatedAt
+		}
+		mod.Releases = append(mod.Releases, forgeapi.ReleaseAbbreviated{
+			URI:       r.URI,
+			Slug:      r.Slug,
+			Module:    r.Module,
+			Version:   r.Version,
+			CreatedAt: r.CreatedAt,
+			FileURI:   r.FileURI,
+			FileSize:  r.FileSize,
+		})
+	}
+	return mod
+}
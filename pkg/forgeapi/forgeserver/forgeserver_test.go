@@ -0,0 +1,295 @@
+package forgeserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+)
+
+// writeTarball writes a release tarball with the metadata.json to dir
+func writeTarball(t *testing.T, dir, file, metadata string, modTime time.Time) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	top := file[:len(file)-len(".tar.gz")]
+	files := map[string]string{top + "/metadata.json": metadata, top + "/README.md": "# " + top}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	path := filepath.Join(dir, file)
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func metadata(name, version, summary string, deps ...string) string {
+	depJSON := ""
+	for i, d := range deps {
+		if i > 0 {
+			depJSON += ","
+		}
+		depJSON += fmt.Sprintf(`{"name": "%s", "version_requirement": ">= 1.0.0"}`, d)
+	}
+	return fmt.Sprintf(`{"name": "%s", "version": "%s", "author": "test", "license": "Apache-2.0", "summary": "%s", "source": "", "tags": ["test"], "dependencies": [%s]}`, name, version, summary, depJSON)
+}
+
+// testForge serves a directory with stdlib 1.0.0 and 1.2.0, apache 2.0.0,
+// and a tarball without a metadata.json
+func testForge(t *testing.T) (*httptest.Server, *forgeapi.Client, string, func()) {
+	dir, err := ioutil.TempDir("", "forgeserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	writeTarball(t, dir, "puppetlabs-stdlib-1.0.0.tar.gz", metadata("puppetlabs-stdlib", "1.0.0", "Standard library"), now.Add(-2*time.Hour))
+	writeTarball(t, dir, "stdlib-latest.tar.gz", metadata("puppetlabs/stdlib", "1.2.0", "Standard library"), now.Add(-time.Hour))
+	writeTarball(t, dir, "puppetlabs-apache-2.0.0.tar.gz", metadata("puppetlabs-apache", "2.0.0", "Installs Apache", "puppetlabs/stdlib"), now)
+	ioutil.WriteFile(filepath.Join(dir, "broken.tar.gz"), []byte("not a tarball"), 0644)
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	c := forgeapi.NewClient(srv.URL, "test/0.0.0")
+	c.HTTPClient = http.DefaultClient
+	c.MaxRetries = 0
+	return srv, c, dir, func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFetchModule(t *testing.T) {
+	_, c, _, cleanup := testForge(t)
+	defer cleanup()
+	mod, err := c.FetchModule(context.Background(), "puppetlabs-stdlib")
+	if err != nil {
+		t.Fatalf("Failed to fetch module with error: %v", err)
+	}
+	if mod.Slug != "puppetlabs-stdlib" || mod.Name != "stdlib" || mod.Owner.Username != "puppetlabs" {
+		t.Errorf("Expected puppetlabs-stdlib, got %s owned by %s", mod.Slug, mod.Owner.Username)
+	}
+	if mod.CurrentRelease.Version != "1.2.0" || len(mod.Releases) != 2 || mod.Releases[1].Version != "1.0.0" {
+		t.Errorf("Expected current release 1.2.0 and releases 1.2.0 and 1.0.0, got %s and %v", mod.CurrentRelease.Version, mod.Releases)
+	}
+	if mod.CreatedAt != mod.Releases[1].CreatedAt || mod.UpdatedAt != mod.Releases[0].CreatedAt {
+		t.Errorf("Expected the module to be created with its first release and updated with its latest, got %s and %s", mod.CreatedAt, mod.UpdatedAt)
+	}
+	var non200 *forgeapi.GetNon200Error
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-missing"); !errors.As(err, &non200) || non200.StatusCode != 404 {
+		t.Errorf("Expected a 404 for a missing module, got: %v", err)
+	}
+}
+
+func TestFetchReleaseFile(t *testing.T) {
+	_, c, dir, cleanup := testForge(t)
+	defer cleanup()
+	rel, err := c.FetchRelease(context.Background(), "puppetlabs-stdlib-1.2.0")
+	if err != nil {
+		t.Fatalf("Failed to fetch release with error: %v", err)
+	}
+	if rel.FileURI != "/v3/files/puppetlabs-stdlib-1.2.0.tar.gz" || rel.Metadata.Summary != "Standard library" {
+		t.Errorf("Expected the release of stdlib-latest.tar.gz, got %s: %s", rel.FileURI, rel.Metadata.Summary)
+	}
+	data, err := c.FetchReleaseFile(context.Background(), rel)
+	if err != nil {
+		t.Fatalf("Failed to fetch and verify release file with error: %v", err)
+	}
+	expected, _ := ioutil.ReadFile(filepath.Join(dir, "stdlib-latest.tar.gz"))
+	if !bytes.Equal(data, expected) || rel.FileSize != len(expected) {
+		t.Errorf("Expected the contents of stdlib-latest.tar.gz")
+	}
+}
+
+func TestListModules(t *testing.T) {
+	_, c, _, cleanup := testForge(t)
+	defer cleanup()
+	tests := []struct {
+		opts     forgeapi.ListModulesOpts
+		expected []string
+	}{
+		{forgeapi.ListModulesOpts{}, []string{"puppetlabs-apache", "puppetlabs-stdlib"}},
+		{forgeapi.ListModulesOpts{Query: "apache"}, []string{"puppetlabs-apache"}},
+		{forgeapi.ListModulesOpts{Query: "standard"}, []string{"puppetlabs-stdlib"}},
+		{forgeapi.ListModulesOpts{Owner: "example"}, []string{}},
+		{forgeapi.ListModulesOpts{Slugs: []string{"puppetlabs-stdlib"}}, []string{"puppetlabs-stdlib"}},
+		{forgeapi.ListModulesOpts{SortBy: "latest_release"}, []string{"puppetlabs-apache", "puppetlabs-stdlib"}},
+	}
+	for _, tt := range tests {
+		results, err := c.ListModules(context.Background(), tt.opts)
+		if err != nil {
+			t.Fatalf("Failed to list modules with error: %v", err)
+		}
+		var slugs []string
+		for _, m := range results.Results {
+			slugs = append(slugs, m.Slug)
+		}
+		if fmt.Sprint(slugs) != fmt.Sprint(tt.expected) || results.Pagination.Total != len(tt.expected) {
+			t.Errorf("Expected %v for %+v, got %v with total %d", tt.expected, tt.opts, slugs, results.Pagination.Total)
+		}
+	}
+}
+
+func TestIterateReleases(t *testing.T) {
+	_, c, _, cleanup := testForge(t)
+	defer cleanup()
+	it := c.IterateReleases(context.Background(), forgeapi.ListReleasesOpts{Limit: 1}, 0)
+	var slugs []string
+	for it.Next() {
+		slugs = append(slugs, it.Release().Slug)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Failed to iterate releases with error: %v", err)
+	}
+	expected := []string{"puppetlabs-apache-2.0.0", "puppetlabs-stdlib-1.2.0", "puppetlabs-stdlib-1.0.0"}
+	if fmt.Sprint(slugs) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v across pages, got %v", expected, slugs)
+	}
+	results, err := c.ListReleases(context.Background(), forgeapi.ListReleasesOpts{Module: "puppetlabs/stdlib", SortBy: "release_date"})
+	if err != nil || len(results.Results) != 2 || results.Results[0].Version != "1.2.0" {
+		t.Errorf("Expected the releases of stdlib, newest first, got %v and error: %v", results.Results, err)
+	}
+	if _, err := c.ListReleases(context.Background(), forgeapi.ListReleasesOpts{Limit: 1000}); err == nil {
+		t.Errorf("Expected an error for a limit over %d", forgeapi.MaxListLimit)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	srv, _, _, cleanup := testForge(t)
+	defer cleanup()
+	resp, err := http.Get(srv.URL + "/v3/modules/puppetlabs-apache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	req, _ := http.NewRequest("GET", srv.URL+"/v3/modules/puppetlabs-apache", nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected a 304 for a matching ETag, got %d", resp.StatusCode)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	srv, _, _, cleanup := testForge(t)
+	defer cleanup()
+	tests := map[string]int{
+		"/v3/users/puppetlabs":                     http.StatusNotFound,
+		"/v3/files/puppetlabs-stdlib-9.9.9.tar.gz": http.StatusNotFound,
+		"/v3/releases/puppetlabs-stdlib-9.9.9":     http.StatusNotFound,
+		"/v3/modules?offset=-1":                    http.StatusBadRequest,
+	}
+	for path, status := range tests {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Expected %d for %s, got %d", status, path, resp.StatusCode)
+		}
+	}
+	resp, err := http.Post(srv.URL+"/v3/modules", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected a 405 for a POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, _, dir, cleanup := testForge(t)
+	defer cleanup()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mods, rels := s.Count(); mods != 2 || rels != 3 {
+		t.Errorf("Expected 2 modules and 3 releases, got %d and %d", mods, rels)
+	}
+	writeTarball(t, dir, "duplicate.tar.gz", metadata("puppetlabs-apache", "2.0.0", "Duplicate"), time.Now())
+	writeTarball(t, dir, "puppetlabs-concat-1.0.0.tar.gz", metadata("puppetlabs-concat", "1.0.0", "Concat"), time.Now())
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if mods, rels := s.Count(); mods != 3 || rels != 4 {
+		t.Errorf("Expected 3 modules and 4 releases after loading again, got %d and %d", mods, rels)
+	}
+	if _, err := New(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+}
+
+// blockingWriter blocks the first Write until unblock is closed
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	writing chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	select {
+	case <-w.writing:
+	default:
+		close(w.writing)
+		<-w.unblock
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestLoadWhileServingFile(t *testing.T) {
+	_, _, dir, cleanup := testForge(t)
+	defer cleanup()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), writing: make(chan struct{}), unblock: make(chan struct{})}
+	served := make(chan struct{})
+	go func() {
+		s.ServeHTTP(w, httptest.NewRequest("GET", forgeapi.V3FilesEndpoint+"/puppetlabs-apache-2.0.0.tar.gz", nil))
+		close(served)
+	}()
+	<-w.writing
+	loaded := make(chan error)
+	go func() { loaded <- s.Load() }()
+	select {
+	case err := <-loaded:
+		if err != nil {
+			t.Errorf("Failed to load with error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected Load not to wait for a file to be served")
+	}
+	close(w.unblock)
+	<-served
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the file to be served, got %d", w.Code)
+	}
+}
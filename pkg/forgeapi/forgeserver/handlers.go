@@ -0,0 +1,277 @@
+package forgeserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// ServeHTTP serves GET and HEAD requests to the Forge v3 API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		WriteError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported", r.Method))
+		return
+	}
+	p := r.URL.Path
+	if strings.HasPrefix(p, forgeapi.V3FilesEndpoint+"/") {
+		s.serveFile(w, r, strings.TrimPrefix(p, forgeapi.V3FilesEndpoint+"/"))
+		return
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	switch {
+	case p == forgeapi.V3ModulesEndpoint:
+		s.listModules(w, r)
+	case strings.HasPrefix(p, forgeapi.V3ModulesEndpoint+"/"):
+		s.getModule(w, r, strings.TrimPrefix(p, forgeapi.V3ModulesEndpoint+"/"))
+	case p == forgeapi.V3ReleasesEndpoint:
+		s.listReleases(w, r)
+	case strings.HasPrefix(p, forgeapi.V3ReleasesEndpoint+"/"):
+		s.getRelease(w, r, strings.TrimPrefix(p, forgeapi.V3ReleasesEndpoint+"/"))
+	default:
+		WriteError(w, http.StatusNotFound, fmt.Sprintf("%s is not served by this Forge", p))
+	}
+}
+
+func (s *Server) getModule(w http.ResponseWriter, r *http.Request, slug string) {
+	m, ok := s.modules[strings.ToLower(ast.NormalizeName(slug))]
+	if !ok {
+		WriteError(w, http.StatusNotFound, fmt.Sprintf("Module %s not found", slug))
+		return
+	}
+	WriteJSON(w, r, m.moduleObject())
+}
+
+func (s *Server) getRelease(w http.ResponseWriter, r *http.Request, slug string) {
+	rel, ok := s.files[strings.ToLower(slug)+".tar.gz"]
+	if !ok {
+		WriteError(w, http.StatusNotFound, fmt.Sprintf("Release %s not found", slug))
+		return
+	}
+	WriteJSON(w, r, rel.Release)
+}
+
+// serveFile serves a release tarball. The lock is only held to look up the
+// release, so that streaming a large tarball doesn't block Load.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	s.mutex.RLock()
+	rel, ok := s.files[strings.ToLower(name)]
+	s.mutex.RUnlock()
+	if !ok {
+		WriteError(w, http.StatusNotFound, fmt.Sprintf("File %s not found", name))
+		return
+	}
+	f, err := os.Open(rel.path)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read %s", name))
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/gzip")
+	http.ServeContent(w, r, name, rel.modTime, f)
+}
+
+// listModules serves the list modules endpoint. The query, owner, tag, and
+// slugs parameters filter the modules, which are sorted by slug, or newest
+// release first with a sort_by of latest_release.
+func (s *Server) listModules(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, err := PageParams(q)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := strings.ToLower(q.Get("query"))
+	owner := strings.ToLower(q.Get("owner"))
+	tag := strings.ToLower(q.Get("tag"))
+	slugs := listParam(q, "slugs")
+	var mods []*module
+	for _, key := range s.slugs {
+		m := s.modules[key]
+		latest := m.releases[0]
+		switch {
+		case owner != "" && strings.ToLower(m.owner) != owner:
+			continue
+		case tag != "" && !containsFold(latest.Tags, tag):
+			continue
+		case len(slugs) > 0 && !containsFold(slugs, key):
+			continue
+		case query != "" && !matchesQuery(m, query):
+			continue
+		}
+		mods = append(mods, m)
+	}
+	if q.Get("sort_by") == "latest_release" {
+		sort.SliceStable(mods, func(i, j int) bool {
+			return mods[i].releases[0].modTime.After(mods[j].releases[0].modTime)
+		})
+	}
+	results := forgeapi.ListResults{Pagination: Paginate(r.URL, limit, offset, len(mods)), Results: []forgeapi.Module{}}
+	for i := offset; i < len(mods) && i < offset+limit; i++ {
+		results.Results = append(results.Results, mods[i].moduleObject())
+	}
+	WriteJSON(w, r, results)
+}
+
+// listReleases serves the list releases endpoint. The module and owner
+// parameters filter the releases, which are sorted by module slug and then
+// newest version first, or newest release first with a sort_by of
+// release_date.
+func (s *Server) listReleases(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, err := PageParams(q)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mod := strings.ToLower(ast.NormalizeName(q.Get("module")))
+	owner := strings.ToLower(q.Get("owner"))
+	var rels []*release
+	for _, key := range s.slugs {
+		m := s.modules[key]
+		if (mod != "" && key != mod) || (owner != "" && strings.ToLower(m.owner) != owner) {
+			continue
+		}
+		rels = append(rels, m.releases...)
+	}
+	if q.Get("sort_by") == "release_date" {
+		sort.SliceStable(rels, func(i, j int) bool {
+			return rels[i].modTime.After(rels[j].modTime)
+		})
+	}
+	results := forgeapi.ListReleasesResults{Pagination: Paginate(r.URL, limit, offset, len(rels)), Results: []forgeapi.Release{}}
+	for i := offset; i < len(rels) && i < offset+limit; i++ {
+		results.Results = append(results.Results, rels[i].Release)
+	}
+	WriteJSON(w, r, results)
+}
+
+// matchesQuery reports whether the slug, summary, or tags of the latest
+// release of a module contain the lowercase query
+func matchesQuery(m *module, query string) bool {
+	latest := m.releases[0]
+	if strings.Contains(strings.ToLower(m.slug), query) || strings.Contains(strings.ToLower(latest.Metadata.Summary), query) {
+		return true
+	}
+	for _, t := range latest.Tags {
+		if strings.Contains(strings.ToLower(t), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listParam returns the values of a list parameter, which may be repeated
+// or comma separated
+func listParam(q url.Values, key string) []string {
+	var values []string
+	for _, v := range q[key] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// PageParams returns the limit and offset of a list request, which default
+// to DefaultLimit and 0
+func PageParams(q url.Values) (int, int, error) {
+	limit, offset := DefaultLimit, 0
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > forgeapi.MaxListLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", forgeapi.MaxListLimit)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be 0 or more")
+		}
+	}
+	return limit, offset, nil
+}
+
+// Paginate returns the pagination of a page of a list request, with URLs
+// relative to the Forge like the Forge's own
+func Paginate(u *url.URL, limit, offset, total int) forgeapi.ListPagination {
+	page := func(o int) string {
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(o))
+		return fmt.Sprintf("%s?%s", u.Path, q.Encode())
+	}
+	p := forgeapi.ListPagination{
+		Limit:   limit,
+		Offset:  offset,
+		First:   page(0),
+		Current: page(offset),
+		Total:   total,
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		p.Previous = page(prev)
+	}
+	if offset+limit < total {
+		p.Next = page(offset + limit)
+	}
+	return p
+}
+
+// WriteJSON writes v as a JSON response with an ETag, or a 304 if the
+// request's If-None-Match matches it
+func WriteJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:16]))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body)
+}
+
+// WriteError writes an error response in the Forge's format
+func WriteError(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}{fmt.Sprintf("%d %s", status, http.StatusText(status)), []string{msg}})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
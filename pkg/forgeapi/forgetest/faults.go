@@ -0,0 +1,107 @@
+package forgetest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi/forgeserver"
+)
+
+// Fault is a failure injected into the responses of a Server
+type Fault struct {
+	// Path limits the Fault to requests to paths that start with it. An
+	// empty Path matches all requests.
+	Path string
+	// Times is the number of requests the Fault applies to, after which it
+	// is removed. A Times of 0 applies the Fault to all requests.
+	Times int
+	// Latency delays the response
+	Latency time.Duration
+	// Status is the status code of the response, with an error body in the
+	// Forge's format, unless it is 0
+	Status int
+	// RetryAfter is sent as a Retry-After header with the Status, rounded
+	// up to whole seconds
+	RetryAfter time.Duration
+	// Drop closes the connection without a response, so the request fails
+	// with a connection error. Note that net/http retries a GET request
+	// once by itself if it was sent on a reused connection that is closed.
+	Drop bool
+}
+
+// Inject adds a Fault. Faults apply in the order they were added, and only
+// the first Fault that matches a request applies to it.
+func (s *Server) Inject(f Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// Latency delays all responses to path by d
+func (s *Server) Latency(path string, d time.Duration) {
+	s.Inject(Fault{Path: path, Latency: d})
+}
+
+// Fail responds to the next times requests to path with the status code
+func (s *Server) Fail(path string, status, times int) {
+	s.Inject(Fault{Path: path, Status: status, Times: times})
+}
+
+// RateLimit responds to the next times requests to path with a 429 Too
+// Many Requests and a Retry-After header
+func (s *Server) RateLimit(path string, retryAfter time.Duration, times int) {
+	s.Inject(Fault{Path: path, Status: http.StatusTooManyRequests, RetryAfter: retryAfter, Times: times})
+}
+
+// fault returns the Fault that applies to a request to path, if any, and
+// counts the request against it. It is called with the mutex held.
+func (s *Server) fault(path string) *Fault {
+	for i, f := range s.faults {
+		if !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		applied := *f
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &applied
+	}
+	return nil
+}
+
+// apply applies the Fault to a response, returning true if the response
+// was written
+func (f *Fault) apply(w http.ResponseWriter, r *http.Request) bool {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return true
+		case <-timer.C:
+		}
+	}
+	if f.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if f.Status == 0 {
+		return false
+	}
+	if f.RetryAfter > 0 {
+		seconds := int((f.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	forgeserver.WriteError(w, f.Status, fmt.Sprintf("Injected by forgetest for %s", r.URL.Path))
+	return true
+}
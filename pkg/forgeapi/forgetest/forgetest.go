@@ -0,0 +1,357 @@
+// Package forgetest provides a fake Forge for testing code that uses the
+// Forge API. Each Server is an independent httptest.Server, so unlike the
+// mocks package it can be used in parallel tests:
+//
+//	func TestOutdated(t *testing.T) {
+//		t.Parallel()
+//		forge := forgetest.New(t)
+//		forge.AddModule(forgeapi.Module{Slug: "puppetlabs-stdlib", ...})
+//		forge.RateLimit("/v3/modules", time.Second, 1)
+//		mod, err := forge.Client().FetchModule(ctx, "puppetlabs-stdlib")
+//		...
+//		if n := len(forge.RequestsTo("/v3/modules/puppetlabs-stdlib")); n != 2 {
+//			...
+//		}
+//	}
+package forgetest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi/forgeserver"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// Server is a fake Forge serving the modules, releases, files, and users
+// endpoints of the Forge v3 API. It serves the Go values added to it, and
+// the release tarballs of the fixture directories loaded into it. Requests
+// are recorded, and failures can be injected with Faults.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	modules  map[string]forgeapi.Module
+	releases map[string]forgeapi.Release
+	files    map[string][]byte
+	users    map[string]forgeapi.User
+	fixtures []*forgeserver.Server
+	requests []Request
+	faults   []*Fault
+}
+
+// Request is a request recorded by a Server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Time   time.Time
+}
+
+// NewServer starts and returns a new Server, which should be closed when
+// the test is done
+func NewServer() *Server {
+	s := &Server{
+		modules:  make(map[string]forgeapi.Module),
+		releases: make(map[string]forgeapi.Release),
+		files:    make(map[string][]byte),
+		users:    make(map[string]forgeapi.User),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// New starts and returns a new Server that is closed when the test is done
+func New(t testing.TB) *Server {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a Client for the Server. It retries failed requests with
+// waits of at most 10ms, including waits requested by a Retry-After
+// header, so tests of retries are fast.
+func (s *Server) Client() *forgeapi.Client {
+	c := forgeapi.NewClient(s.URL, "forgetest/0.0.0")
+	c.HTTPClient = s.HTTPClient()
+	c.DownloadHTTPClient = c.HTTPClient
+	c.RetryWaitMin = time.Millisecond
+	c.RetryWaitMax = 10 * time.Millisecond
+	return c
+}
+
+// HTTPClient returns an HTTPClient for making requests to the Server
+func (s *Server) HTTPClient() forgeapi.HTTPClient {
+	return s.Server.Client()
+}
+
+// AddModule adds modules to be served by their slug. The current release
+// of each module is also added as a release, if it has a slug and no
+// release with that slug was added, with the module as its Module if it
+// has none.
+func (s *Server) AddModule(mods ...forgeapi.Module) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, m := range mods {
+		s.modules[strings.ToLower(m.Slug)] = m
+		rel := m.CurrentRelease
+		key := strings.ToLower(rel.Slug)
+		if _, ok := s.releases[key]; key != "" && !ok {
+			if rel.Module.Slug == "" {
+				rel.Module = forgeapi.ModuleAbbreviated{URI: m.URI, Slug: m.Slug, Name: m.Name, Owner: m.Owner}
+			}
+			s.releases[key] = rel
+		}
+	}
+}
+
+// AddRelease adds a release to be served by its slug. If file isn't nil, it
+// is served as the release's tarball. The release's FileURI defaults to
+// /v3/files/<slug>.tar.gz, and its file size and checksums are set from
+// the file.
+func (s *Server) AddRelease(rel forgeapi.Release, file []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if file != nil {
+		if rel.FileURI == "" {
+			rel.FileURI = fmt.Sprintf("%s/%s.tar.gz", forgeapi.V3FilesEndpoint, rel.Slug)
+		}
+		md5sum := md5.Sum(file)
+		sha256sum := sha256.Sum256(file)
+		rel.FileSize = len(file)
+		rel.FileMD5 = hex.EncodeToString(md5sum[:])
+		rel.FileSHA256 = hex.EncodeToString(sha256sum[:])
+		s.files[strings.ToLower(rel.FileURI)] = file
+	}
+	s.releases[strings.ToLower(rel.Slug)] = rel
+}
+
+// AddUser adds users to be served by their slug
+func (s *Server) AddUser(users ...forgeapi.User) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, u := range users {
+		s.users[strings.ToLower(u.Slug)] = u
+	}
+}
+
+// LoadDir serves the release tarballs in dir, like pufctl forge serve.
+// Requests for modules, releases, and files that weren't added as Go
+// values are served from the fixture directories, in the order they were
+// loaded. List requests are only served from the fixture directories if no
+// Go values of that kind were added.
+func (s *Server) LoadDir(dir string) error {
+	fixture, err := forgeserver.New(dir)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fixtures = append(s.fixtures, fixture)
+	return nil
+}
+
+// Requests returns the requests made to the Server, in order
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests made to the path, in order
+func (s *Server) RequestsTo(path string) []Request {
+	var reqs []Request
+	for _, r := range s.Requests() {
+		if r.Path == path {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// Reset forgets the recorded requests and removes all Faults
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = nil
+	s.faults = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Time:   time.Now(),
+	})
+	fault := s.fault(r.URL.Path)
+	s.mutex.Unlock()
+	if fault != nil && fault.apply(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		forgeserver.WriteError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported", r.Method))
+		return
+	}
+	if s.serveValue(w, r) {
+		return
+	}
+	s.mutex.Lock()
+	fixtures := s.fixtures
+	s.mutex.Unlock()
+	if len(fixtures) == 0 {
+		forgeserver.WriteError(w, http.StatusNotFound, fmt.Sprintf("%s not found", r.URL.Path))
+		return
+	}
+	// Each fixture but the last is only used if it has the resource, so
+	// a 404 comes from the last one
+	for _, f := range fixtures[:len(fixtures)-1] {
+		rec := httptest.NewRecorder()
+		f.ServeHTTP(rec, r)
+		if rec.Code != http.StatusNotFound {
+			for k, v := range rec.Header() {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+	}
+	fixtures[len(fixtures)-1].ServeHTTP(w, r)
+}
+
+// serveValue serves the request from the Go values added to the Server,
+// returning false if it should be served from the fixture directories
+func (s *Server) serveValue(w http.ResponseWriter, r *http.Request) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p := r.URL.Path
+	switch {
+	case p == forgeapi.V3ModulesEndpoint && len(s.modules) > 0:
+		serveList(w, r, s.modules, func(v interface{}, q url.Values) bool {
+			m := v.(forgeapi.Module)
+			return matches(q.Get("owner"), m.Owner.Username) && matchesSlugs(q, m.Slug) &&
+				(q.Get("query") == "" || strings.Contains(strings.ToLower(m.Slug), strings.ToLower(q.Get("query"))))
+		})
+	case p == forgeapi.V3ReleasesEndpoint && len(s.releases) > 0:
+		serveList(w, r, s.releases, func(v interface{}, q url.Values) bool {
+			rel := v.(forgeapi.Release)
+			return matches(q.Get("owner"), rel.Module.Owner.Username) &&
+				matches(ast.NormalizeName(q.Get("module")), rel.Module.Slug)
+		})
+	case p == forgeapi.V3UsersEndpoint:
+		serveList(w, r, s.users, func(interface{}, url.Values) bool { return true })
+	default:
+		return s.serveItem(w, r)
+	}
+	return true
+}
+
+// serveItem serves a single module, release, file, or user
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request) bool {
+	p := r.URL.Path
+	key := strings.ToLower(p[strings.LastIndex(p, "/")+1:])
+	switch {
+	case strings.HasPrefix(p, forgeapi.V3ModulesEndpoint+"/"):
+		if m, ok := s.modules[key]; ok {
+			forgeserver.WriteJSON(w, r, m)
+			return true
+		}
+	case strings.HasPrefix(p, forgeapi.V3ReleasesEndpoint+"/"):
+		if rel, ok := s.releases[key]; ok {
+			forgeserver.WriteJSON(w, r, rel)
+			return true
+		}
+	case strings.HasPrefix(p, forgeapi.V3FilesEndpoint+"/"):
+		if file, ok := s.files[strings.ToLower(p)]; ok {
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(file)
+			return true
+		}
+	case strings.HasPrefix(p, forgeapi.V3UsersEndpoint+"/"):
+		if u, ok := s.users[key]; ok {
+			forgeserver.WriteJSON(w, r, u)
+			return true
+		}
+		forgeserver.WriteError(w, http.StatusNotFound, fmt.Sprintf("User %s not found", key))
+		return true
+	}
+	return false
+}
+
+// serveList serves a page of the values in a map that match a filter,
+// sorted by their key
+func serveList(w http.ResponseWriter, r *http.Request, values interface{}, filter func(interface{}, url.Values) bool) {
+	q := r.URL.Query()
+	limit, offset, err := forgeserver.PageParams(q)
+	if err != nil {
+		forgeserver.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var keys []string
+	items := make(map[string]interface{})
+	switch v := values.(type) {
+	case map[string]forgeapi.Module:
+		for k, m := range v {
+			items[k] = m
+		}
+	case map[string]forgeapi.Release:
+		for k, rel := range v {
+			items[k] = rel
+		}
+	case map[string]forgeapi.User:
+		for k, u := range v {
+			items[k] = u
+		}
+	}
+	for k, item := range items {
+		if filter(item, q) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	results := []interface{}{}
+	for i := offset; i < len(keys) && i < offset+limit; i++ {
+		results = append(results, items[keys[i]])
+	}
+	forgeserver.WriteJSON(w, r, struct {
+		Pagination forgeapi.ListPagination `json:"pagination"`
+		Results    []interface{}           `json:"results"`
+	}{forgeserver.Paginate(r.URL, limit, offset, len(keys)), results})
+}
+
+// matches reports whether a filter is unset, or matches a value
+func matches(filter, value string) bool {
+	return filter == "" || strings.EqualFold(filter, value)
+}
+
+// matchesSlugs reports whether the slugs parameter is unset, or includes
+// the slug
+func matchesSlugs(q url.Values, slug string) bool {
+	if len(q["slugs"]) == 0 {
+		return true
+	}
+	for _, v := range q["slugs"] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), slug) {
+				return true
+			}
+		}
+	}
+	return false
+}
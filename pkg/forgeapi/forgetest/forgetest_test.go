@@ -0,0 +1,178 @@
+package forgetest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi"
+)
+
+func stdlib(version string) forgeapi.Module {
+	return forgeapi.Module{
+		Slug:  "puppetlabs-stdlib",
+		Name:  "stdlib",
+		Owner: forgeapi.UserAbbreviated{Slug: "puppetlabs", Username: "puppetlabs"},
+		CurrentRelease: forgeapi.Release{
+			Slug:    "puppetlabs-stdlib-" + version,
+			Version: version,
+		},
+	}
+}
+
+func TestServeValues(t *testing.T) {
+	t.Parallel()
+	forge := New(t)
+	forge.AddModule(stdlib("6.0.0"), forgeapi.Module{Slug: "example-ntp", Owner: forgeapi.UserAbbreviated{Username: "example"}})
+	forge.AddRelease(forgeapi.Release{Slug: "puppetlabs-stdlib-5.0.0", Version: "5.0.0", Module: forgeapi.ModuleAbbreviated{Slug: "puppetlabs-stdlib"}}, []byte("tarball"))
+	forge.AddUser(forgeapi.User{Slug: "puppetlabs", Username: "puppetlabs"})
+	c := forge.Client()
+	ctx := context.Background()
+	mod, err := c.FetchModule(ctx, "puppetlabs-stdlib")
+	if err != nil || mod.CurrentRelease.Version != "6.0.0" {
+		t.Errorf("Expected stdlib 6.0.0, got %s and error: %v", mod.CurrentRelease.Version, err)
+	}
+	rel, err := c.FetchRelease(ctx, "puppetlabs-stdlib-5.0.0")
+	if err != nil {
+		t.Fatalf("Failed to fetch release with error: %v", err)
+	}
+	if data, err := c.FetchReleaseFile(ctx, rel); err != nil || string(data) != "tarball" {
+		t.Errorf("Expected the release file with matching checksums, got %q and error: %v", data, err)
+	}
+	if _, err := c.FetchRelease(ctx, "puppetlabs-stdlib-6.0.0"); err != nil {
+		t.Errorf("Expected the current release of a module to be served, got error: %v", err)
+	}
+	if u, err := c.FetchUser(ctx, "puppetlabs"); err != nil || u.Username != "puppetlabs" {
+		t.Errorf("Expected user puppetlabs, got %s and error: %v", u.Username, err)
+	}
+	results, err := c.ListModules(ctx, forgeapi.ListModulesOpts{Owner: "example"})
+	if err != nil || len(results.Results) != 1 || results.Results[0].Slug != "example-ntp" {
+		t.Errorf("Expected example-ntp, got %v and error: %v", results.Results, err)
+	}
+	it := c.IterateReleases(ctx, forgeapi.ListReleasesOpts{Module: "puppetlabs/stdlib", Limit: 1}, 0)
+	var slugs []string
+	for it.Next() {
+		slugs = append(slugs, it.Release().Slug)
+	}
+	if it.Err() != nil || fmt.Sprint(slugs) != "[puppetlabs-stdlib-5.0.0 puppetlabs-stdlib-6.0.0]" {
+		t.Errorf("Expected both stdlib releases across pages, got %v and error: %v", slugs, it.Err())
+	}
+	var non200 *forgeapi.GetNon200Error
+	if _, err := c.FetchModule(ctx, "puppetlabs-missing"); !errors.As(err, &non200) || non200.StatusCode != 404 {
+		t.Errorf("Expected a 404 for a missing module, got: %v", err)
+	}
+}
+
+func TestRecordRequests(t *testing.T) {
+	t.Parallel()
+	forge := New(t)
+	forge.AddModule(stdlib("6.0.0"))
+	c := forge.Client()
+	c.Token = "secret"
+	for i := 0; i < 2; i++ {
+		c.FetchModule(context.Background(), "puppetlabs-stdlib")
+	}
+	c.FetchModule(context.Background(), "puppetlabs-apache")
+	reqs := forge.RequestsTo("/v3/modules/puppetlabs-stdlib")
+	if len(reqs) != 2 || len(forge.Requests()) != 3 {
+		t.Fatalf("Expected 2 of 3 requests to stdlib, got %d of %d", len(reqs), len(forge.Requests()))
+	}
+	if reqs[0].Header.Get("Authorization") != "Bearer secret" || reqs[0].Method != "GET" {
+		t.Errorf("Expected a GET with the token, got %s with %s", reqs[0].Method, reqs[0].Header.Get("Authorization"))
+	}
+	forge.Reset()
+	if len(forge.Requests()) != 0 {
+		t.Errorf("Expected no requests after Reset")
+	}
+}
+
+func TestFaults(t *testing.T) {
+	t.Parallel()
+	forge := New(t)
+	forge.AddModule(stdlib("6.0.0"))
+	forge.RateLimit("/v3/modules", time.Second, 2)
+	forge.Fail("/v3/modules/puppetlabs-stdlib", http.StatusServiceUnavailable, 1)
+	c := forge.Client()
+	c.MaxRetries = 3
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-stdlib"); err != nil {
+		t.Fatalf("Expected the request to succeed after retries, got error: %v", err)
+	}
+	reqs := forge.Requests()
+	if len(reqs) != 4 {
+		t.Errorf("Expected 2 rate limited, 1 failed, and 1 successful request, got %d requests", len(reqs))
+	}
+
+	forge.Fail("", http.StatusNotFound, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := c.FetchModule(context.Background(), "puppetlabs-stdlib"); err == nil {
+			t.Errorf("Expected a fault without Times to apply to every request")
+		}
+	}
+	forge.Reset()
+
+	forge.Inject(Fault{Drop: true})
+	c.MaxRetries = 0
+	var getErr *forgeapi.GetError
+	if _, err := c.FetchModule(context.Background(), "puppetlabs-stdlib"); !errors.As(err, &getErr) {
+		t.Errorf("Expected a connection error for a dropped request, got: %v", err)
+	}
+	forge.Reset()
+
+	forge.Latency("/v3/modules", time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.FetchModule(ctx, "puppetlabs-stdlib"); err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected the request to time out in 50ms, took %s with error: %v", time.Since(start), err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "forgetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	meta := `{"name": "puppetlabs-concat", "version": "1.0.0", "author": "test", "license": "Apache-2.0", "summary": "", "source": ""}`
+	tw.WriteHeader(&tar.Header{Name: "puppetlabs-concat-1.0.0/metadata.json", Mode: 0644, Size: int64(len(meta)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(meta))
+	tw.Close()
+	gz.Close()
+	ioutil.WriteFile(filepath.Join(dir, "puppetlabs-concat-1.0.0.tar.gz"), buf.Bytes(), 0644)
+
+	forge := New(t)
+	if err := forge.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	forge.AddModule(stdlib("6.0.0"))
+	c := forge.Client()
+	for _, slug := range []string{"puppetlabs-stdlib", "puppetlabs-concat"} {
+		if mod, err := c.FetchModule(context.Background(), slug); err != nil || mod.Slug != slug {
+			t.Errorf("Expected %s, got %s and error: %v", slug, mod.Slug, err)
+		}
+	}
+	results, err := c.ListReleases(context.Background(), forgeapi.ListReleasesOpts{})
+	if err != nil || len(results.Results) != 1 || results.Results[0].Slug != "puppetlabs-stdlib-6.0.0" {
+		t.Errorf("Expected releases to be listed from the Go values, got %v and error: %v", results.Results, err)
+	}
+	rel, err := c.FetchRelease(context.Background(), "puppetlabs-concat-1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchReleaseFile(context.Background(), rel); err != nil {
+		t.Errorf("Expected the fixture's release file, got error: %v", err)
+	}
+}
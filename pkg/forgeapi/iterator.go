@@ -0,0 +1,143 @@
+package forgeapi
+
+import (
+	"context"
+	"strings"
+)
+
+// pager follows the pagination of a Forge API list endpoint. Each page is
+// requested from the next URL of the page before it, until there are no
+// more pages, max results have been returned, or a request fails.
+type pager struct {
+	ctx    context.Context
+	client *Client
+	base   string
+	next   string
+	max    int
+	count  int
+	total  int
+	err    error
+}
+
+func newPager(ctx context.Context, c *Client, endpoint string, opts interface{}, max int) pager {
+	p := pager{ctx: ctx, client: c, max: max}
+	p.base, p.err = requestBaseURL(c.BaseURL, "")
+	if p.err == nil {
+		p.next, p.err = listURL(c.BaseURL, endpoint, opts)
+	}
+	if p.err != nil {
+		p.err = &ListError{Err: p.err}
+	}
+	return p
+}
+
+// full reports whether max results have been returned
+func (p *pager) full() bool {
+	return p.max > 0 && p.count >= p.max
+}
+
+// fetch requests the next page and decodes it into page, returning false
+// if there are no more pages or the request failed
+func (p *pager) fetch(page interface{}, pagination func() ListPagination) bool {
+	if p.err != nil || p.next == "" || p.full() {
+		return false
+	}
+	if err := p.client.getJSON(p.ctx, p.next, page); err != nil {
+		p.err = &ListError{Err: err}
+		return false
+	}
+	p.total = pagination().Total
+	next := pagination().Next
+	switch {
+	case next == "":
+		p.next = ""
+	case strings.HasPrefix(next, "http://") || strings.HasPrefix(next, "https://"):
+		p.next = next
+	default:
+		p.next = p.base + next
+	}
+	return true
+}
+
+// Err returns the error that stopped the iteration, if any
+func (p *pager) Err() error {
+	return p.err
+}
+
+// Total returns the total number of results reported by the Forge, which
+// is known after the first call to Next
+func (p *pager) Total() int {
+	return p.total
+}
+
+// ModuleIterator iterates over the Modules of a list modules search, across
+// all pages of results:
+//
+//	it := forgeapi.IterateModules(url, agent, opts, 100)
+//	for it.Next() {
+//		mod := it.Module()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ModuleIterator struct {
+	pager
+	page ListResults
+	cur  Module
+}
+
+// Next advances the iterator to the next Module, requesting the next page
+// of results if needed. It returns false when there are no more Modules or
+// a request failed.
+func (it *ModuleIterator) Next() bool {
+	if it.full() {
+		return false
+	}
+	for len(it.page.Results) == 0 {
+		it.page = ListResults{}
+		if !it.fetch(&it.page, func() ListPagination { return it.page.Pagination }) {
+			return false
+		}
+	}
+	it.cur = it.page.Results[0]
+	it.page.Results = it.page.Results[1:]
+	it.count++
+	return true
+}
+
+// Module returns the current Module
+func (it *ModuleIterator) Module() Module {
+	return it.cur
+}
+
+// ReleaseIterator iterates over the Releases of a list releases search,
+// across all pages of results. It is used the same way as ModuleIterator.
+type ReleaseIterator struct {
+	pager
+	page ListReleasesResults
+	cur  Release
+}
+
+// Next advances the iterator to the next Release, requesting the next page
+// of results if needed. It returns false when there are no more Releases
+// or a request failed.
+func (it *ReleaseIterator) Next() bool {
+	if it.full() {
+		return false
+	}
+	for len(it.page.Results) == 0 {
+		it.page = ListReleasesResults{}
+		if !it.fetch(&it.page, func() ListPagination { return it.page.Pagination }) {
+			return false
+		}
+	}
+	it.cur = it.page.Results[0]
+	it.page.Results = it.page.Results[1:]
+	it.count++
+	return true
+}
+
+// Release returns the current Release
+func (it *ReleaseIterator) Release() Release {
+	return it.cur
+}
@@ -0,0 +1,93 @@
+package forgeapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/forgeapi/utils/mocks"
+)
+
+// mockPages makes the mock client serve total modules in pages of limit,
+// with relative next URLs like the Forge
+func mockPages(total int, failAt int) *int {
+	requests := 0
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+		requests++
+		if requests == failAt {
+			return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		q := req.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		var results []string
+		for i := offset; i < offset+limit && i < total; i++ {
+			results = append(results, fmt.Sprintf(`{"slug": "fakeorg-mod%d", "version": "1.0.%d"}`, i, i))
+		}
+		next := "null"
+		if offset+limit < total {
+			next = fmt.Sprintf(`"%s?limit=%d&offset=%d"`, req.URL.Path, limit, offset+limit)
+		}
+		body := fmt.Sprintf(`{"pagination": {"limit": %d, "offset": %d, "next": %s, "total": %d}, "results": [%s]}`, limit, offset, next, total, strings.Join(results, ","))
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+	return &requests
+}
+
+func TestIterateModules(t *testing.T) {
+	requests := mockPages(7, 0)
+	it := IterateModules(fakeURLStr, fakeUAStr, ListModulesOpts{Limit: 3}, 0)
+	var slugs []string
+	for it.Next() {
+		slugs = append(slugs, it.Module().Slug)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iteration failed with error: %v", err)
+	}
+	if len(slugs) != 7 || slugs[0] != "fakeorg-mod0" || slugs[6] != "fakeorg-mod6" {
+		t.Errorf("Expected all 7 modules in order, got: %v", slugs)
+	}
+	if *requests != 3 || it.Total() != 7 {
+		t.Errorf("Expected 3 requests and a total of 7, got %d requests and a total of %d", *requests, it.Total())
+	}
+}
+
+func TestIterateModulesMax(t *testing.T) {
+	requests := mockPages(10, 0)
+	it := IterateModules(fakeURLStr, fakeUAStr, ListModulesOpts{Limit: 3}, 4)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != 4 || *requests != 2 {
+		t.Errorf("Expected 4 modules from 2 requests, got %d modules from %d requests", count, *requests)
+	}
+}
+
+func TestIterateModulesError(t *testing.T) {
+	mockPages(10, 2)
+	it := IterateModules(fakeURLStr, fakeUAStr, ListModulesOpts{Limit: 3}, 0)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != 3 || it.Err() == nil {
+		t.Errorf("Expected 3 modules and an error from the failed second page, got %d modules and error: %v", count, it.Err())
+	}
+}
+
+func TestIterateReleases(t *testing.T) {
+	mockPages(5, 0)
+	it := IterateReleases(fakeURLStr, fakeUAStr, ListReleasesOpts{Limit: 2, Module: "fakeorg-mod"}, 0)
+	var versions []string
+	for it.Next() {
+		versions = append(versions, it.Release().Version)
+	}
+	if it.Err() != nil || len(versions) != 5 || versions[4] != "1.0.4" {
+		t.Errorf("Expected 5 releases, got %v with error: %v", versions, it.Err())
+	}
+}
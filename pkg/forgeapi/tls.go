@@ -0,0 +1,62 @@
+package forgeapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLSOptions configure the TLS connections to a Forge, for a Forge with a
+// certificate signed by a private CA, or one that requires a client
+// certificate
+type TLSOptions struct {
+	// CAFile is a PEM bundle of CA certificates trusted in addition to the
+	// system's
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key
+	CertFile string
+	KeyFile  string
+}
+
+// TLSConfig returns a tls.Config with the options
+func TLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{}
+	if opts.CAFile != "" {
+		pem, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read CA bundle %s with error: %w", opts.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No PEM certificates found in CA bundle %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("A client certificate requires both a certificate and a key")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load client certificate %s with error: %w", opts.CertFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// ConfigureTLS sets the TLS options of the transport shared by the
+// DefaultHTTPClient and DefaultDownloadHTTPClient. It should be called
+// before any requests are made.
+func ConfigureTLS(opts TLSOptions) error {
+	config, err := TLSConfig(opts)
+	if err != nil {
+		return err
+	}
+	transport.TLSClientConfig = config
+	return nil
+}
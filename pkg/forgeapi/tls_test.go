@@ -0,0 +1,110 @@
+package forgeapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes PEM blocks of the type to a file in dir
+func writePEM(t *testing.T, dir, name, blockType string, blocks ...[]byte) string {
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: b})...)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCert writes a self-signed client certificate and its key to dir
+func clientCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pufctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, dir, "client.pem", "CERTIFICATE", cert), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", der)
+}
+
+func tlsClient(t *testing.T, url string, opts TLSOptions) *Client {
+	config, err := TLSConfig(opts)
+	if err != nil {
+		t.Fatalf("Failed to create TLS config with error: %v", err)
+	}
+	c := testClient(url)
+	c.MaxRetries = 0
+	c.HTTPClient = &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	return c
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forgetls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"slug": "%s"}`, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+	ca := writePEM(t, dir, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	cert, key := clientCert(t, dir)
+
+	if _, err := tlsClient(t, srv.URL, TLSOptions{CertFile: cert, KeyFile: key}).FetchModule(context.Background(), "pufctl"); err == nil {
+		t.Errorf("Expected the Forge's certificate to be untrusted without the CA bundle")
+	}
+	if _, err := tlsClient(t, srv.URL, TLSOptions{CAFile: ca}).FetchModule(context.Background(), "pufctl"); err == nil {
+		t.Errorf("Expected the Forge to refuse a connection without a client certificate")
+	}
+	mod, err := tlsClient(t, srv.URL, TLSOptions{CAFile: ca, CertFile: cert, KeyFile: key}).FetchModule(context.Background(), "pufctl")
+	if err != nil || mod.Slug != "pufctl" {
+		t.Errorf("Expected to connect with the CA bundle and client certificate, got %s and error: %v", mod.Slug, err)
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	tests := []TLSOptions{
+		{CAFile: "/nonexistent/ca.pem"},
+		{CAFile: "tls_test.go"},
+		{CertFile: "client.pem"},
+		{CertFile: "/nonexistent/client.pem", KeyFile: "/nonexistent/client-key.pem"},
+	}
+	for _, opts := range tests {
+		if _, err := TLSConfig(opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+}
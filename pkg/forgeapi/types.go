@@ -1,6 +1,13 @@
 // Package forgeapi provides a (incomplete) API for the Puppet Forge
 package forgeapi
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
 // Module is a struct representation of the Puppet Forge OpenAPI Module object
 type Module struct {
 	URI            string               `json:"uri" alias:"uri"`
@@ -23,6 +30,25 @@ type Module struct {
 	IssuesURL      string               `json:"issues_url" alias:"issuesurl,issues_url"`
 }
 
+// Deprecated reports whether the module is deprecated on the Forge. The
+// reason is in DeprecatedFor, and the replacement, if any, in SupersededBy.
+func (m Module) Deprecated() bool {
+	return m.DeprecatedAt != ""
+}
+
+// DeprecationMessage describes why the module is deprecated, and what to use
+// instead
+func (m Module) DeprecationMessage() string {
+	msg := fmt.Sprintf("Module %s is deprecated", m.Slug)
+	if reason := strings.TrimSpace(m.DeprecatedFor); reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, strings.TrimSuffix(reason, "."))
+	}
+	if m.SupersededBy.Slug != "" {
+		msg = fmt.Sprintf("%s. Use %s instead", msg, ast.NormalizeName(m.SupersededBy.Slug))
+	}
+	return msg + "."
+}
+
 // ModuleAbbreviated is a struct representation of the Puppet Forge OpenAPI ModuleAbbreviated object
 type ModuleAbbreviated struct {
 	URI          string          `json:"uri" alias:"uri"`
@@ -201,3 +227,96 @@ type ListPagination struct {
 	Next     string `json:"next"`
 	Total    int    `json:"total"`
 }
+
+// User is a struct representation of the Puppet Forge OpenAPI User object
+type User struct {
+	URI          string `json:"uri" alias:"uri"`
+	Slug         string `json:"slug" alias:"slug"`
+	GravatarID   string `json:"gravatar_id,omitempty" alias:"gravatarid,gravatar_id"`
+	Username     string `json:"username" alias:"username"`
+	DisplayName  string `json:"display_name" alias:"displayname,display_name"`
+	ReleaseCount int    `json:"release_count" alias:"releasecount,release_count"`
+	ModuleCount  int    `json:"module_count" alias:"modulecount,module_count"`
+	CreatedAt    string `json:"created_at" alias:"createdat,created_at"`
+	UpdatedAt    string `json:"updated_at" alias:"updatedat,updated_at"`
+}
+
+// ReleasePlan is a struct representation of the Puppet Forge OpenAPI ReleasePlan object
+type ReleasePlan struct {
+	URI        string            `json:"uri" alias:"uri"`
+	Name       string            `json:"name" alias:"name"`
+	PlanSchema ReleasePlanSchema `json:"plan_schema" alias:"planschema,plan_schema"`
+	Private    bool              `json:"private" alias:"private"`
+	CreatedAt  string            `json:"created_at" alias:"createdat,created_at"`
+	UpdatedAt  string            `json:"updated_at" alias:"updatedat,updated_at"`
+}
+
+// ReleasePlanSchema is a struct representation of the plan_schema of a
+// Puppet Forge OpenAPI ReleasePlan object
+type ReleasePlanSchema struct {
+	Name        string                          `json:"name" alias:"name"`
+	Description string                          `json:"description,omitempty" alias:"description"`
+	Private     bool                            `json:"private" alias:"private"`
+	Parameters  map[string]ReleasePlanParameter `json:"parameters,omitempty" alias:"parameters,params"`
+}
+
+// ReleasePlanParameter is a struct representation of a parameter in the
+// plan_schema of a Puppet Forge OpenAPI ReleasePlan object
+type ReleasePlanParameter struct {
+	Type         string      `json:"type" alias:"type"`
+	Description  string      `json:"description,omitempty" alias:"description"`
+	DefaultValue interface{} `json:"default_value,omitempty" alias:"defaultvalue,default_value,default"`
+}
+
+// ListReleasesOpts is used by ListReleases to construct the
+// search parameters for the list releases API endpoint
+type ListReleasesOpts struct {
+	Limit                  int      `url:"limit,omitempty"`
+	Offset                 int      `url:"offset,omitempty"`
+	SortBy                 string   `url:"sort_by,omitempty"`
+	Module                 string   `url:"module,omitempty"`
+	Owner                  string   `url:"owner,omitempty"`
+	WithPDK                bool     `url:"with_pdk,omitempty"`
+	OperatingSystem        string   `url:"operatingsystem,omitempty"`
+	OperatingSystemRelease string   `url:"operatingsystemrelease,omitempty"`
+	PERequirement          string   `url:"pe_requirement,omitempty"`
+	PupRequirement         string   `url:"puppet_requirement,omitempty"`
+	ModuleGroups           []string `url:"module_groups,omitempty"`
+	ShowDeleted            bool     `url:"show_deleted,omitempty"`
+	HideDeprecated         bool     `url:"hide_deprecated,omitempty"`
+	WithHTML               bool     `url:"with_html,omitempty"`
+	Supported              bool     `url:"supported,omitempty"`
+	IncludeFields          []string `url:"include_fields,omitempty"`
+	ExcludeFields          []string `url:"exclude_fields,omitempty"`
+}
+
+// ListUsersOpts is used by ListUsers to construct the
+// search parameters for the list users API endpoint
+type ListUsersOpts struct {
+	Limit         int      `url:"limit,omitempty"`
+	Offset        int      `url:"offset,omitempty"`
+	SortBy        string   `url:"sort_by,omitempty"`
+	IncludeFields []string `url:"include_fields,omitempty"`
+	ExcludeFields []string `url:"exclude_fields,omitempty"`
+}
+
+// ListReleasesResults is a struct representation of the JSON return
+// of the ForgeAPI list releases endpoint
+type ListReleasesResults struct {
+	Pagination ListPagination `json:"pagination"`
+	Results    []Release      `json:"results"`
+}
+
+// ListUsersResults is a struct representation of the JSON return
+// of the ForgeAPI list users endpoint
+type ListUsersResults struct {
+	Pagination ListPagination `json:"pagination"`
+	Results    []User         `json:"results"`
+}
+
+// ListReleasePlansResults is a struct representation of the JSON return
+// of the ForgeAPI release plans endpoint
+type ListReleasePlansResults struct {
+	Pagination ListPagination `json:"pagination"`
+	Results    []ReleasePlan  `json:"results"`
+}
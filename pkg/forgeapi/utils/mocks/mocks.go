@@ -1,3 +1,6 @@
+// Package mocks provides a mock HTTPClient for the forgeapi package's own
+// tests. Its GetDoFunc hook is global, so tests that use it can't run in
+// parallel. The forgetest package provides a fake Forge that can.
 package mocks
 
 import (
@@ -96,3 +96,185 @@ const ModuleJSONBody200 = `{
 	"homepage_url": "https://github.com/puppetlabs/puppetlabs-apache",
 	"issues_url": "https://tickets.puppetlabs.com/browse/MODULES"
   }`
+
+// ReleaseJSONBody200 provides a string of a valid JSON response for
+// a release fetch operation (status code 200). The checksums match
+// ReleaseFileBody200.
+const ReleaseJSONBody200 = `{
+	"uri": "/v3/releases/puppetlabs-stdlib-6.0.0",
+	"slug": "puppetlabs-stdlib-6.0.0",
+	"module": {
+	  "uri": "/v3/modules/puppetlabs-stdlib",
+	  "slug": "puppetlabs-stdlib",
+	  "name": "stdlib",
+	  "owner": {
+		"uri": "/v3/users/puppetlabs",
+		"slug": "puppetlabs",
+		"username": "puppetlabs",
+		"gravatar_id": "fdd009b7c1ec96e088b389f773e87aec"
+	  }
+	},
+	"version": "6.0.0",
+	"metadata": {
+	  "name": "puppetlabs-stdlib",
+	  "version": "6.0.0",
+	  "author": "puppetlabs",
+	  "dependencies": []
+	},
+	"tags": [],
+	"supported": true,
+	"file_uri": "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz",
+	"file_size": 19,
+	"file_md5": "5e3bbc4bec053b32ae28113d9e2d0397",
+	"file_sha256": "e22fab9fa152b689b7b242d780a17339ff068d39cc4317367a10f2d14e28134b",
+	"downloads": 1000,
+	"created_at": "2019-05-13 05:46:26 -0700",
+	"updated_at": "2019-05-13 05:46:26 -0700"
+}`
+
+// ReleaseFileBody200 provides the body of a release tarball download
+const ReleaseFileBody200 = "pufctl test release"
+
+// ReleasesListJSONBody200 provides a string of a valid JSON response for
+// a list releases operation (status code 200)
+const ReleasesListJSONBody200 = `{
+	"pagination": {
+	  "limit": 2,
+	  "offset": 0,
+	  "first": "/v3/releases?limit=2&module=puppetlabs-stdlib&offset=0",
+	  "previous": null,
+	  "current": "/v3/releases?limit=2&module=puppetlabs-stdlib&offset=0",
+	  "next": "/v3/releases?limit=2&module=puppetlabs-stdlib&offset=2",
+	  "total": 3
+	},
+	"results": [
+	  {
+		"uri": "/v3/releases/puppetlabs-stdlib-6.1.0",
+		"slug": "puppetlabs-stdlib-6.1.0",
+		"module": {
+		  "uri": "/v3/modules/puppetlabs-stdlib",
+		  "slug": "puppetlabs-stdlib",
+		  "name": "stdlib",
+		  "owner": {
+			"uri": "/v3/users/puppetlabs",
+			"slug": "puppetlabs",
+			"username": "puppetlabs"
+		  }
+		},
+		"version": "6.1.0",
+		"supported": true,
+		"pdk": true,
+		"file_uri": "/v3/files/puppetlabs-stdlib-6.1.0.tar.gz",
+		"created_at": "2019-10-01 05:46:26 -0700"
+	  },
+	  {
+		"uri": "/v3/releases/puppetlabs-stdlib-6.0.0",
+		"slug": "puppetlabs-stdlib-6.0.0",
+		"module": {
+		  "uri": "/v3/modules/puppetlabs-stdlib",
+		  "slug": "puppetlabs-stdlib",
+		  "name": "stdlib",
+		  "owner": {
+			"uri": "/v3/users/puppetlabs",
+			"slug": "puppetlabs",
+			"username": "puppetlabs"
+		  }
+		},
+		"version": "6.0.0",
+		"supported": true,
+		"pdk": true,
+		"file_uri": "/v3/files/puppetlabs-stdlib-6.0.0.tar.gz",
+		"created_at": "2019-05-13 05:46:26 -0700",
+		"deleted_at": null
+	  }
+	]
+}`
+
+// UserJSONBody200 provides a string of a valid JSON response for
+// a user fetch operation (status code 200)
+const UserJSONBody200 = `{
+	"uri": "/v3/users/puppetlabs",
+	"slug": "puppetlabs",
+	"gravatar_id": "fdd009b7c1ec96e088b389f773e87aec",
+	"username": "puppetlabs",
+	"display_name": "Puppet",
+	"release_count": 4926,
+	"module_count": 213,
+	"created_at": "2010-05-19 05:46:26 -0700",
+	"updated_at": "2019-05-13 05:46:26 -0700"
+}`
+
+// UsersListJSONBody200 provides a string of a valid JSON response for
+// a list users operation (status code 200)
+const UsersListJSONBody200 = `{
+	"pagination": {
+	  "limit": 1,
+	  "offset": 0,
+	  "first": "/v3/users?limit=1&offset=0",
+	  "previous": null,
+	  "current": "/v3/users?limit=1&offset=0",
+	  "next": null,
+	  "total": 1
+	},
+	"results": [
+	  {
+		"uri": "/v3/users/puppetlabs",
+		"slug": "puppetlabs",
+		"username": "puppetlabs",
+		"display_name": "Puppet",
+		"release_count": 4926,
+		"module_count": 213
+	  }
+	]
+}`
+
+// ReleasePlanJSONBody200 provides a string of a valid JSON response for
+// a release plan fetch operation (status code 200)
+const ReleasePlanJSONBody200 = `{
+	"uri": "/v3/releases/puppetlabs-facts-1.0.0/plans/facts::info",
+	"name": "facts::info",
+	"plan_schema": {
+	  "name": "facts::info",
+	  "description": "A plan that prints basic OS information for the specified targets.",
+	  "private": false,
+	  "parameters": {
+		"targets": {
+		  "type": "TargetSpec",
+		  "description": "The targets to retrieve the OS information from."
+		}
+	  }
+	},
+	"private": false,
+	"created_at": "2019-05-13 05:46:26 -0700",
+	"updated_at": "2019-05-13 05:46:26 -0700"
+}`
+
+// ReleasePlansJSONBody200 provides a string of a valid JSON response for
+// a list release plans operation (status code 200)
+const ReleasePlansJSONBody200 = `{
+	"pagination": {
+	  "limit": 20,
+	  "offset": 0,
+	  "first": "/v3/releases/puppetlabs-facts-1.0.0/plans?limit=20&offset=0",
+	  "previous": null,
+	  "current": "/v3/releases/puppetlabs-facts-1.0.0/plans?limit=20&offset=0",
+	  "next": null,
+	  "total": 1
+	},
+	"results": [
+	  {
+		"uri": "/v3/releases/puppetlabs-facts-1.0.0/plans/facts::info",
+		"name": "facts::info",
+		"plan_schema": {
+		  "name": "facts::info",
+		  "private": false,
+		  "parameters": {
+			"targets": {
+			  "type": "TargetSpec"
+			}
+		  }
+		},
+		"private": false
+	  }
+	]
+}`
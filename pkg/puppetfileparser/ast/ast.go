@@ -16,10 +16,12 @@ import (
 
 // PuppetfileLexer is a custom regex lexer for Puppetfiles
 var PuppetfileLexer = lexer.Must(regex.New(`
+	Moduledir = ^moduledir
 	Keyword = ^mod
 	Forge = ^forge
 	String = '([^']*)'
 	Int = \d
+	Bool = \b(true|false)\b
 	Char = [[:alpha:]]
 	Ident = :[A-Za-z0-9_]+
 	Comment = #.*
@@ -62,11 +64,12 @@ func NewParser() (*participle.Parser, error) {
 	)
 }
 
-// Value Struct that holds the types that a value can be
+// Value Struct that holds the types that a value can be. The
+// booleans true and false are held in Ident, Ex. :local => true
 type Value struct {
 	Pos    lexer.Position
 	String string `@String`
-	Ident  string `| @Ident`
+	Ident  string `| @Ident | @Bool`
 }
 
 // Sprint returns string representation of type
@@ -125,6 +128,19 @@ func (p Property) ChecksumValue() [16]byte {
 	return p.Value.Checksum()
 }
 
+// Copy returns a deep copy of the Value
+func (v *Value) Copy() *Value {
+	if v == nil {
+		return nil
+	}
+	return &Value{Pos: v.Pos, String: v.String, Ident: v.Ident}
+}
+
+// Copy returns a deep copy of the Property
+func (p *Property) Copy() *Property {
+	return &Property{Pos: p.Pos, Key: p.Key.Copy(), Value: p.Value.Copy()}
+}
+
 // OverwriteValue creates a new *Value adds it to the Property
 // Value field.
 func (p *Property) OverwriteValue(val string) {
@@ -240,6 +256,33 @@ func (m Module) GetPropertyValue(name string) string {
 	return ""
 }
 
+// GetVersionOrRef returns the module's version, as returned by
+// GetPropertyValue("version"), if it has one. Otherwise, the value of
+// the first of the :ref, :branch, :commit, or :default_branch properties
+// found is returned. If the module has none of these, an empty string
+// is returned.
+func (m Module) GetVersionOrRef() string {
+	if v := m.GetPropertyValue("version"); v != "" {
+		return v
+	}
+	for _, k := range []string{":ref", ":branch", ":commit", ":default_branch"} {
+		if v := m.GetPropertyValue(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Copy returns a deep copy of the Module. Changes made to the
+// copy's properties do not affect the original Module.
+func (m Module) Copy() *Module {
+	props := make([]*Property, 0, len(m.Properties))
+	for _, p := range m.Properties {
+		props = append(props, p.Copy())
+	}
+	return &Module{Pos: m.Pos, Name: m.Name, Properties: props}
+}
+
 // AddProperties accepts strings in the form of "key=>value",
 // parses them into Property objects, and adds them to the module.
 // Key must have a ":" prefixing it, just like in a Puppetfile.
@@ -397,9 +440,21 @@ func (f Forge) Checksum() [16]byte {
 	return md5.Sum(data)
 }
 
+// Moduledir holds a moduledir declaration. Only string literals are
+// supported, Ruby expressions such as File.join() are not.
+type Moduledir struct {
+	Path string `Moduledir @String`
+}
+
+// Sprint returns a string representation of type
+func (m *Moduledir) Sprint() string {
+	return fmt.Sprintf("moduledir '%s'", m.Path)
+}
+
 // Puppetfile Struct that holds entire Puppetfile
 type Puppetfile struct {
 	Forge               *Forge       `( @@ )?`
+	Moduledir           *Moduledir   `( @@ )?`
 	Statements          []*Statement `{ @@ }`
 	Metadata            Metadata
 	ModuleMetadata      []ModuleMetadata
@@ -408,28 +463,30 @@ type Puppetfile struct {
 	ModuleVersionMap    map[string]string
 }
 
-// HasModule returns true if the named module exists in the Puppetfile
+// NormalizeName returns a module name in the "org-mod" form, so
+// "puppetlabs/stdlib" becomes "puppetlabs-stdlib"
+func NormalizeName(name string) string {
+	return strings.Replace(name, "/", "-", 1)
+}
+
+// HasModule returns true and the index of the module's Statement if the
+// named module exists in the Puppetfile. Names are compared in their
+// normalized form.
 func (p Puppetfile) HasModule(name string) (bool, int) {
-	stmts := []*Statement{}
-	stmts = append(stmts, p.Statements...)
-	stmts = p.RemoveComments(stmts)
-	sort.Sort(StmtByName(stmts))
-	idx := sort.Search(len(stmts), func(i int) bool { return stmts[i].Module.Name >= name })
-	if idx < len(stmts) && stmts[idx].Module.Name == name {
-		return true, idx
+	normalized := NormalizeName(name)
+	for i, s := range p.Statements {
+		if s.Module != nil && NormalizeName(s.Module.Name) == normalized {
+			return true, i
+		}
 	}
 	return false, -1
 }
 
-// GetModule returns a Module struct by name
+// GetModule returns a Module struct by name, or nil. Names are compared in
+// their normalized form, so "puppetlabs/stdlib" finds "puppetlabs-stdlib".
 func (p Puppetfile) GetModule(name string) *Module {
-	stmts := []*Statement{}
-	stmts = append(stmts, p.Statements...)
-	stmts = p.RemoveComments(stmts)
-	sort.Sort(StmtByName(stmts))
-	idx := sort.Search(len(stmts), func(i int) bool { return stmts[i].Module.Name >= name })
-	if idx < len(stmts) && stmts[idx].Module.Name == name {
-		return stmts[idx].Module
+	if ok, i := p.HasModule(name); ok {
+		return p.Statements[i].Module
 	}
 	return nil
 }
@@ -577,6 +634,29 @@ func (p *Puppetfile) AddModule(slug string, props []string) error {
 	return fmt.Errorf("Puppetfile already contains module %s", slug)
 }
 
+// RemoveModule removes a module from the Puppetfile, along with the comments
+// and metadata directly above it.
+func (p *Puppetfile) RemoveModule(name string) error {
+	for i, s := range p.Statements {
+		if s.Module == nil || s.Module.Name != name {
+			continue
+		}
+		start := i
+		for start > 0 && p.Statements[start-1].Comment != nil {
+			start--
+		}
+		p.Statements = append(p.Statements[:start], p.Statements[i+1:]...)
+		for j, m := range p.ModuleMetadata {
+			if m.Name == name {
+				p.ModuleMetadata = append(p.ModuleMetadata[:j], p.ModuleMetadata[j+1:]...)
+				break
+			}
+		}
+		return p.ParseMetadata()
+	}
+	return fmt.Errorf("Module %s can't be found in the Puppetfile", name)
+}
+
 // AddComment adds a Comment to either the top or bottom block comments
 func (p *Puppetfile) AddComment(location, text string) error {
 	com := DummyComment()
@@ -635,6 +715,111 @@ func (p *Puppetfile) AddModuleMetadata(name string, tag string, data string) err
 	return nil
 }
 
+// ReplaceModuleMetadata replaces the metadata statements directly above a
+// module in the Puppetfile with the given metadata. Comments above the
+// module that are not metadata are kept.
+func (p *Puppetfile) ReplaceModuleMetadata(name string, meta Metadata) error {
+	for i, s := range p.Statements {
+		if s.Module == nil || s.Module.Name != name {
+			continue
+		}
+		start := i
+		for start > 0 && p.Statements[start-1].Comment != nil {
+			start--
+		}
+		var stmts []*Statement
+		stmts = append(stmts, p.Statements[:start]...)
+		for _, c := range p.Statements[start:i] {
+			if _, err := c.Comment.MetaPair(); err != nil {
+				stmts = append(stmts, c)
+			}
+		}
+		for _, mp := range meta.MetaPairs {
+			com := DummyComment()
+			com.Comment.Text = mp.Sprint()
+			stmts = append(stmts, com)
+		}
+		stmts = append(stmts, p.Statements[i:]...)
+		p.Statements = stmts
+		for j, m := range p.ModuleMetadata {
+			if m.Name == name {
+				p.ModuleMetadata[j].Metadata = meta
+				return p.ParseMetadata()
+			}
+		}
+		p.ModuleMetadata = append(p.ModuleMetadata, ModuleMetadata{Name: name, Metadata: meta})
+		return p.ParseMetadata()
+	}
+	return fmt.Errorf("Module %s can't be found in the Puppetfile", name)
+}
+
+// GetModuleComments returns the text of the comments directly above a
+// module in the Puppetfile, including its metadata, or nil if the module
+// can't be found
+func (p *Puppetfile) GetModuleComments(name string) []string {
+	ok, i := p.HasModule(name)
+	if !ok {
+		return nil
+	}
+	start := i
+	for start > 0 && p.Statements[start-1].Comment != nil {
+		start--
+	}
+	comments := make([]string, 0, i-start)
+	for _, c := range p.Statements[start:i] {
+		comments = append(comments, c.Comment.Text)
+	}
+	return comments
+}
+
+// ReplaceModuleComments replaces all comments directly above a module in the
+// Puppetfile, including its metadata, with the given comments.
+func (p *Puppetfile) ReplaceModuleComments(name string, comments []string) error {
+	ok, i := p.HasModule(name)
+	if !ok {
+		return fmt.Errorf("Module %s can't be found in the Puppetfile", name)
+	}
+	mod := p.Statements[i].Module
+	start := i
+	for start > 0 && p.Statements[start-1].Comment != nil {
+		start--
+	}
+	var stmts []*Statement
+	stmts = append(stmts, p.Statements[:start]...)
+	meta := Metadata{MetaPairs: make([]MetaPair, 0)}
+	for _, text := range comments {
+		com := DummyComment()
+		com.Comment.Text = text
+		stmts = append(stmts, com)
+		if mp, err := com.Comment.MetaPair(); err == nil {
+			meta.MetaPairs = append(meta.MetaPairs, mp)
+		}
+	}
+	stmts = append(stmts, p.Statements[i:]...)
+	p.Statements = stmts
+	found := false
+	for j, m := range p.ModuleMetadata {
+		if m.Name == mod.Name {
+			p.ModuleMetadata[j].Metadata = meta
+			found = true
+		}
+	}
+	if !found && len(meta.MetaPairs) > 0 {
+		p.ModuleMetadata = append(p.ModuleMetadata, ModuleMetadata{Name: mod.Name, Metadata: meta})
+	}
+	return p.ParseMetadata()
+}
+
+// GetModuleMetadata returns the metadata of a module in the Puppetfile
+func (p *Puppetfile) GetModuleMetadata(name string) Metadata {
+	for _, m := range p.ModuleMetadata {
+		if m.Name == name {
+			return m.Metadata
+		}
+	}
+	return Metadata{MetaPairs: make([]MetaPair, 0)}
+}
+
 // SearchModulesByMetaTag returns a slice of module name strings that
 // have the given tag associated with them.
 func (p *Puppetfile) SearchModulesByMetaTag(tag string) []string {
@@ -685,8 +870,15 @@ func (p *Puppetfile) Sprint() string {
 	for _, b := range p.BottomBlockComments {
 		bottomBlock = append(bottomBlock, b.Sprint())
 	}
+	var directives []string
 	if p.Forge != nil {
-		return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n", strings.Join(topBlock, "\n"), p.Forge.Sprint(), strings.Join(modStrings, "\n"), strings.Join(bottomBlock, "\n"))
+		directives = append(directives, p.Forge.Sprint())
+	}
+	if p.Moduledir != nil {
+		directives = append(directives, p.Moduledir.Sprint())
+	}
+	if len(directives) > 0 {
+		return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n", strings.Join(topBlock, "\n"), strings.Join(directives, "\n"), strings.Join(modStrings, "\n"), strings.Join(bottomBlock, "\n"))
 	}
 	return fmt.Sprintf("%s\n\n%s\n\n%s\n", strings.Join(topBlock, "\n"), strings.Join(modStrings, "\n"), strings.Join(bottomBlock, "\n"))
 }
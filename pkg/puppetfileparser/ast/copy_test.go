@@ -0,0 +1,46 @@
+package ast
+
+import "testing"
+
+func TestModuleCopy(t *testing.T) {
+	p, err := Parse("mod 'puppetlabs-apache',\n  :git => 'https://github.com/puppetlabs/puppetlabs-apache.git',\n  :ref => 'main'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	orig := p.GetModule("puppetlabs-apache")
+	c := orig.Copy()
+	if c == orig || c.Name != orig.Name || len(c.Properties) != len(orig.Properties) {
+		t.Fatalf("Expected a new module with the same name and properties, got %+v", c)
+	}
+	for i := range c.Properties {
+		if c.Properties[i] == orig.Properties[i] || c.Properties[i].Key == orig.Properties[i].Key || c.Properties[i].Value == orig.Properties[i].Value {
+			t.Errorf("Expected property %d to be copied, not shared", i)
+		}
+	}
+	c.Name = "puppetlabs-nginx"
+	c.EditProperty(":ref", "v6.0.0")
+	c.Properties[0].Key.Ident = ":github"
+	if orig.Name != "puppetlabs-apache" || orig.GetPropertyValue(":ref") != "main" || orig.Properties[0].Key.Ident != ":git" {
+		t.Errorf("Expected changes to the copy not to change the original, got %+v", orig)
+	}
+	if c.GetPropertyValue(":ref") != "v6.0.0" {
+		t.Errorf("Expected the copy's :ref to be v6.0.0, got %s", c.GetPropertyValue(":ref"))
+	}
+}
+
+func TestPropertyCopyBare(t *testing.T) {
+	p, err := Parse("mod 'puppetlabs-stdlib', '6.0.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	orig := p.GetModule("puppetlabs-stdlib")
+	c := orig.Copy()
+	c.EditProperty("bare", "7.0.0")
+	if orig.GetPropertyValue("version") != "6.0.0" || c.GetPropertyValue("version") != "7.0.0" {
+		t.Errorf("Expected only the copy's version to change, got %s and %s", orig.GetPropertyValue("version"), c.GetPropertyValue("version"))
+	}
+	var v *Value
+	if v.Copy() != nil {
+		t.Errorf("Expected a copy of a nil Value to be nil")
+	}
+}
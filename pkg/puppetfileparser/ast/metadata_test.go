@@ -0,0 +1,97 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+const metadataPuppetfile = `
+# Managed by the platform team
+# @platform: core
+# @owner: team@fake.com
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '6.0.0'
+`
+
+func TestGetModuleMetadata(t *testing.T) {
+	p, err := Parse(metadataPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	meta := p.GetModuleMetadata("puppetlabs-stdlib")
+	if len(meta.MetaPairs) != 2 || len(meta.SearchByTag("platform")) != 1 || meta.SearchByTag("owner")[0].Data != "team@fake.com" {
+		t.Errorf("Expected the platform and owner meta tags, got %+v", meta)
+	}
+	if meta := p.GetModuleMetadata("puppetlabs-concat"); meta.MetaPairs == nil || len(meta.MetaPairs) != 0 {
+		t.Errorf("Expected empty, non-nil metadata for a module without meta tags, got %+v", meta)
+	}
+	if meta := p.GetModuleMetadata("missing"); meta.MetaPairs == nil || len(meta.MetaPairs) != 0 {
+		t.Errorf("Expected empty, non-nil metadata for a missing module, got %+v", meta)
+	}
+}
+
+func TestReplaceModuleMetadata(t *testing.T) {
+	p, err := Parse(metadataPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	meta := Metadata{MetaPairs: []MetaPair{{Tag: "owner", Data: "web@fake.com"}}}
+	if err := p.ReplaceModuleMetadata("puppetlabs-stdlib", meta); err != nil {
+		t.Fatalf("Failed to replace metadata with error: %v", err)
+	}
+	got := p.GetModuleMetadata("puppetlabs-stdlib")
+	if len(got.MetaPairs) != 1 || got.MetaPairs[0].Data != "web@fake.com" {
+		t.Errorf("Expected only the new owner meta tag, got %+v", got)
+	}
+	out := p.Sprint()
+	if strings.Contains(out, "@platform") || strings.Contains(out, "team@fake.com") {
+		t.Errorf("Expected the old meta tags to be removed, got:\n%s", out)
+	}
+	if !strings.Contains(out, "# Managed by the platform team") || !strings.Contains(out, "# @owner: web@fake.com") {
+		t.Errorf("Expected plain comments to be kept and the new meta tag added, got:\n%s", out)
+	}
+
+	if err := p.ReplaceModuleMetadata("puppetlabs-concat", meta); err != nil {
+		t.Fatalf("Failed to add metadata with error: %v", err)
+	}
+	if got := p.GetModuleMetadata("puppetlabs-concat"); len(got.MetaPairs) != 1 {
+		t.Errorf("Expected metadata to be added to a module without meta tags, got %+v", got)
+	}
+	if err := p.ReplaceModuleMetadata("missing", meta); err == nil {
+		t.Errorf("Expected an error replacing the metadata of a missing module")
+	}
+}
+
+func TestReplaceModuleComments(t *testing.T) {
+	p, err := Parse(metadataPuppetfile)
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	expected := []string{"# Managed by the platform team", "# @platform: core", "# @owner: team@fake.com"}
+	if got := p.GetModuleComments("puppetlabs/stdlib"); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected comments %v, got %v", expected, got)
+	}
+	if got := p.GetModuleComments("missing"); got != nil {
+		t.Errorf("Expected no comments for a missing module, got %v", got)
+	}
+	if err := p.ReplaceModuleComments("puppetlabs-stdlib", []string{"# @owner: web@fake.com"}); err != nil {
+		t.Fatalf("Failed to replace comments with error: %v", err)
+	}
+	out := p.Sprint()
+	if strings.Contains(out, "# Managed by the platform team") || strings.Contains(out, "@platform") {
+		t.Errorf("Expected all old comments to be removed, got:\n%s", out)
+	}
+	if meta := p.GetModuleMetadata("puppetlabs-stdlib"); len(meta.MetaPairs) != 1 || meta.MetaPairs[0].Data != "web@fake.com" {
+		t.Errorf("Expected the metadata to match the new comments, got %+v", meta)
+	}
+	if err := p.ReplaceModuleComments("puppetlabs-concat", []string{"# @platform: core"}); err != nil {
+		t.Fatalf("Failed to add comments with error: %v", err)
+	}
+	if names := p.SearchModulesByMetaTag("platform"); len(names) != 1 || names[0] != "puppetlabs-concat" {
+		t.Errorf("Expected only puppetlabs-concat to have the platform tag, got %v", names)
+	}
+	if err := p.ReplaceModuleComments("missing", nil); err == nil {
+		t.Errorf("Expected an error replacing the comments of a missing module")
+	}
+}
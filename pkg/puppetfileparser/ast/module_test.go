@@ -0,0 +1,37 @@
+package ast
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	cases := map[string]string{
+		"puppetlabs/stdlib": "puppetlabs-stdlib",
+		"puppetlabs-stdlib": "puppetlabs-stdlib",
+		"stdlib":            "stdlib",
+	}
+	for in, expected := range cases {
+		if got := NormalizeName(in); got != expected {
+			t.Errorf("Expected NormalizeName(%q) to be %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestGetModule(t *testing.T) {
+	p, err := Parse("mod 'puppetlabs/stdlib', '6.0.0'\n\nmod 'puppetlabs-concat', '6.0.0'\n")
+	if err != nil {
+		t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+	}
+	for _, name := range []string{"puppetlabs-stdlib", "puppetlabs/stdlib"} {
+		if m := p.GetModule(name); m == nil || m.Name != "puppetlabs/stdlib" {
+			t.Errorf("Expected %s to find puppetlabs/stdlib, got %v", name, m)
+		}
+	}
+	if ok, i := p.HasModule("puppetlabs/concat"); !ok || p.Statements[i].Module.Name != "puppetlabs-concat" {
+		t.Errorf("Expected puppetlabs/concat to find puppetlabs-concat, got %v at %d", ok, i)
+	}
+	if m := p.GetModule("puppetlabs-apache"); m != nil {
+		t.Errorf("Expected a missing module to be nil, got %v", m)
+	}
+	if err := p.AddModule("puppetlabs-stdlib", []string{"'6.1.0'"}); err == nil {
+		t.Errorf("Expected an error adding a module that exists under its other name")
+	}
+}
@@ -0,0 +1,350 @@
+// Package diff compares parsed Puppetfiles and reports the differences
+// between their modules at the property level.
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+// ChangeType describes how a property or meta tag changed
+type ChangeType string
+
+const (
+	// Added means the property or tag is only present in the second Puppetfile
+	Added ChangeType = "added"
+	// Removed means the property or tag is only present in the first Puppetfile
+	Removed ChangeType = "removed"
+	// Changed means the property or tag is present in both Puppetfiles with different values
+	Changed ChangeType = "changed"
+)
+
+// Direction describes whether a version change is an upgrade or a downgrade
+type Direction string
+
+const (
+	// Upgrade means the second version has higher precedence than the first
+	Upgrade Direction = "upgrade"
+	// Downgrade means the second version has lower precedence than the first
+	Downgrade Direction = "downgrade"
+	// Equal means both versions have the same precedence, such as when
+	// only the build metadata changed
+	Equal Direction = "equal"
+	// Unknown means at least one of the versions is not a semantic version
+	Unknown Direction = "unknown"
+)
+
+// Level describes which part of a semantic version changed
+type Level string
+
+const (
+	// Major means the major (X.y.z) version changed
+	Major Level = "major"
+	// Minor means the minor (x.Y.z) version changed
+	Minor Level = "minor"
+	// Patch means the patch (x.y.Z) version changed
+	Patch Level = "patch"
+	// PreRelease means only the prerelease (x.y.z-PreRelease) version changed
+	PreRelease Level = "prerelease"
+	// BuildMetadata means only the build metadata (x.y.z+BuildMetadata) changed
+	BuildMetadata Level = "buildmetadata"
+)
+
+// SourceType is the type of source a module is installed from
+type SourceType string
+
+const (
+	// Forge modules are installed from the Puppet Forge
+	Forge SourceType = "forge"
+	// Git modules are installed from a Git repository
+	Git SourceType = "git"
+	// SVN modules are installed from a Subversion repository
+	SVN SourceType = "svn"
+	// Local modules are managed outside of the Puppetfile
+	Local SourceType = "local"
+	// Tarball modules are installed from a tarball URL
+	Tarball SourceType = "tarball"
+)
+
+// bareKey is the key used for properties without a value, such as a bare
+// version string or the :latest symbol.
+const bareKey = "version"
+
+// Options holds options used when diffing Puppetfiles
+type Options struct {
+	// Meta enables diffing the meta tags of each module
+	Meta bool
+}
+
+// PropertyChange is a single changed module property
+type PropertyChange struct {
+	Key  string     `json:"key"`
+	Type ChangeType `json:"type"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// VersionChange holds the version or ref of a module in both Puppetfiles.
+// Level is only set if both versions are semantic versions.
+type VersionChange struct {
+	Old       string    `json:"old"`
+	New       string    `json:"new"`
+	Direction Direction `json:"direction"`
+	Level     Level     `json:"level,omitempty"`
+}
+
+// SourceChange holds the source type of a module in both Puppetfiles
+type SourceChange struct {
+	Old SourceType `json:"old"`
+	New SourceType `json:"new"`
+}
+
+// MetaChange is a single changed meta tag
+type MetaChange struct {
+	Tag  string     `json:"tag"`
+	Type ChangeType `json:"type"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// ModuleDiff holds all differences of a module found in both Puppetfiles
+type ModuleDiff struct {
+	Name       string           `json:"name"`
+	Properties []PropertyChange `json:"properties,omitempty"`
+	Version    *VersionChange   `json:"version,omitempty"`
+	Source     *SourceChange    `json:"source,omitempty"`
+	Meta       []MetaChange     `json:"meta,omitempty"`
+}
+
+// Empty returns true if the module has no differences
+func (m ModuleDiff) Empty() bool {
+	return len(m.Properties) == 0 && m.Version == nil && m.Source == nil && len(m.Meta) == 0
+}
+
+// Result holds the difference between two Puppetfiles. Removed holds modules
+// only found in the first Puppetfile and Added holds modules only found in
+// the second Puppetfile.
+type Result struct {
+	Removed []*ast.Module
+	Added   []*ast.Module
+	Changed []ModuleDiff
+}
+
+// Empty returns true if there are no differences between the Puppetfiles
+func (r Result) Empty() bool {
+	return len(r.Removed) == 0 && len(r.Added) == 0 && len(r.Changed) == 0
+}
+
+// Puppetfiles returns the difference between two Puppetfiles. Modules are
+// matched by name, accepting both the "org-mod" and "org/mod" forms, and
+// the order of modules in the Puppetfiles is not taken into account.
+func Puppetfiles(one, two *ast.Puppetfile, opts Options) Result {
+	result := Result{}
+	modsOne := modulesByName(one)
+	modsTwo := modulesByName(two)
+	for _, name := range sortedNames(modsOne) {
+		modTwo, ok := modsTwo[name]
+		if !ok {
+			result.Removed = append(result.Removed, modsOne[name])
+			continue
+		}
+		d := Modules(modsOne[name], modTwo)
+		d.Name = name
+		if opts.Meta {
+			d.Meta = Meta(moduleMeta(one, modsOne[name].Name), moduleMeta(two, modTwo.Name))
+		}
+		if !d.Empty() {
+			result.Changed = append(result.Changed, d)
+		}
+	}
+	for _, name := range sortedNames(modsTwo) {
+		if _, ok := modsOne[name]; !ok {
+			result.Added = append(result.Added, modsTwo[name])
+		}
+	}
+	return result
+}
+
+// Modules returns the property, version, and source differences between two modules
+func Modules(one, two *ast.Module) ModuleDiff {
+	d := ModuleDiff{Name: ast.NormalizeName(one.Name)}
+	d.Properties = Properties(one, two)
+	if len(d.Properties) == 0 {
+		return d
+	}
+	d.Version = Version(one.GetVersionOrRef(), two.GetVersionOrRef())
+	srcOne, srcTwo := Source(one), Source(two)
+	if srcOne != srcTwo {
+		d.Source = &SourceChange{Old: srcOne, New: srcTwo}
+	}
+	return d
+}
+
+// Properties returns the added, removed, and changed properties of
+// two modules, sorted by property key.
+func Properties(one, two *ast.Module) []PropertyChange {
+	var changes []PropertyChange
+	propsOne := propertyMap(one)
+	propsTwo := propertyMap(two)
+	for _, k := range sortedKeys(propsOne, propsTwo) {
+		valOne, inOne := propsOne[k]
+		valTwo, inTwo := propsTwo[k]
+		switch {
+		case !inTwo:
+			changes = append(changes, PropertyChange{Key: k, Type: Removed, Old: valOne})
+		case !inOne:
+			changes = append(changes, PropertyChange{Key: k, Type: Added, New: valTwo})
+		case valOne != valTwo:
+			changes = append(changes, PropertyChange{Key: k, Type: Changed, Old: valOne, New: valTwo})
+		}
+	}
+	return changes
+}
+
+// Version classifies the change between two module versions or refs. It
+// returns nil if the versions are the same.
+func Version(one, two string) *VersionChange {
+	if one == two {
+		return nil
+	}
+	vc := &VersionChange{Old: one, New: two, Direction: Unknown}
+	svOne, errOne := semver.Make(one)
+	svTwo, errTwo := semver.Make(two)
+	if errOne != nil || errTwo != nil {
+		return vc
+	}
+	switch svOne.Compare(svTwo) {
+	case -1:
+		vc.Direction = Upgrade
+	case 1:
+		vc.Direction = Downgrade
+	default:
+		vc.Direction = Equal
+	}
+	switch {
+	case svOne.Major != svTwo.Major:
+		vc.Level = Major
+	case svOne.Minor != svTwo.Minor:
+		vc.Level = Minor
+	case svOne.Patch != svTwo.Patch:
+		vc.Level = Patch
+	case svOne.PreRelease != svTwo.PreRelease:
+		vc.Level = PreRelease
+	case svOne.BuildMetadata != svTwo.BuildMetadata:
+		vc.Level = BuildMetadata
+	}
+	return vc
+}
+
+// Source returns the type of source a module is installed from
+func Source(m *ast.Module) SourceType {
+	switch {
+	case m.GetProperty(":git") != nil:
+		return Git
+	case m.GetProperty(":svn") != nil:
+		return SVN
+	case m.GetProperty(":local") != nil:
+		return Local
+	case m.GetProperty(":source") != nil:
+		return Tarball
+	default:
+		return Forge
+	}
+}
+
+// Meta returns the added, removed, and changed meta tags between two
+// sets of module metadata, sorted by tag.
+func Meta(one, two ast.Metadata) []MetaChange {
+	var changes []MetaChange
+	tagsOne := metaMap(one)
+	tagsTwo := metaMap(two)
+	for _, t := range sortedKeys(tagsOne, tagsTwo) {
+		dataOne, inOne := tagsOne[t]
+		dataTwo, inTwo := tagsTwo[t]
+		switch {
+		case !inTwo:
+			changes = append(changes, MetaChange{Tag: t, Type: Removed, Old: dataOne})
+		case !inOne:
+			changes = append(changes, MetaChange{Tag: t, Type: Added, New: dataTwo})
+		case dataOne != dataTwo:
+			changes = append(changes, MetaChange{Tag: t, Type: Changed, Old: dataOne, New: dataTwo})
+		}
+	}
+	return changes
+}
+
+func modulesByName(p *ast.Puppetfile) map[string]*ast.Module {
+	mods := map[string]*ast.Module{}
+	for _, s := range p.Statements {
+		if s.Module != nil {
+			mods[ast.NormalizeName(s.Module.Name)] = s.Module
+		}
+	}
+	return mods
+}
+
+// moduleMeta returns the metadata of the named module in the Puppetfile
+func moduleMeta(p *ast.Puppetfile, name string) ast.Metadata {
+	for _, m := range p.ModuleMetadata {
+		if m.Name == name {
+			return m.Metadata
+		}
+	}
+	return ast.Metadata{}
+}
+
+// propertyMap returns a map of property keys to values. Properties without
+// a value are stored under the "version" key.
+func propertyMap(m *ast.Module) map[string]string {
+	props := map[string]string{}
+	for _, p := range m.Properties {
+		if p.Key == nil {
+			continue
+		}
+		if p.Value == nil {
+			props[bareKey] = p.Key.Sprint()
+			continue
+		}
+		props[p.Key.Sprint()] = p.Value.Sprint()
+	}
+	return props
+}
+
+func metaMap(m ast.Metadata) map[string]string {
+	tags := map[string]string{}
+	for _, mp := range m.MetaPairs {
+		if existing, ok := tags[mp.Tag]; ok {
+			tags[mp.Tag] = strings.Join([]string{existing, mp.Data}, ", ")
+			continue
+		}
+		tags[mp.Tag] = mp.Data
+	}
+	return tags
+}
+
+func sortedNames(mods map[string]*ast.Module) []string {
+	names := make([]string, 0, len(mods))
+	for n := range mods {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(one, two map[string]string) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, m := range []map[string]string{one, two} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
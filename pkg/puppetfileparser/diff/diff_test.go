@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+const puppetfileOne = `
+# @owner: team-a
+mod 'puppetlabs/stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '4.2.1'
+
+mod 'removed', :latest
+
+mod 'gitmod',
+  :git => 'https://fake.com/gitmod',
+  :ref => 'main',
+`
+
+const puppetfileTwo = `
+# @owner: team-b
+mod 'puppetlabs-stdlib', '7.1.0'
+
+mod 'puppetlabs/concat', '4.2.1'
+
+mod 'added', :latest
+
+mod 'gitmod', '1.0.0'
+`
+
+func parseTestPuppetfiles(t *testing.T) (*ast.Puppetfile, *ast.Puppetfile) {
+	one, err := ast.Parse(puppetfileOne)
+	if err != nil {
+		t.Fatalf("Failed to parse first Puppetfile with error: %v", err)
+	}
+	two, err := ast.Parse(puppetfileTwo)
+	if err != nil {
+		t.Fatalf("Failed to parse second Puppetfile with error: %v", err)
+	}
+	return one, two
+}
+
+func findChanged(r Result, name string) *ModuleDiff {
+	for _, d := range r.Changed {
+		if d.Name == name {
+			return &d
+		}
+	}
+	return nil
+}
+
+func TestPuppetfiles(t *testing.T) {
+	one, two := parseTestPuppetfiles(t)
+	r := Puppetfiles(one, two, Options{})
+	if len(r.Removed) != 1 || r.Removed[0].Name != "removed" {
+		t.Errorf("Unexpected removed modules: %v", r.Removed)
+	}
+	if len(r.Added) != 1 || r.Added[0].Name != "added" {
+		t.Errorf("Unexpected added modules: %v", r.Added)
+	}
+	if findChanged(r, "puppetlabs-concat") != nil {
+		t.Errorf("Module puppetlabs-concat should not be changed when only the name form differs")
+	}
+	stdlib := findChanged(r, "puppetlabs-stdlib")
+	if stdlib == nil {
+		t.Fatalf("Module puppetlabs-stdlib should be changed")
+	}
+	if stdlib.Version == nil || stdlib.Version.Direction != Upgrade || stdlib.Version.Level != Major {
+		t.Errorf("Unexpected version change for puppetlabs-stdlib: %+v", stdlib.Version)
+	}
+	if stdlib.Source != nil {
+		t.Errorf("Unexpected source change for puppetlabs-stdlib: %+v", stdlib.Source)
+	}
+	if len(stdlib.Meta) != 0 {
+		t.Errorf("Meta tags should not be diffed unless enabled, got: %+v", stdlib.Meta)
+	}
+	gitmod := findChanged(r, "gitmod")
+	if gitmod == nil {
+		t.Fatalf("Module gitmod should be changed")
+	}
+	if gitmod.Source == nil || gitmod.Source.Old != Git || gitmod.Source.New != Forge {
+		t.Errorf("Unexpected source change for gitmod: %+v", gitmod.Source)
+	}
+	if gitmod.Version == nil || gitmod.Version.Direction != Unknown {
+		t.Errorf("Unexpected version change for gitmod: %+v", gitmod.Version)
+	}
+	if len(gitmod.Properties) != 3 {
+		t.Errorf("Expected 3 property changes for gitmod, got: %+v", gitmod.Properties)
+	}
+}
+
+func TestPuppetfilesMeta(t *testing.T) {
+	one, two := parseTestPuppetfiles(t)
+	r := Puppetfiles(one, two, Options{Meta: true})
+	stdlib := findChanged(r, "puppetlabs-stdlib")
+	if stdlib == nil {
+		t.Fatalf("Module puppetlabs-stdlib should be changed")
+	}
+	if len(stdlib.Meta) != 1 {
+		t.Fatalf("Expected 1 meta change, got: %+v", stdlib.Meta)
+	}
+	mc := stdlib.Meta[0]
+	if mc.Tag != "owner" || mc.Type != Changed || mc.Old != "team-a" || mc.New != "team-b" {
+		t.Errorf("Unexpected meta change: %+v", mc)
+	}
+}
+
+func TestPuppetfilesEmpty(t *testing.T) {
+	one, _ := parseTestPuppetfiles(t)
+	other, _ := parseTestPuppetfiles(t)
+	r := Puppetfiles(one, other, Options{Meta: true})
+	if !r.Empty() {
+		t.Errorf("Expected no difference between identical Puppetfiles, got: %+v", r)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	cases := []struct {
+		one       string
+		two       string
+		direction Direction
+		level     Level
+	}{
+		{"1.0.0", "2.0.0", Upgrade, Major},
+		{"1.2.0", "1.1.0", Downgrade, Minor},
+		{"1.0.0", "1.0.1", Upgrade, Patch},
+		{"1.0.0-rc.1", "1.0.0", Upgrade, PreRelease},
+		{"1.0.0+a", "1.0.0+b", Equal, BuildMetadata},
+		{"main", "1.0.0", Unknown, ""},
+	}
+	for _, c := range cases {
+		vc := Version(c.one, c.two)
+		if vc == nil {
+			t.Errorf("Expected a version change for %s -> %s", c.one, c.two)
+			continue
+		}
+		if vc.Direction != c.direction || vc.Level != c.level {
+			t.Errorf("Unexpected version change for %s -> %s. Expected: %s %s, Got: %s %s", c.one, c.two, c.level, c.direction, vc.Level, vc.Direction)
+		}
+	}
+	if Version("1.0.0", "1.0.0") != nil {
+		t.Errorf("Expected no version change for equal versions")
+	}
+}
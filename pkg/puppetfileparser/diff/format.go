@@ -0,0 +1,358 @@
+package diff
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// Formats holds the names of all supported output formats
+var Formats = []string{"text", "unified", "json", "markdown", "junit"}
+
+// SchemaVersion is the version of the JSON output schema. It is only
+// incremented when a change to the schema is not backwards compatible.
+const SchemaVersion = 1
+
+// ValidFormat returns true if the format is a supported output format
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleSummary is the JSON representation of an added or removed module
+type ModuleSummary struct {
+	Name    string     `json:"name"`
+	Version string     `json:"version"`
+	Source  SourceType `json:"source"`
+}
+
+// Summary holds the number of added, removed, and changed modules
+type Summary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// JSONResult is the stable JSON schema of a Result
+type JSONResult struct {
+	SchemaVersion int             `json:"schema_version"`
+	From          string          `json:"from"`
+	To            string          `json:"to"`
+	Identical     bool            `json:"identical"`
+	Summary       Summary         `json:"summary"`
+	Added         []ModuleSummary `json:"added"`
+	Removed       []ModuleSummary `json:"removed"`
+	Changed       []ModuleDiff    `json:"changed"`
+}
+
+// JSONResult returns the Result as a JSONResult. Slices are never nil, so
+// they are always encoded as arrays.
+func (r Result) JSONResult(nameOne, nameTwo string) JSONResult {
+	jr := JSONResult{
+		SchemaVersion: SchemaVersion,
+		From:          nameOne,
+		To:            nameTwo,
+		Identical:     r.Empty(),
+		Summary:       Summary{Added: len(r.Added), Removed: len(r.Removed), Changed: len(r.Changed)},
+		Added:         summarizeModules(r.Added),
+		Removed:       summarizeModules(r.Removed),
+		Changed:       make([]ModuleDiff, 0, len(r.Changed)),
+	}
+	jr.Changed = append(jr.Changed, r.Changed...)
+	return jr
+}
+
+// JSON returns the indented JSON encoding of the Result
+func (r Result) JSON(nameOne, nameTwo string) ([]byte, error) {
+	return json.MarshalIndent(r.JSONResult(nameOne, nameTwo), "", "  ")
+}
+
+// Markdown returns the Result as Markdown tables, suitable for use in
+// merge request comments.
+func (r Result) Markdown(nameOne, nameTwo string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Puppetfile diff: `%s` → `%s`\n\n", nameOne, nameTwo)
+	if r.Empty() {
+		b.WriteString("No difference between Puppetfiles\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "**%d added, %d removed, %d changed**\n", len(r.Added), len(r.Removed), len(r.Changed))
+	if len(r.Added) > 0 {
+		fmt.Fprintf(&b, "\n#### Added modules (only in `%s`)\n\n", nameTwo)
+		writeMarkdownModules(&b, r.Added)
+	}
+	if len(r.Removed) > 0 {
+		fmt.Fprintf(&b, "\n#### Removed modules (only in `%s`)\n\n", nameOne)
+		writeMarkdownModules(&b, r.Removed)
+	}
+	if len(r.Changed) > 0 {
+		b.WriteString("\n#### Changed modules\n\n")
+		b.WriteString("| Module | Version | Source | Changes |\n|---|---|---|---|\n")
+		for _, d := range r.Changed {
+			version := ""
+			if d.Version != nil {
+				version = markdownEscape(d.Version.Text())
+			}
+			source := ""
+			if d.Source != nil {
+				source = fmt.Sprintf("%s → %s", d.Source.Old, d.Source.New)
+			}
+			var changes []string
+			for _, p := range d.Properties {
+				changes = append(changes, fmt.Sprintf("`%s %s`", changeSymbol(p.Type), p.Key))
+			}
+			for _, m := range d.Meta {
+				changes = append(changes, fmt.Sprintf("`%s @%s`", changeSymbol(m.Type), m.Tag))
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", d.Name, version, source, strings.Join(changes, " "))
+		}
+	}
+	return b.String()
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// JUnit returns the Result as a JUnit XML report so CI systems can show
+// Puppetfile differences as test failures. Each added, removed, or changed
+// module is a failed test case. If there are no differences, the report
+// holds a single passing test case.
+func (r Result) JUnit(nameOne, nameTwo string) ([]byte, error) {
+	const className = "pufctl.diff"
+	suite := junitTestSuite{Name: fmt.Sprintf("%s -> %s", nameOne, nameTwo)}
+	for _, m := range r.Added {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			ClassName: className,
+			Name:      ast.NormalizeName(m.Name),
+			Failure:   &junitFailure{Message: fmt.Sprintf("Module only found in %s", nameTwo), Type: string(Added), Text: m.Sprint()},
+		})
+	}
+	for _, m := range r.Removed {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			ClassName: className,
+			Name:      ast.NormalizeName(m.Name),
+			Failure:   &junitFailure{Message: fmt.Sprintf("Module only found in %s", nameOne), Type: string(Removed), Text: m.Sprint()},
+		})
+	}
+	for _, d := range r.Changed {
+		message := "Module properties changed"
+		if d.Version != nil {
+			message = fmt.Sprintf("Module version changed: %s", d.Version.Text())
+		}
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			ClassName: className,
+			Name:      d.Name,
+			Failure:   &junitFailure{Message: message, Type: string(Changed), Text: d.Text()},
+		})
+	}
+	suite.Failures = len(suite.TestCases)
+	if suite.Failures == 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{ClassName: className, Name: "identical"})
+	}
+	suite.Tests = len(suite.TestCases)
+	suites := junitTestSuites{Name: "pufctl diff", Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// Unified returns a unified diff, like the output of "diff -u", of the
+// normalized (sorted) renders of two Puppetfiles. The context is the number
+// of unchanged lines to show around each change. If the renders are the
+// same, an empty string is returned.
+func Unified(one, two *ast.Puppetfile, nameOne, nameTwo string, context int) string {
+	linesOne := strings.Split(strings.TrimRight(one.Sprint(), "\n"), "\n")
+	linesTwo := strings.Split(strings.TrimRight(two.Sprint(), "\n"), "\n")
+	ops := editScript(linesOne, linesTwo)
+	hunks := groupHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", nameOne, nameTwo)
+	for _, h := range hunks {
+		oldStart, oldCount, newStart, newCount := h[0].lineOne, 0, h[0].lineTwo, 0
+		for _, o := range h {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+		if oldCount > 0 {
+			oldStart++
+		}
+		if newCount > 0 {
+			newStart++
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, o := range h {
+			fmt.Fprintf(&b, "%c%s\n", o.kind, o.text)
+		}
+	}
+	return b.String()
+}
+
+// editOp is a single line of an edit script. The kind is ' ' for unchanged
+// lines, '-' for removed lines, and '+' for added lines. lineOne and lineTwo
+// are the indexes of the line in the first and second text at the op.
+type editOp struct {
+	kind    byte
+	text    string
+	lineOne int
+	lineTwo int
+}
+
+// editScript returns the shortest edit script between two sets of lines
+// using their longest common subsequence.
+func editScript(one, two []string) []editOp {
+	lcs := make([][]int, len(one)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(two)+1)
+	}
+	for i := len(one) - 1; i >= 0; i-- {
+		for j := len(two) - 1; j >= 0; j-- {
+			switch {
+			case one[i] == two[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []editOp
+	i, j := 0, 0
+	for i < len(one) || j < len(two) {
+		switch {
+		case i < len(one) && j < len(two) && one[i] == two[j]:
+			ops = append(ops, editOp{' ', one[i], i, j})
+			i++
+			j++
+		case j == len(two) || (i < len(one) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, editOp{'-', one[i], i, j})
+			i++
+		default:
+			ops = append(ops, editOp{'+', two[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// groupHunks groups an edit script into hunks of changes surrounded by
+// context lines. Changes separated by fewer than 2 * context unchanged
+// lines are grouped into the same hunk.
+func groupHunks(ops []editOp, context int) [][]editOp {
+	var hunks [][]editOp
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		last := i
+		j := i
+		for j < len(ops) {
+			if ops[j].kind != ' ' {
+				last = j
+				j++
+				continue
+			}
+			k := j
+			for k < len(ops) && ops[k].kind == ' ' {
+				k++
+			}
+			if k < len(ops) && k-j <= 2*context {
+				j = k
+				continue
+			}
+			break
+		}
+		stop := last + 1 + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		hunks = append(hunks, ops[start:stop])
+		i = stop
+	}
+	return hunks
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func summarizeModules(mods []*ast.Module) []ModuleSummary {
+	summaries := make([]ModuleSummary, 0, len(mods))
+	for _, m := range mods {
+		summaries = append(summaries, ModuleSummary{Name: ast.NormalizeName(m.Name), Version: m.GetVersionOrRef(), Source: Source(m)})
+	}
+	return summaries
+}
+
+func writeMarkdownModules(b *strings.Builder, mods []*ast.Module) {
+	b.WriteString("| Module | Version | Source |\n|---|---|---|\n")
+	for _, m := range mods {
+		fmt.Fprintf(b, "| `%s` | %s | %s |\n", ast.NormalizeName(m.Name), markdownEscape(m.GetVersionOrRef()), Source(m))
+	}
+}
+
+func changeSymbol(t ChangeType) string {
+	switch t {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	one, two := parseTestPuppetfiles(t)
+	out := Unified(one, two, "one", "two", 1)
+	if !strings.HasPrefix(out, "--- one\n+++ two\n@@ ") {
+		t.Fatalf("Unexpected unified diff header:\n%s", out)
+	}
+	for _, line := range []string{"-  '6.0.0'", "+  '7.1.0'", "-mod 'removed',", "+mod 'added',", "-  :git => 'https://fake.com/gitmod',"} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected unified diff to contain line %q, got:\n%s", line, out)
+		}
+	}
+	if Unified(one, one, "one", "one", 3) != "" {
+		t.Errorf("Expected empty unified diff for identical Puppetfiles")
+	}
+}
+
+func TestGroupHunks(t *testing.T) {
+	one := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	two := []string{"a", "B", "c", "d", "e", "f", "g", "h", "I", "j"}
+	if hunks := groupHunks(editScript(one, two), 1); len(hunks) != 2 {
+		t.Errorf("Expected 2 hunks with context 1, got %d", len(hunks))
+	}
+	if hunks := groupHunks(editScript(one, two), 3); len(hunks) != 1 {
+		t.Errorf("Expected 1 hunk with context 3, got %d", len(hunks))
+	}
+}
+
+func TestJSON(t *testing.T) {
+	one, two := parseTestPuppetfiles(t)
+	out, err := Puppetfiles(one, two, Options{}).JSON("one", "two")
+	if err != nil {
+		t.Fatalf("Failed to encode JSON with error: %v", err)
+	}
+	var jr JSONResult
+	if err := json.Unmarshal(out, &jr); err != nil {
+		t.Fatalf("Failed to decode JSON with error: %v", err)
+	}
+	if jr.SchemaVersion != SchemaVersion || jr.Identical {
+		t.Errorf("Unexpected JSON result: %+v", jr)
+	}
+	if jr.Summary.Added != 1 || jr.Summary.Removed != 1 || jr.Summary.Changed != 2 {
+		t.Errorf("Unexpected JSON summary: %+v", jr.Summary)
+	}
+	out, err = Puppetfiles(one, one, Options{}).JSON("one", "one")
+	if err != nil {
+		t.Fatalf("Failed to encode JSON with error: %v", err)
+	}
+	if !strings.Contains(string(out), `"added": []`) || !strings.Contains(string(out), `"changed": []`) {
+		t.Errorf("Expected empty arrays in JSON output, got:\n%s", out)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	one, two := parseTestPuppetfiles(t)
+	out, err := Puppetfiles(one, two, Options{}).JUnit("one", "two")
+	if err != nil {
+		t.Fatalf("Failed to encode JUnit XML with error: %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out, &suites); err != nil {
+		t.Fatalf("Failed to decode JUnit XML with error: %v", err)
+	}
+	if suites.Tests != 4 || suites.Failures != 4 {
+		t.Errorf("Expected 4 failed tests, got %d tests and %d failures", suites.Tests, suites.Failures)
+	}
+}
@@ -0,0 +1,240 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// ManyFormats holds the names of the output formats supported when
+// diffing more than two Puppetfiles
+var ManyFormats = []string{"text", "json", "markdown"}
+
+// missingMarker is shown in place of the version of a module that is
+// missing from a Puppetfile
+const missingMarker = "-"
+
+// ManyModule holds the versions of a module across many Puppetfiles.
+// Versions maps the name of each Puppetfile that has the module to the
+// module's version or ref.
+type ManyModule struct {
+	Name          string            `json:"name"`
+	Majority      string            `json:"majority"`
+	MajorityCount int               `json:"majority_count"`
+	Versions      map[string]string `json:"versions"`
+	Missing       []string          `json:"missing"`
+	Differs       []string          `json:"differs"`
+}
+
+// ManyResult holds the difference between many Puppetfiles. Only modules
+// that are missing from some Puppetfiles or that have different versions
+// are included.
+type ManyResult struct {
+	Names   []string
+	Modules []ManyModule
+}
+
+// ManyJSONResult is the stable JSON schema of a ManyResult
+type ManyJSONResult struct {
+	SchemaVersion int          `json:"schema_version"`
+	Inputs        []string     `json:"inputs"`
+	Identical     bool         `json:"identical"`
+	Partial       int          `json:"partial"`
+	Differing     int          `json:"differing"`
+	Modules       []ManyModule `json:"modules"`
+}
+
+// Many returns the difference between many Puppetfiles. The names must be
+// unique and are used to refer to the Puppetfile at the same index. For
+// each module, the majority version is the version found in the most
+// Puppetfiles, with ties going to the version found first.
+func Many(names []string, puppetfiles []*ast.Puppetfile) ManyResult {
+	result := ManyResult{Names: names}
+	all := map[string]*ManyModule{}
+	var order []string
+	for i, p := range puppetfiles {
+		for _, s := range p.Statements {
+			if s.Module == nil {
+				continue
+			}
+			name := ast.NormalizeName(s.Module.Name)
+			if _, ok := all[name]; !ok {
+				all[name] = &ManyModule{Name: name, Versions: map[string]string{}, Missing: []string{}, Differs: []string{}}
+				order = append(order, name)
+			}
+			all[name].Versions[names[i]] = s.Module.GetVersionOrRef()
+		}
+	}
+	sort.Strings(order)
+	for _, n := range order {
+		m := all[n]
+		counts := map[string]int{}
+		for _, input := range names {
+			v, ok := m.Versions[input]
+			if !ok {
+				m.Missing = append(m.Missing, input)
+				continue
+			}
+			counts[v]++
+			if counts[v] > m.MajorityCount {
+				m.Majority = v
+				m.MajorityCount = counts[v]
+			}
+		}
+		for _, input := range names {
+			if v, ok := m.Versions[input]; ok && v != m.Majority {
+				m.Differs = append(m.Differs, input)
+			}
+		}
+		if len(m.Missing) > 0 || len(m.Differs) > 0 {
+			result.Modules = append(result.Modules, *m)
+		}
+	}
+	return result
+}
+
+// Empty returns true if there are no differences between the Puppetfiles
+func (r ManyResult) Empty() bool {
+	return len(r.Modules) == 0
+}
+
+// Partial returns the number of modules that are missing from some Puppetfiles
+func (r ManyResult) Partial() int {
+	count := 0
+	for _, m := range r.Modules {
+		if len(m.Missing) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Differing returns the number of modules that have different versions
+func (r ManyResult) Differing() int {
+	count := 0
+	for _, m := range r.Modules {
+		if len(m.Differs) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ExclusiveFirst returns true if any module in the first Puppetfile is
+// missing from at least one of the other Puppetfiles
+func (r ManyResult) ExclusiveFirst() bool {
+	for _, m := range r.Modules {
+		if _, ok := m.Versions[r.Names[0]]; ok && len(m.Missing) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ExclusiveOthers returns true if any module is missing from the first
+// Puppetfile but found in at least one of the other Puppetfiles
+func (r ManyResult) ExclusiveOthers() bool {
+	for _, m := range r.Modules {
+		if _, ok := m.Versions[r.Names[0]]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// JSON returns the indented JSON encoding of the ManyResult
+func (r ManyResult) JSON() ([]byte, error) {
+	jr := ManyJSONResult{
+		SchemaVersion: SchemaVersion,
+		Inputs:        r.Names,
+		Identical:     r.Empty(),
+		Partial:       r.Partial(),
+		Differing:     r.Differing(),
+		Modules:       make([]ManyModule, 0, len(r.Modules)),
+	}
+	jr.Modules = append(jr.Modules, r.Modules...)
+	return json.MarshalIndent(jr, "", "  ")
+}
+
+// Text returns a human readable table of the ManyResult. Each Puppetfile
+// gets a numbered column, missing modules are shown as "-", the same as in
+// Markdown, and versions that differ from the majority are marked with "*".
+func (r ManyResult) Text() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Consolidated diff of %d Puppetfiles\n%s\n", len(r.Names), dashSep)
+	for i, n := range r.Names {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, n)
+	}
+	fmt.Fprintf(&b, "%s\n", dashSep)
+	if r.Empty() {
+		b.WriteString("No difference between Puppetfiles\n")
+		return b.String()
+	}
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	header := []string{"MODULE", "MAJORITY"}
+	for i := range r.Names {
+		header = append(header, fmt.Sprintf("[%d]", i+1))
+	}
+	fmt.Fprintf(w, "%s\n", strings.Join(header, "\t"))
+	for _, m := range r.Modules {
+		row := []string{m.Name, displayVersion(m.Majority)}
+		for _, n := range r.Names {
+			row = append(row, m.cell(n, missingMarker, "%s*"))
+		}
+		fmt.Fprintf(w, "%s\n", strings.Join(row, "\t"))
+	}
+	w.Flush()
+	fmt.Fprintf(&b, "%s\n%d modules missing from some Puppetfiles, %d modules with differing versions (marked with *)\n", dashSep, r.Partial(), r.Differing())
+	return b.String()
+}
+
+// Markdown returns the ManyResult as a Markdown table, with missing modules
+// shown as "-" and versions that differ from the majority in bold
+func (r ManyResult) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Puppetfile diff of %d Puppetfiles\n\n", len(r.Names))
+	if r.Empty() {
+		b.WriteString("No difference between Puppetfiles\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "**%d missing from some Puppetfiles, %d with differing versions**\n\n", r.Partial(), r.Differing())
+	header := []string{"Module", "Majority"}
+	for _, n := range r.Names {
+		header = append(header, fmt.Sprintf("`%s`", n))
+	}
+	fmt.Fprintf(&b, "| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat("---|", len(header)))
+	for _, m := range r.Modules {
+		row := []string{fmt.Sprintf("`%s`", m.Name), markdownEscape(displayVersion(m.Majority))}
+		for _, n := range r.Names {
+			row = append(row, markdownEscape(m.cell(n, missingMarker, "**%s**")))
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+	return b.String()
+}
+
+// cell returns the display value of the module's version in the named
+// Puppetfile. Versions that differ from the majority are formatted with
+// the differs format.
+func (m ManyModule) cell(name, missing, differs string) string {
+	v, ok := m.Versions[name]
+	if !ok {
+		return missing
+	}
+	if v != m.Majority {
+		return fmt.Sprintf(differs, displayVersion(v))
+	}
+	return displayVersion(v)
+}
+
+func displayVersion(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+func parseMany(t *testing.T, texts ...string) []*ast.Puppetfile {
+	var parsed []*ast.Puppetfile
+	for _, text := range texts {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed
+}
+
+func TestMany(t *testing.T) {
+	puppetfiles := parseMany(t,
+		"mod 'puppetlabs-stdlib', '6.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n",
+		"mod 'puppetlabs/stdlib', '7.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n",
+		"mod 'puppetlabs-stdlib', '6.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n\nmod 'extra', :latest\n",
+	)
+	r := Many([]string{"prod", "dev", "test"}, puppetfiles)
+	if len(r.Modules) != 2 {
+		t.Fatalf("Expected 2 differing modules, got: %+v", r.Modules)
+	}
+	extra, stdlib := r.Modules[0], r.Modules[1]
+	if extra.Name != "extra" || len(extra.Missing) != 2 || extra.Majority != ":latest" {
+		t.Errorf("Unexpected result for module extra: %+v", extra)
+	}
+	if stdlib.Name != "puppetlabs-stdlib" || stdlib.Majority != "6.0.0" || stdlib.MajorityCount != 2 {
+		t.Errorf("Unexpected majority for module puppetlabs-stdlib: %+v", stdlib)
+	}
+	if len(stdlib.Differs) != 1 || stdlib.Differs[0] != "dev" {
+		t.Errorf("Expected dev to differ for module puppetlabs-stdlib, got: %v", stdlib.Differs)
+	}
+	if r.Partial() != 1 || r.Differing() != 1 {
+		t.Errorf("Expected 1 partial and 1 differing module, got %d and %d", r.Partial(), r.Differing())
+	}
+	if r.ExclusiveFirst() || !r.ExclusiveOthers() {
+		t.Errorf("Expected only the other Puppetfiles to have exclusive modules")
+	}
+}
+
+func TestManyIdentical(t *testing.T) {
+	text := "mod 'puppetlabs-stdlib', '6.0.0'\n"
+	r := Many([]string{"one", "two", "three"}, parseMany(t, text, text, text))
+	if !r.Empty() {
+		t.Errorf("Expected no difference between identical Puppetfiles, got: %+v", r.Modules)
+	}
+}
+
+func TestManyMissingMarker(t *testing.T) {
+	r := Many([]string{"prod", "dev"}, parseMany(t,
+		"mod 'puppetlabs-stdlib', '6.0.0'\n\nmod 'extra', '1.0.0'\n",
+		"mod 'puppetlabs-stdlib', '6.0.0'\n",
+	))
+	var row string
+	for _, line := range strings.Split(r.Text(), "\n") {
+		if strings.HasPrefix(line, "extra") {
+			row = strings.Join(strings.Fields(line), " ")
+		}
+	}
+	if row != "extra 1.0.0 1.0.0 -" {
+		t.Errorf("Expected the missing module to be shown as - in text, got: %s", row)
+	}
+	if md := r.Markdown(); !strings.Contains(md, "| `extra` | 1.0.0 | 1.0.0 | - |") {
+		t.Errorf("Expected the missing module to be shown as - in Markdown, got:\n%s", md)
+	}
+}
@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+// MatrixResult is a module x environment table of module versions
+type MatrixResult struct {
+	Environments []string    `json:"environments"`
+	Modules      []MatrixRow `json:"modules"`
+}
+
+// MatrixRow holds the versions of a single module in each environment.
+// Environments that don't have the module are absent from Versions and
+// listed in Missing. A module drifts if it is missing from some
+// environments or has different versions in them.
+type MatrixRow struct {
+	Name     string            `json:"name"`
+	Versions map[string]string `json:"versions"`
+	Missing  []string          `json:"missing,omitempty"`
+	Drift    bool              `json:"drift"`
+}
+
+// Matrix returns the versions of every module in the Puppetfiles, which are
+// keyed by environment name. With driftOnly, only modules that drift are
+// included. Environments and modules are sorted by name.
+func Matrix(puppetfiles map[string]*ast.Puppetfile, driftOnly bool) MatrixResult {
+	matrix := MatrixResult{Environments: []string{}, Modules: []MatrixRow{}}
+	rows := map[string]*MatrixRow{}
+	for env, p := range puppetfiles {
+		matrix.Environments = append(matrix.Environments, env)
+		for _, s := range p.Statements {
+			if s.Module == nil {
+				continue
+			}
+			name := ast.NormalizeName(s.Module.Name)
+			if _, ok := rows[name]; !ok {
+				rows[name] = &MatrixRow{Name: name, Versions: map[string]string{}}
+			}
+			rows[name].Versions[env] = s.Module.GetVersionOrRef()
+		}
+	}
+	sort.Strings(matrix.Environments)
+	for _, r := range rows {
+		seen := map[string]bool{}
+		for _, env := range matrix.Environments {
+			v, ok := r.Versions[env]
+			if !ok {
+				r.Missing = append(r.Missing, env)
+				continue
+			}
+			seen[v] = true
+		}
+		r.Drift = len(r.Missing) > 0 || len(seen) > 1
+		if driftOnly && !r.Drift {
+			continue
+		}
+		matrix.Modules = append(matrix.Modules, *r)
+	}
+	sort.Slice(matrix.Modules, func(i, j int) bool { return matrix.Modules[i].Name < matrix.Modules[j].Name })
+	return matrix
+}
+
+// Drifting returns the number of modules in the MatrixResult that drift
+func (m MatrixResult) Drifting() int {
+	drift := 0
+	for _, r := range m.Modules {
+		if r.Drift {
+			drift++
+		}
+	}
+	return drift
+}
+
+// Text returns a human readable table of the MatrixResult, with modules
+// that drift marked with "!"
+func (m MatrixResult) Text() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  \tMODULE\t%s\n", strings.ToUpper(strings.Join(m.Environments, "\t")))
+	for _, r := range m.Modules {
+		marker := " "
+		if r.Drift {
+			marker = "!"
+		}
+		cells := []string{}
+		for _, env := range m.Environments {
+			cells = append(cells, r.cell(env))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", marker, r.Name, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	fmt.Fprintf(&buf, "%s\n%d modules, %d environments, %d modules with drift (marked with !)\n", dashSep, len(m.Modules), len(m.Environments), m.Drifting())
+	return buf.String()
+}
+
+// CSV returns the MatrixResult as CSV, with a column for each environment
+// and a drift column
+func (m MatrixResult) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := append([]string{"module"}, m.Environments...)
+	header = append(header, "drift")
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, r := range m.Modules {
+		record := []string{r.Name}
+		for _, env := range m.Environments {
+			record = append(record, r.Versions[env])
+		}
+		record = append(record, fmt.Sprintf("%t", r.Drift))
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// JSON returns the indented JSON encoding of the MatrixResult
+func (m MatrixResult) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Markdown returns the MatrixResult as a Markdown table, with modules that
+// drift in bold
+func (m MatrixResult) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| Module | %s |\n", strings.Join(m.Environments, " | "))
+	fmt.Fprintf(&b, "|---|%s\n", strings.Repeat("---|", len(m.Environments)))
+	for _, r := range m.Modules {
+		name := fmt.Sprintf("`%s`", r.Name)
+		if r.Drift {
+			name = fmt.Sprintf("**%s** :warning:", name)
+		}
+		cells := []string{}
+		for _, env := range m.Environments {
+			cells = append(cells, r.cell(env))
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", name, strings.Join(cells, " | "))
+	}
+	return b.String()
+}
+
+// cell returns the display value of the module's version in an environment
+func (r MatrixRow) cell(env string) string {
+	v, ok := r.Versions[env]
+	if !ok {
+		return missingMarker
+	}
+	return displayVersion(v)
+}
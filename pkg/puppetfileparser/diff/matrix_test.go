@@ -0,0 +1,89 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+func parseEnvs(t *testing.T, texts map[string]string) map[string]*ast.Puppetfile {
+	puppetfiles := map[string]*ast.Puppetfile{}
+	for env, text := range texts {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		puppetfiles[env] = p
+	}
+	return puppetfiles
+}
+
+func TestMatrix(t *testing.T) {
+	puppetfiles := parseEnvs(t, map[string]string{
+		"production":  "mod 'puppetlabs-stdlib', '6.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n",
+		"development": "mod 'puppetlabs/stdlib', '7.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n\nmod 'extra', :latest\n",
+		"test":        "mod 'puppetlabs-stdlib', '6.0.0'\n\nmod 'puppetlabs-concat', '4.0.0'\n",
+	})
+	m := Matrix(puppetfiles, false)
+	if strings.Join(m.Environments, ",") != "development,production,test" {
+		t.Errorf("Expected sorted environments, got %v", m.Environments)
+	}
+	var names []string
+	for _, r := range m.Modules {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "extra,puppetlabs-concat,puppetlabs-stdlib" {
+		t.Fatalf("Expected sorted, normalized modules, got %v", names)
+	}
+	extra, concat, stdlib := m.Modules[0], m.Modules[1], m.Modules[2]
+	if !extra.Drift || strings.Join(extra.Missing, ",") != "production,test" {
+		t.Errorf("Expected extra to drift and be missing from production and test, got %+v", extra)
+	}
+	if concat.Drift || len(concat.Missing) != 0 {
+		t.Errorf("Expected puppetlabs-concat not to drift, got %+v", concat)
+	}
+	if !stdlib.Drift || stdlib.Versions["development"] != "7.0.0" || stdlib.Versions["production"] != "6.0.0" {
+		t.Errorf("Expected puppetlabs-stdlib to drift with its versions per environment, got %+v", stdlib)
+	}
+	if m.Drifting() != 2 {
+		t.Errorf("Expected 2 drifting modules, got %d", m.Drifting())
+	}
+
+	driftOnly := Matrix(puppetfiles, true)
+	if len(driftOnly.Modules) != 2 || driftOnly.Modules[0].Name != "extra" || driftOnly.Modules[1].Name != "puppetlabs-stdlib" {
+		t.Errorf("Expected only drifting modules, got %+v", driftOnly.Modules)
+	}
+}
+
+func TestMatrixFormats(t *testing.T) {
+	m := Matrix(parseEnvs(t, map[string]string{
+		"production":  "mod 'puppetlabs-stdlib', '6.0.0'\n",
+		"development": "mod 'puppetlabs-stdlib', '7.0.0'\n\nmod 'extra', :latest\n",
+	}), false)
+	text := m.Text()
+	for _, expected := range []string{"DEVELOPMENT  PRODUCTION", "!   puppetlabs-stdlib  7.0.0        6.0.0", "2 modules, 2 environments, 2 modules with drift"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected text to contain %q, got:\n%s", expected, text)
+		}
+	}
+	csv, err := m.CSV()
+	if err != nil {
+		t.Fatalf("Failed to render CSV with error: %v", err)
+	}
+	if expected := "module,development,production,drift\nextra,:latest,,true\npuppetlabs-stdlib,7.0.0,6.0.0,true\n"; csv != expected {
+		t.Errorf("Expected CSV %q, got %q", expected, csv)
+	}
+	if md := m.Markdown(); !strings.Contains(md, "| **`extra`** :warning: | :latest | - |") {
+		t.Errorf("Expected Markdown to mark drift and missing modules, got:\n%s", md)
+	}
+	out, err := m.JSON()
+	if err != nil {
+		t.Fatalf("Failed to render JSON with error: %v", err)
+	}
+	if !strings.Contains(string(out), `"missing": [
+        "production"
+      ]`) {
+		t.Errorf("Expected JSON to list missing environments, got:\n%s", out)
+	}
+}
@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dashSep matches uitext.DashSep, the separator used throughout pufctl
+// output. It is kept here so the diff package has no internal imports.
+const dashSep = "-----------------------------------------------------------------"
+
+// Text returns a human readable representation of the Result. The names
+// are used to refer to the first and second Puppetfile.
+func (r Result) Text(nameOne, nameTwo string) string {
+	if r.Empty() {
+		return "No difference between Puppetfiles\n"
+	}
+	var b strings.Builder
+	if len(r.Removed) > 0 || len(r.Added) > 0 {
+		fmt.Fprintf(&b, "\nExclusive Modules\n%s\n", dashSep)
+		if len(r.Removed) > 0 {
+			fmt.Fprintf(&b, "Only found in %s\n%s\n", nameOne, dashSep)
+			for _, m := range r.Removed {
+				fmt.Fprintf(&b, "Module: %s\n", m.Name)
+			}
+		}
+		if len(r.Added) > 0 {
+			if len(r.Removed) > 0 {
+				fmt.Fprintf(&b, "%s\n", dashSep)
+			}
+			fmt.Fprintf(&b, "Only found in %s\n%s\n", nameTwo, dashSep)
+			for _, m := range r.Added {
+				fmt.Fprintf(&b, "Module: %s\n", m.Name)
+			}
+		}
+	}
+	if len(r.Changed) > 0 {
+		fmt.Fprintf(&b, "\nChanged Modules (%s -> %s)\n", nameOne, nameTwo)
+		for _, d := range r.Changed {
+			fmt.Fprintf(&b, "%s\nMODULE: %s\n%s\n", dashSep, d.Name, dashSep)
+			b.WriteString(d.Text())
+		}
+	}
+	fmt.Fprintf(&b, "%s\n", dashSep)
+	return b.String()
+}
+
+// Text returns a human readable representation of the ModuleDiff
+func (m ModuleDiff) Text() string {
+	var b strings.Builder
+	if m.Version != nil {
+		fmt.Fprintf(&b, "version: %s\n", m.Version.Text())
+	}
+	if m.Source != nil {
+		fmt.Fprintf(&b, "source: %s -> %s\n", m.Source.Old, m.Source.New)
+	}
+	for _, p := range m.Properties {
+		switch p.Type {
+		case Added:
+			fmt.Fprintf(&b, "  + %s => %s\n", p.Key, p.New)
+		case Removed:
+			fmt.Fprintf(&b, "  - %s => %s\n", p.Key, p.Old)
+		default:
+			fmt.Fprintf(&b, "  ~ %s => %s -> %s\n", p.Key, p.Old, p.New)
+		}
+	}
+	for _, c := range m.Meta {
+		switch c.Type {
+		case Added:
+			fmt.Fprintf(&b, "  + # @%s: %s\n", c.Tag, c.New)
+		case Removed:
+			fmt.Fprintf(&b, "  - # @%s: %s\n", c.Tag, c.Old)
+		default:
+			fmt.Fprintf(&b, "  ~ # @%s: %s -> %s\n", c.Tag, c.Old, c.New)
+		}
+	}
+	return b.String()
+}
+
+// Text returns a human readable representation of the VersionChange,
+// such as "1.0.0 -> 2.0.0 (major upgrade)"
+func (v VersionChange) Text() string {
+	oldVer := v.Old
+	if oldVer == "" {
+		oldVer = "(none)"
+	}
+	newVer := v.New
+	if newVer == "" {
+		newVer = "(none)"
+	}
+	if v.Level != "" {
+		return fmt.Sprintf("%s -> %s (%s %s)", oldVer, newVer, v.Level, v.Direction)
+	}
+	return fmt.Sprintf("%s -> %s (%s)", oldVer, newVer, v.Direction)
+}
@@ -0,0 +1,322 @@
+// Package merge performs three-way merges of parsed Puppetfiles module by module.
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+	"github.com/hsnodgrass/pufctl/pkg/semver"
+)
+
+// DefaultMarkerSize is the default length of conflict markers, matching Git
+const DefaultMarkerSize = 7
+
+// Options holds options used when merging Puppetfiles
+type Options struct {
+	// PreferHigher resolves modules whose version was changed on both sides
+	// by choosing the higher semantic version. Modules changed to the same
+	// version with different properties are still conflicts.
+	PreferHigher bool
+}
+
+// Conflict is a module that was changed differently on both sides of the
+// merge. A nil module means the module is absent on that side. The comments
+// are the comments directly above the module on each side, including its
+// meta tags.
+type Conflict struct {
+	Name           string
+	Base           *ast.Module
+	Ours           *ast.Module
+	Theirs         *ast.Module
+	BaseComments   []string
+	OursComments   []string
+	TheirsComments []string
+}
+
+// SectionConflict is a part of the Puppetfile outside of its modules that
+// was changed differently on both sides of the merge. Name is one of
+// "forge", "moduledir", "top comments", or "bottom comments", and each side
+// holds the text of the section, which is empty if it is absent.
+type SectionConflict struct {
+	Name   string
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// Result holds the merged Puppetfile and any conflicts. The merged Puppetfile
+// is based on ours, and conflicting modules and sections keep their ours
+// text.
+type Result struct {
+	Puppetfile       *ast.Puppetfile
+	Conflicts        []Conflict
+	SectionConflicts []SectionConflict
+	// Resolved holds the names of modules that were changed on both sides
+	// and resolved by choosing the higher version.
+	Resolved []string
+}
+
+// HasConflicts returns true if any module or section has a conflict
+func (r Result) HasConflicts() bool {
+	return len(r.Conflicts) > 0 || len(r.SectionConflicts) > 0
+}
+
+// Puppetfiles merges the changes made to base in ours and theirs. Each module
+// is merged on its own, together with the comments and meta tags above it:
+// if only one side changed a module, that change is taken, and if both sides
+// made the same change it is taken once. Modules changed differently on both
+// sides are conflicts, unless opts.PreferHigher is set and both sides have
+// semantic versions. The forge and moduledir directives, and the comments at
+// the top and bottom of the Puppetfile, are merged the same way as sections.
+// The ours Puppetfile is modified in place and returned as the merged
+// Puppetfile.
+func Puppetfiles(base, ours, theirs *ast.Puppetfile, opts Options) (Result, error) {
+	result := Result{Puppetfile: ours}
+	result.SectionConflicts = mergeSections(base, ours, theirs)
+	modsBase := modulesByName(base)
+	modsOurs := modulesByName(ours)
+	modsTheirs := modulesByName(theirs)
+	for _, name := range unionNames(modsBase, modsOurs, modsTheirs) {
+		b, o, t := modsBase[name], modsOurs[name], modsTheirs[name]
+		switch {
+		case same(o, t), same(b, t):
+			continue
+		case same(b, o):
+			if err := apply(ours, o, t); err != nil {
+				return result, err
+			}
+		case opts.PreferHigher && higher(o, t) != nil:
+			if higher(o, t) == t {
+				if err := apply(ours, o, t); err != nil {
+					return result, err
+				}
+			}
+			result.Resolved = append(result.Resolved, name)
+		default:
+			if o == nil {
+				// Add theirs so the conflict has a place in the merged Puppetfile
+				if err := apply(ours, o, t); err != nil {
+					return result, err
+				}
+			}
+			c := Conflict{Name: name}
+			c.Base, c.BaseComments = b.split()
+			c.Ours, c.OursComments = o.split()
+			c.Theirs, c.TheirsComments = t.split()
+			result.Conflicts = append(result.Conflicts, c)
+		}
+	}
+	return result, nil
+}
+
+// Sprint returns the merged Puppetfile with conflict markers around each
+// conflicting module and section, in the same style as Git. The labels are
+// shown after the ours and theirs markers. Conflicting sections that are
+// absent from ours are shown at the top of the Puppetfile.
+func (r Result) Sprint(markerSize int, oursLabel, theirsLabel string) string {
+	text := r.Puppetfile.Sprint()
+	for _, c := range r.Conflicts {
+		var block string
+		if c.Ours != nil {
+			block = moduleBlock(c.Ours, c.OursComments)
+		} else {
+			block = moduleBlock(c.Theirs, c.TheirsComments)
+		}
+		text = strings.Replace(text, block, c.Markers(markerSize, oursLabel, theirsLabel), 1)
+	}
+	for _, c := range r.SectionConflicts {
+		markers := c.Markers(markerSize, oursLabel, theirsLabel)
+		i := strings.Index(text, c.Ours)
+		if c.Name == "bottom comments" {
+			i = strings.LastIndex(text, c.Ours)
+		}
+		if c.Ours == "" || i < 0 {
+			text = markers + text
+			continue
+		}
+		text = text[:i] + strings.TrimSuffix(markers, "\n") + text[i+len(c.Ours):]
+	}
+	return text
+}
+
+// Markers returns the conflicting module and the comments above it
+// surrounded by conflict markers
+func (c Conflict) Markers(markerSize int, oursLabel, theirsLabel string) string {
+	var ours, theirs string
+	if c.Ours != nil {
+		ours = moduleBlock(c.Ours, c.OursComments)
+	}
+	if c.Theirs != nil {
+		theirs = moduleBlock(c.Theirs, c.TheirsComments)
+	}
+	return markers(markerSize, oursLabel, theirsLabel, ours, theirs)
+}
+
+// Markers returns the conflicting section surrounded by conflict markers
+func (c SectionConflict) Markers(markerSize int, oursLabel, theirsLabel string) string {
+	return markers(markerSize, oursLabel, theirsLabel, c.Ours, c.Theirs)
+}
+
+func markers(markerSize int, oursLabel, theirsLabel, ours, theirs string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", strings.Repeat("<", markerSize), oursLabel)
+	if ours != "" {
+		b.WriteString(strings.TrimSuffix(ours, "\n") + "\n")
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Repeat("=", markerSize))
+	if theirs != "" {
+		b.WriteString(strings.TrimSuffix(theirs, "\n") + "\n")
+	}
+	fmt.Fprintf(&b, "%s %s\n", strings.Repeat(">", markerSize), theirsLabel)
+	return b.String()
+}
+
+// moduleBlock returns a module and the comments above it as they are
+// printed in the Puppetfile
+func moduleBlock(m *ast.Module, comments []string) string {
+	return strings.Join(append(append([]string{}, comments...), m.Sprint()), "\n")
+}
+
+// mergeSections merges the forge and moduledir directives, and the comments
+// at the top and bottom of the Puppetfile, and returns the sections that
+// conflict. Sections only changed in theirs are copied to ours.
+func mergeSections(base, ours, theirs *ast.Puppetfile) []SectionConflict {
+	var conflicts []SectionConflict
+	sections := []struct {
+		name string
+		text func(*ast.Puppetfile) string
+		take func()
+	}{
+		{"forge", forgeText, func() { ours.Forge = theirs.Forge }},
+		{"moduledir", moduledirText, func() { ours.Moduledir = theirs.Moduledir }},
+		{"top comments", func(p *ast.Puppetfile) string { return commentsText(p.TopBlockComments) }, func() {
+			ours.TopBlockComments = append([]*ast.Statement{}, theirs.TopBlockComments...)
+		}},
+		{"bottom comments", func(p *ast.Puppetfile) string { return commentsText(p.BottomBlockComments) }, func() {
+			ours.BottomBlockComments = append([]*ast.Statement{}, theirs.BottomBlockComments...)
+		}},
+	}
+	for _, s := range sections {
+		b, o, t := s.text(base), s.text(ours), s.text(theirs)
+		switch {
+		case o == t, b == t:
+			continue
+		case b == o:
+			s.take()
+		default:
+			conflicts = append(conflicts, SectionConflict{Name: s.name, Base: b, Ours: o, Theirs: t})
+		}
+	}
+	return conflicts
+}
+
+func forgeText(p *ast.Puppetfile) string {
+	if p.Forge == nil {
+		return ""
+	}
+	return p.Forge.Sprint()
+}
+
+func moduledirText(p *ast.Puppetfile) string {
+	if p.Moduledir == nil {
+		return ""
+	}
+	return p.Moduledir.Sprint()
+}
+
+func commentsText(stmts []*ast.Statement) string {
+	var lines []string
+	for _, s := range stmts {
+		lines = append(lines, s.Sprint())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entry is a module and the comments directly above it
+type entry struct {
+	module   *ast.Module
+	comments []string
+}
+
+// split returns the module and comments of the entry, which may be nil
+func (e *entry) split() (*ast.Module, []string) {
+	if e == nil {
+		return nil, nil
+	}
+	return e.module, e.comments
+}
+
+// apply makes the ours module match the theirs module, adding, removing,
+// or replacing the module and the comments above it in the ours Puppetfile.
+func apply(ours *ast.Puppetfile, o, t *entry) error {
+	switch {
+	case t == nil:
+		return ours.RemoveModule(o.module.Name)
+	case o == nil:
+		stmt := ast.DummyStatement()
+		stmt.Module = t.module.Copy()
+		if err := ours.AddStatement(stmt); err != nil {
+			return err
+		}
+		return ours.ReplaceModuleComments(t.module.Name, t.comments)
+	default:
+		o.module.Properties = t.module.Copy().Properties
+		return ours.ReplaceModuleComments(o.module.Name, t.comments)
+	}
+}
+
+// same returns true if both modules are absent, or have the same properties
+// and comments
+func same(one, two *entry) bool {
+	if one == nil || two == nil {
+		return one == nil && two == nil
+	}
+	return len(diff.Properties(one.module, two.module)) == 0 && strings.Join(one.comments, "\n") == strings.Join(two.comments, "\n")
+}
+
+// higher returns the module with the higher semantic version, or nil if
+// either module is absent, doesn't have a semantic version, or both have the
+// same version. Modules with the same version differ in other properties,
+// such as their source, so neither side is chosen.
+func higher(one, two *entry) *entry {
+	if one == nil || two == nil {
+		return nil
+	}
+	c, err := semver.Compare(one.module.GetVersionOrRef(), two.module.GetVersionOrRef())
+	switch {
+	case err != nil, c == 0:
+		return nil
+	case c < 0:
+		return two
+	default:
+		return one
+	}
+}
+
+func modulesByName(p *ast.Puppetfile) map[string]*entry {
+	mods := map[string]*entry{}
+	for _, s := range p.Statements {
+		if s.Module != nil {
+			mods[ast.NormalizeName(s.Module.Name)] = &entry{module: s.Module, comments: p.GetModuleComments(s.Module.Name)}
+		}
+	}
+	return mods
+}
+
+func unionNames(mods ...map[string]*entry) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range mods {
+		for n := range m {
+			if !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
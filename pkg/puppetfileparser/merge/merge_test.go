@@ -0,0 +1,240 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+const basePuppetfile = `
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '4.0.0'
+
+mod 'puppetlabs-apache', '5.0.0'
+
+mod 'removed', :latest
+`
+
+const oursPuppetfile = `
+mod 'puppetlabs-stdlib', '6.0.0'
+
+mod 'puppetlabs-concat', '4.1.0'
+
+mod 'puppetlabs-apache', '6.0.0'
+`
+
+const theirsPuppetfile = `
+mod 'puppetlabs/stdlib', '6.1.0'
+
+mod 'puppetlabs-concat', '4.1.0'
+
+mod 'puppetlabs-apache', '5.2.0'
+
+mod 'removed', :latest
+
+mod 'added', '1.0.0'
+`
+
+func parseMergeTest(t *testing.T) (*ast.Puppetfile, *ast.Puppetfile, *ast.Puppetfile) {
+	var parsed []*ast.Puppetfile
+	for _, text := range []string{basePuppetfile, oursPuppetfile, theirsPuppetfile} {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed[0], parsed[1], parsed[2]
+}
+
+func moduleVersion(p *ast.Puppetfile, name string) string {
+	for _, s := range p.Statements {
+		if s.Module != nil && s.Module.Name == name {
+			return s.Module.GetVersionOrRef()
+		}
+	}
+	return ""
+}
+
+func TestPuppetfiles(t *testing.T) {
+	base, ours, theirs := parseMergeTest(t)
+	r, err := Puppetfiles(base, ours, theirs, Options{})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if len(r.Conflicts) != 1 || r.Conflicts[0].Name != "puppetlabs-apache" {
+		t.Fatalf("Expected a single conflict in puppetlabs-apache, got: %+v", r.Conflicts)
+	}
+	if v := moduleVersion(r.Puppetfile, "puppetlabs-stdlib"); v != "6.1.0" {
+		t.Errorf("Expected theirs version of puppetlabs-stdlib, got: %s", v)
+	}
+	if v := moduleVersion(r.Puppetfile, "puppetlabs-concat"); v != "4.1.0" {
+		t.Errorf("Expected identical change to puppetlabs-concat, got: %s", v)
+	}
+	if v := moduleVersion(r.Puppetfile, "added"); v != "1.0.0" {
+		t.Errorf("Expected module added in theirs, got: %s", v)
+	}
+	if ok, _ := r.Puppetfile.HasModule("removed"); ok {
+		t.Errorf("Expected module removed in ours to stay removed")
+	}
+	out := r.Sprint(DefaultMarkerSize, "ours", "theirs")
+	expected := "<<<<<<< ours\nmod 'puppetlabs-apache',\n  '6.0.0'\n=======\nmod 'puppetlabs-apache',\n  '5.2.0'\n>>>>>>> theirs\n"
+	if !strings.Contains(out, expected) {
+		t.Errorf("Expected conflict markers in merged Puppetfile, got:\n%s", out)
+	}
+}
+
+func TestPuppetfilesPreferHigher(t *testing.T) {
+	base, ours, theirs := parseMergeTest(t)
+	r, err := Puppetfiles(base, ours, theirs, Options{PreferHigher: true})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if len(r.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got: %+v", r.Conflicts)
+	}
+	if len(r.Resolved) != 1 || r.Resolved[0] != "puppetlabs-apache" {
+		t.Errorf("Expected puppetlabs-apache to be resolved, got: %v", r.Resolved)
+	}
+	if v := moduleVersion(r.Puppetfile, "puppetlabs-apache"); v != "6.0.0" {
+		t.Errorf("Expected the higher version of puppetlabs-apache, got: %s", v)
+	}
+	if strings.Contains(r.Sprint(DefaultMarkerSize, "ours", "theirs"), "<<<<<<<") {
+		t.Errorf("Expected no conflict markers in merged Puppetfile")
+	}
+}
+
+func TestPuppetfilesDeleteModifyConflict(t *testing.T) {
+	base, ours, theirs := parseMergeTest(t)
+	if err := theirs.RemoveModule("puppetlabs-concat"); err != nil {
+		t.Fatalf("Failed to remove module with error: %v", err)
+	}
+	r, err := Puppetfiles(base, ours, theirs, Options{PreferHigher: true})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if len(r.Conflicts) != 1 || r.Conflicts[0].Name != "puppetlabs-concat" || r.Conflicts[0].Theirs != nil {
+		t.Fatalf("Expected a delete / modify conflict in puppetlabs-concat, got: %+v", r.Conflicts)
+	}
+	expected := "<<<<<<< ours\nmod 'puppetlabs-concat',\n  '4.1.0'\n=======\n>>>>>>> theirs\n"
+	if !strings.Contains(r.Sprint(DefaultMarkerSize, "ours", "theirs"), expected) {
+		t.Errorf("Expected conflict markers with an empty theirs side")
+	}
+}
+
+func TestPuppetfilesPreferHigherEqualVersions(t *testing.T) {
+	parse := func(text string) *ast.Puppetfile {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		return p
+	}
+	base := parse("mod 'puppetlabs-apache',\n  :git => 'https://github.com/puppetlabs/puppetlabs-apache.git',\n  :tag => '5.0.0'\n")
+	ours := parse("mod 'puppetlabs-apache',\n  :git => 'https://github.com/puppetlabs/puppetlabs-apache.git',\n  :tag => '6.0.0'\n")
+	theirs := parse("mod 'puppetlabs-apache',\n  :git => 'https://github.com/fakeorg/puppetlabs-apache.git',\n  :tag => '6.0.0'\n")
+	r, err := Puppetfiles(base, ours, theirs, Options{PreferHigher: true})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if len(r.Resolved) != 0 {
+		t.Errorf("Expected modules with the same version not to be resolved, got: %v", r.Resolved)
+	}
+	if len(r.Conflicts) != 1 || r.Conflicts[0].Name != "puppetlabs-apache" {
+		t.Fatalf("Expected a conflict in puppetlabs-apache, got: %+v", r.Conflicts)
+	}
+	if out := r.Sprint(DefaultMarkerSize, "ours", "theirs"); !strings.Contains(out, "https://github.com/fakeorg/puppetlabs-apache.git") {
+		t.Errorf("Expected theirs source in the conflict markers, got:\n%s", out)
+	}
+}
+
+func TestPuppetfilesMetaTags(t *testing.T) {
+	parse := func(text string) *ast.Puppetfile {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		return p
+	}
+	base := parse("\n# @owner: web\nmod 'puppetlabs-apache', '5.0.0'\n\n# @owner: platform\nmod 'puppetlabs-stdlib', '6.0.0'\n")
+	ours := parse("\n# @owner: web\nmod 'puppetlabs-apache', '5.1.0'\n\n# @owner: platform\nmod 'puppetlabs-stdlib', '6.0.0'\n")
+	theirs := parse("\n# @owner: ops\nmod 'puppetlabs-apache', '5.2.0'\n\n# A comment that isn't a meta tag\n# @owner: core\nmod 'puppetlabs-stdlib', '6.0.0'\n\n# @platform: core\nmod 'puppetlabs-concat', '6.0.0'\n")
+	r, err := Puppetfiles(base, ours, theirs, Options{})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if meta := r.Puppetfile.GetModuleMetadata("puppetlabs-stdlib"); len(meta.MetaPairs) != 1 || meta.MetaPairs[0].Data != "core" {
+		t.Errorf("Expected the meta tag changed in theirs to be merged, got %+v", meta)
+	}
+	if meta := r.Puppetfile.GetModuleMetadata("puppetlabs-concat"); len(meta.SearchByTag("platform")) != 1 {
+		t.Errorf("Expected the module added in theirs to keep its meta tag, got %+v", meta)
+	}
+	if len(r.Conflicts) != 1 || r.Conflicts[0].Name != "puppetlabs-apache" {
+		t.Fatalf("Expected a conflict in puppetlabs-apache, got: %+v", r.Conflicts)
+	}
+	out := r.Sprint(DefaultMarkerSize, "ours", "theirs")
+	expected := "<<<<<<< ours\n# @owner: web\nmod 'puppetlabs-apache',\n  '5.1.0'\n=======\n# @owner: ops\nmod 'puppetlabs-apache',\n  '5.2.0'\n>>>>>>> theirs\n"
+	if !strings.Contains(out, expected) {
+		t.Errorf("Expected conflict markers around the module and its meta tags, got:\n%s", out)
+	}
+	if !strings.Contains(out, "# A comment that isn't a meta tag\n# @owner: core\nmod 'puppetlabs-stdlib'") {
+		t.Errorf("Expected the comments above puppetlabs-stdlib to be merged, got:\n%s", out)
+	}
+
+	// A change to only the meta tags on both sides is a conflict too
+	ours = parse("\n# @owner: web\nmod 'puppetlabs-apache', '5.0.0'\n")
+	theirs = parse("\n# @owner: ops\nmod 'puppetlabs-apache', '5.0.0'\n")
+	r, err = Puppetfiles(parse("\nmod 'puppetlabs-apache', '5.0.0'\n"), ours, theirs, Options{PreferHigher: true})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if len(r.Conflicts) != 1 || !r.HasConflicts() {
+		t.Errorf("Expected meta tags changed on both sides to conflict, got: %+v", r.Conflicts)
+	}
+}
+
+func TestPuppetfilesSections(t *testing.T) {
+	parse := func(text string) *ast.Puppetfile {
+		p, err := ast.Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse Puppetfile with error: %v", err)
+		}
+		return p
+	}
+	base := parse("forge 'https://forge.puppet.com'\nmoduledir 'modules'\n\nmod 'puppetlabs-stdlib', '6.0.0'\n")
+	ours := parse("forge 'https://forge.puppet.com'\nmoduledir 'site'\n\nmod 'puppetlabs-stdlib', '6.0.0'\n")
+	theirs := parse("forge 'https://forge.example.com'\nmoduledir 'external'\n\nmod 'puppetlabs-stdlib', '6.0.0'\n\n# Managed by the platform team\n")
+	r, err := Puppetfiles(base, ours, theirs, Options{})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if r.Puppetfile.Forge == nil || r.Puppetfile.Forge.URL != "https://forge.example.com" {
+		t.Errorf("Expected the forge directive changed in theirs to be merged, got %+v", r.Puppetfile.Forge)
+	}
+	if len(r.Conflicts) != 0 {
+		t.Errorf("Expected no module conflicts, got: %+v", r.Conflicts)
+	}
+	if len(r.SectionConflicts) != 1 || r.SectionConflicts[0].Name != "moduledir" || !r.HasConflicts() {
+		t.Fatalf("Expected a conflict in the moduledir directive, got: %+v", r.SectionConflicts)
+	}
+	out := r.Sprint(DefaultMarkerSize, "ours", "theirs")
+	expected := "<<<<<<< ours\nmoduledir 'site'\n=======\nmoduledir 'external'\n>>>>>>> theirs\n"
+	if !strings.Contains(out, expected) {
+		t.Errorf("Expected conflict markers around the moduledir directive, got:\n%s", out)
+	}
+	if !strings.Contains(out, "# Managed by the platform team") {
+		t.Errorf("Expected the comments added to the bottom in theirs to be merged, got:\n%s", out)
+	}
+
+	// A section removed in ours and changed in theirs is shown at the top
+	base = parse("forge 'https://forge.puppet.com'\n\nmod 'puppetlabs-stdlib', '6.0.0'\n")
+	r, err = Puppetfiles(base, parse("mod 'puppetlabs-stdlib', '6.0.0'\n"), parse("forge 'https://forge.example.com'\n\nmod 'puppetlabs-stdlib', '6.0.0'\n"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to merge Puppetfiles with error: %v", err)
+	}
+	if out := r.Sprint(DefaultMarkerSize, "ours", "theirs"); !strings.HasPrefix(out, "<<<<<<< ours\n=======\nforge 'https://forge.example.com'\n>>>>>>> theirs\n") {
+		t.Errorf("Expected conflict markers for the removed forge directive at the top, got:\n%s", out)
+	}
+}
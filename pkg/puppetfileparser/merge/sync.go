@@ -0,0 +1,133 @@
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/diff"
+)
+
+// SyncOptions selects the modules synced by Sync
+type SyncOptions struct {
+	// Modules are the names of modules to sync
+	Modules []string
+	// Tag syncs the modules with the meta tag in the reference Puppetfile.
+	// It may be given with or without its leading @.
+	Tag string
+	// All syncs every module in the reference Puppetfile
+	All bool
+	// Prune removes selected modules that are absent from the reference
+	// Puppetfile. With All, every module absent from the reference is
+	// selected, and with Tag, the modules with the meta tag in the target.
+	Prune bool
+}
+
+// SyncedModule is a module that was added, updated, or removed by Sync
+type SyncedModule struct {
+	Name   string
+	Change string
+	Old    string
+	New    string
+}
+
+// SyncResult holds the modules changed by Sync, and the selected modules
+// that were left as they are
+type SyncResult struct {
+	Synced []SyncedModule
+	// NotFound holds modules that are in neither Puppetfile
+	NotFound []string
+	// NotPruned holds modules that are absent from the reference, but
+	// weren't removed because Prune isn't set
+	NotPruned []string
+}
+
+// Sync makes the selected modules of the target Puppetfile match the
+// reference Puppetfile, including their meta tags. The target Puppetfile
+// is modified in place. Modules are synced in order of their normalized
+// names.
+func Sync(reference, target *ast.Puppetfile, opts SyncOptions) (SyncResult, error) {
+	var result SyncResult
+	tag := strings.TrimPrefix(opts.Tag, "@")
+	selected := map[string]bool{}
+	for _, m := range opts.Modules {
+		selected[ast.NormalizeName(m)] = true
+	}
+	if tag != "" {
+		for _, m := range reference.SearchModulesByMetaTag(tag) {
+			selected[ast.NormalizeName(m)] = true
+		}
+	}
+	if opts.All {
+		for _, s := range reference.Statements {
+			if s.Module != nil {
+				selected[ast.NormalizeName(s.Module.Name)] = true
+			}
+		}
+	}
+	if opts.Prune {
+		var candidates []string
+		switch {
+		case opts.All:
+			for _, s := range target.Statements {
+				if s.Module != nil {
+					candidates = append(candidates, s.Module.Name)
+				}
+			}
+		case tag != "":
+			candidates = target.SearchModulesByMetaTag(tag)
+		}
+		for _, m := range candidates {
+			if reference.GetModule(m) == nil {
+				selected[ast.NormalizeName(m)] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(selected))
+	for n := range selected {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		ref := reference.GetModule(n)
+		tgt := target.GetModule(n)
+		switch {
+		case ref == nil && tgt == nil:
+			result.NotFound = append(result.NotFound, n)
+		case ref == nil && !opts.Prune:
+			result.NotPruned = append(result.NotPruned, n)
+		case ref == nil:
+			old := tgt.GetVersionOrRef()
+			if err := target.RemoveModule(tgt.Name); err != nil {
+				return result, fmt.Errorf("Failed to remove module %s: %w", n, err)
+			}
+			result.Synced = append(result.Synced, SyncedModule{Name: n, Change: "removed", Old: old})
+		case tgt == nil:
+			stmt := ast.DummyStatement()
+			stmt.Module = ref.Copy()
+			if err := target.AddStatement(stmt); err != nil {
+				return result, fmt.Errorf("Failed to add module %s: %w", n, err)
+			}
+			if err := target.ReplaceModuleMetadata(ref.Name, reference.GetModuleMetadata(ref.Name)); err != nil {
+				return result, fmt.Errorf("Failed to copy meta tags of module %s: %w", n, err)
+			}
+			result.Synced = append(result.Synced, SyncedModule{Name: n, Change: "added", New: ref.GetVersionOrRef()})
+		default:
+			propsChanged := len(diff.Properties(tgt, ref)) > 0
+			metaChanged := len(diff.Meta(target.GetModuleMetadata(tgt.Name), reference.GetModuleMetadata(ref.Name))) > 0
+			if !propsChanged && !metaChanged {
+				continue
+			}
+			old := tgt.GetVersionOrRef()
+			tgt.Properties = ref.Copy().Properties
+			if metaChanged {
+				if err := target.ReplaceModuleMetadata(tgt.Name, reference.GetModuleMetadata(ref.Name)); err != nil {
+					return result, fmt.Errorf("Failed to copy meta tags of module %s: %w", n, err)
+				}
+			}
+			result.Synced = append(result.Synced, SyncedModule{Name: n, Change: "updated", Old: old, New: ref.GetVersionOrRef()})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package merge
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hsnodgrass/pufctl/pkg/puppetfileparser/ast"
+)
+
+const syncReference = `
+# @platform: core
+mod 'puppetlabs-stdlib', '7.0.0'
+
+# @platform: core
+mod 'puppetlabs-concat', '6.0.0'
+
+mod 'puppetlabs-apache', '6.0.0'
+`
+
+const syncTarget = `
+# @platform: core
+mod 'puppetlabs-stdlib', '6.0.0'
+
+# @platform: core
+mod 'puppetlabs-ntp', '8.0.0'
+
+# @owner: web
+mod 'puppetlabs-apache', '5.0.0'
+
+mod 'fakeorg-profile', :latest
+`
+
+func parseSyncTest(t *testing.T) (*ast.Puppetfile, *ast.Puppetfile) {
+	reference, err := ast.Parse(syncReference)
+	if err != nil {
+		t.Fatalf("Failed to parse reference Puppetfile with error: %v", err)
+	}
+	target, err := ast.Parse(syncTarget)
+	if err != nil {
+		t.Fatalf("Failed to parse target Puppetfile with error: %v", err)
+	}
+	return reference, target
+}
+
+func TestSyncTagPrune(t *testing.T) {
+	reference, target := parseSyncTest(t)
+	result, err := Sync(reference, target, SyncOptions{Tag: "@platform", Prune: true})
+	if err != nil {
+		t.Fatalf("Failed to sync with error: %v", err)
+	}
+	expected := []SyncedModule{
+		{Name: "puppetlabs-concat", Change: "added", New: "6.0.0"},
+		{Name: "puppetlabs-ntp", Change: "removed", Old: "8.0.0"},
+		{Name: "puppetlabs-stdlib", Change: "updated", Old: "6.0.0", New: "7.0.0"},
+	}
+	if fmt.Sprint(result.Synced) != fmt.Sprint(expected) {
+		t.Errorf("Expected synced modules %v, got %v", expected, result.Synced)
+	}
+	// Modules without the tag are left alone, even if they aren't in the
+	// reference
+	if m := target.GetModule("fakeorg-profile"); m == nil {
+		t.Errorf("Expected untagged fakeorg-profile, which isn't in the reference, to be kept")
+	}
+	if m := target.GetModule("puppetlabs-apache"); m == nil || m.GetVersionOrRef() != "5.0.0" {
+		t.Errorf("Expected untagged puppetlabs-apache to be unchanged, got %v", m)
+	}
+	if meta := target.GetModuleMetadata("puppetlabs-concat"); len(meta.SearchByTag("platform")) != 1 {
+		t.Errorf("Expected the meta tags of added puppetlabs-concat to be copied, got %+v", meta)
+	}
+}
+
+func TestSyncAllPrune(t *testing.T) {
+	reference, target := parseSyncTest(t)
+	result, err := Sync(reference, target, SyncOptions{All: true, Prune: true})
+	if err != nil {
+		t.Fatalf("Failed to sync with error: %v", err)
+	}
+	if len(result.Synced) != 5 {
+		t.Errorf("Expected 5 synced modules, got %v", result.Synced)
+	}
+	for _, name := range []string{"fakeorg-profile", "puppetlabs-ntp"} {
+		if target.GetModule(name) != nil {
+			t.Errorf("Expected %s, which isn't in the reference, to be removed", name)
+		}
+	}
+	apache := target.GetModuleMetadata("puppetlabs-apache")
+	if len(apache.SearchByTag("owner")) != 0 {
+		t.Errorf("Expected the meta tags of puppetlabs-apache to match the reference, got %+v", apache)
+	}
+	if target.Sprint() != reference.Sprint() {
+		t.Errorf("Expected the target to match the reference, got:\n%s", target.Sprint())
+	}
+}
+
+func TestSyncModulesNoPrune(t *testing.T) {
+	reference, target := parseSyncTest(t)
+	result, err := Sync(reference, target, SyncOptions{Modules: []string{"puppetlabs/apache", "puppetlabs-ntp", "missing-module"}})
+	if err != nil {
+		t.Fatalf("Failed to sync with error: %v", err)
+	}
+	if len(result.Synced) != 1 || result.Synced[0].Name != "puppetlabs-apache" {
+		t.Errorf("Expected only puppetlabs-apache to be synced, got %v", result.Synced)
+	}
+	if fmt.Sprint(result.NotFound) != "[missing-module]" {
+		t.Errorf("Expected missing-module not to be found, got %v", result.NotFound)
+	}
+	if fmt.Sprint(result.NotPruned) != "[puppetlabs-ntp]" {
+		t.Errorf("Expected puppetlabs-ntp not to be pruned, got %v", result.NotPruned)
+	}
+	if target.GetModule("puppetlabs-ntp") == nil {
+		t.Errorf("Expected puppetlabs-ntp to be kept without Prune")
+	}
+}
+
+func TestSyncMetaTags(t *testing.T) {
+	reference, target := parseSyncTest(t)
+	result, err := Sync(reference, target, SyncOptions{Modules: []string{"puppetlabs/concat", "puppetlabs-apache"}})
+	if err != nil {
+		t.Fatalf("Failed to sync with error: %v", err)
+	}
+	expected := []SyncedModule{
+		{Name: "puppetlabs-apache", Change: "updated", Old: "5.0.0", New: "6.0.0"},
+		{Name: "puppetlabs-concat", Change: "added", New: "6.0.0"},
+	}
+	if fmt.Sprint(result.Synced) != fmt.Sprint(expected) {
+		t.Errorf("Expected synced modules %v, got %v", expected, result.Synced)
+	}
+	if meta := target.GetModuleMetadata("puppetlabs-concat"); len(meta.SearchByTag("platform")) != 1 {
+		t.Errorf("Expected the added module to keep its @platform meta tag, got %+v", meta)
+	}
+	if meta := target.GetModuleMetadata("puppetlabs-apache"); len(meta.MetaPairs) != 0 {
+		t.Errorf("Expected the updated module's meta tags to match the reference, got %+v", meta)
+	}
+}
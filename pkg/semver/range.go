@@ -0,0 +1,245 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Range is a version range as used in the version_requirement of a
+// metadata.json dependency. A Range is a set of alternatives separated
+// by "||", each of which is a set of comparators a version has to satisfy.
+type Range struct {
+	alternatives [][]comparator
+	raw          string
+}
+
+type comparator struct {
+	op string
+	v  SemVer
+}
+
+func (c comparator) satisfiedBy(v SemVer) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// ParseRange parses a version range. Comparators are one of =, >, >=, <,
+// or <= followed by a version, for example ">= 1.0.0 < 2.0.0". Versions
+// may also be partial or use x wildcards, as in "1.x" or "1.2", and may be
+// prefixed with ~ to allow patch updates or ^ to allow minor updates.
+// Hyphen ranges such as "1.0.0 - 1.4.0" are inclusive. An empty range, or
+// "*", matches any version.
+func ParseRange(input string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(input)}
+	for _, alt := range strings.Split(input, "||") {
+		comps, err := parseAlternative(alt)
+		if err != nil {
+			return Range{}, fmt.Errorf("Could not parse input string %s into a version range: %w", input, err)
+		}
+		r.alternatives = append(r.alternatives, comps)
+	}
+	return r, nil
+}
+
+func parseAlternative(input string) ([]comparator, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 3 && fields[1] == "-" {
+		low, _, err := partial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		high, parts, err := partial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if parts < 3 {
+			return []comparator{{">=", low}, {"<", bumpPartial(high, parts)}}, nil
+		}
+		return []comparator{{">=", low}, {"<=", high}}, nil
+	}
+	// Join operators separated from their version by whitespace
+	var terms []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Trim(f, "<>=~^") == "" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		terms = append(terms, f)
+	}
+	var comps []comparator
+	for _, t := range terms {
+		c, err := parseTerm(t)
+		if err != nil {
+			return nil, err
+		}
+		comps = append(comps, c...)
+	}
+	return comps, nil
+}
+
+func parseTerm(term string) ([]comparator, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+	v, parts, err := partial(strings.TrimPrefix(term, op))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parts == 0:
+		if op == "<" || op == ">" {
+			return nil, fmt.Errorf("%s matches no versions", term)
+		}
+		return nil, nil
+	case op == "~":
+		if parts == 1 {
+			return []comparator{{">=", v}, {"<", bumpPartial(v, 1)}}, nil
+		}
+		return []comparator{{">=", v}, {"<", bumpPartial(v, 2)}}, nil
+	case op == "^":
+		upper := bumpPartial(v, 1)
+		if v.Major == 0 && parts > 1 {
+			upper = bumpPartial(v, 2)
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case parts == 3 && op == "":
+		return []comparator{{"=", v}}, nil
+	case parts == 3:
+		return []comparator{{op, v}}, nil
+	}
+	// Partial versions match every version they are a prefix of
+	switch op {
+	case ">":
+		return []comparator{{">=", bumpPartial(v, parts)}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		return []comparator{{"<", bumpPartial(v, parts)}}, nil
+	default:
+		return []comparator{{">=", v}, {"<", bumpPartial(v, parts)}}, nil
+	}
+}
+
+// partial parses a full or partial version, returning the number of
+// version parts that were given. Wildcard parts end the version.
+func partial(input string) (SemVer, int, error) {
+	if sv, err := Make(input); err == nil {
+		return sv, 3, nil
+	}
+	input = strings.TrimPrefix(strings.TrimPrefix(input, "v"), "V")
+	var nums []int
+	for _, p := range strings.Split(input, ".") {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || len(nums) == 3 {
+			return SemVer{}, 0, fmt.Errorf("%s is not a valid version", input)
+		}
+		nums = append(nums, n)
+	}
+	var sv SemVer
+	for i, n := range nums {
+		switch i {
+		case 0:
+			sv.Major = n
+		case 1:
+			sv.Minor = n
+		case 2:
+			sv.Patch = n
+		}
+	}
+	return sv, len(nums), nil
+}
+
+func bumpPartial(v SemVer, parts int) SemVer {
+	switch parts {
+	case 1:
+		v.BumpMajor()
+	case 2:
+		v.BumpMinor()
+	default:
+		v.BumpPatch()
+	}
+	return v
+}
+
+// Contains returns true if the version satisfies the range
+func (r Range) Contains(v SemVer) bool {
+	for _, alt := range r.alternatives {
+		ok := true
+		for _, c := range alt {
+			ok = ok && c.satisfiedBy(v)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Lowest returns the lowest version that satisfies the range, if the
+// range has a lower bound. Exclusive lower bounds are bumped to the next
+// patch version.
+func (r Range) Lowest() (SemVer, bool) {
+	var lowest SemVer
+	found := false
+	for _, alt := range r.alternatives {
+		var low SemVer
+		bounded := false
+		for _, c := range alt {
+			var v SemVer
+			switch c.op {
+			case ">=", "=":
+				v = c.v
+			case ">":
+				v = bumpPartial(c.v, 3)
+			default:
+				continue
+			}
+			if !bounded || v.Compare(low) > 0 {
+				low = v
+			}
+			bounded = true
+		}
+		if !bounded || !r.Contains(low) {
+			continue
+		}
+		if !found || low.Compare(lowest) < 0 {
+			lowest = low
+		}
+		found = true
+	}
+	return lowest, found
+}
+
+func (r Range) String() string {
+	return r.raw
+}
+
+// MajorRange returns a range that allows all versions from v up to, but not
+// including, the next major version, for example ">= 1.2.3 < 2.0.0"
+func MajorRange(v SemVer) string {
+	next := v
+	next.BumpMajor()
+	return fmt.Sprintf(">= %s < %s", v.String(), next.String())
+}
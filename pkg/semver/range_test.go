@@ -0,0 +1,72 @@
+package semver
+
+import "testing"
+
+func TestRangeContains(t *testing.T) {
+	cases := map[string]map[string]bool{
+		">= 1.0.0 < 2.0.0": {"1.0.0": true, "1.9.9": true, "2.0.0": false, "0.9.0": false},
+		">=4.13.1 <7.0.0":  {"4.13.1": true, "6.5.0": true, "4.13.0": false, "7.0.0": false},
+		"1.x":              {"1.0.0": true, "1.5.2": true, "2.0.0": false},
+		"1.2.x":            {"1.2.9": true, "1.3.0": false},
+		"1.2.3":            {"1.2.3": true, "1.2.4": false},
+		"~1.2":             {"1.2.0": true, "1.2.9": true, "1.3.0": false},
+		"^1.2.3":           {"1.9.0": true, "2.0.0": false, "1.2.2": false},
+		"1.0.0 - 1.4":      {"1.4.9": true, "1.5.0": false},
+		"> 1.2":            {"1.2.9": false, "1.3.0": true},
+		"<= 1.2":           {"1.2.9": true, "1.3.0": false},
+		"1.x || >= 3.0.0":  {"1.1.0": true, "2.0.0": false, "3.1.0": true},
+		"":                 {"0.0.1": true, "9.0.0": true},
+	}
+	for input, versions := range cases {
+		r, err := ParseRange(input)
+		if err != nil {
+			t.Errorf("Failed to parse range %s with error: %v", input, err)
+			continue
+		}
+		for v, expected := range versions {
+			sv, err := Make(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Contains(sv) != expected {
+				t.Errorf("Expected range %s to contain %s to be %t", input, v, expected)
+			}
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, input := range []string{"foo", ">= 1.a", "1.2.3.4", "< x"} {
+		if _, err := ParseRange(input); err == nil {
+			t.Errorf("Expected range %s to fail to parse", input)
+		}
+	}
+}
+
+func TestRangeLowest(t *testing.T) {
+	cases := map[string]string{
+		">= 1.0.0 < 2.0.0": "1.0.0",
+		"> 1.2.3":          "1.2.4",
+		"2.x || 1.x":       "1.0.0",
+		"~4.6":             "4.6.0",
+	}
+	for input, expected := range cases {
+		r, err := ParseRange(input)
+		if err != nil {
+			t.Fatalf("Failed to parse range %s with error: %v", input, err)
+		}
+		if v, ok := r.Lowest(); !ok || v.String() != expected {
+			t.Errorf("Expected the lowest version of %s to be %s, got %s", input, expected, v)
+		}
+	}
+	r, _ := ParseRange("< 2.0.0")
+	if _, ok := r.Lowest(); ok {
+		t.Errorf("Expected a range without a lower bound to have no lowest version")
+	}
+}
+
+func TestMajorRange(t *testing.T) {
+	if r := MajorRange(SemVer{Major: 6, Minor: 2}); r != ">= 6.2.0 < 7.0.0" {
+		t.Errorf("Expected >= 6.2.0 < 7.0.0, got %s", r)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // Pattern is a string representation of the regular expression to capture SemVers
@@ -101,6 +102,85 @@ func (v *SemVer) BumpPatch() {
 	v.BuildMetadata = ""
 }
 
+// Compare compares the precedence of the SemVer to another SemVer as
+// defined by the Semantic Versioning 2.0.0 spec. It returns -1 if v
+// is lower than o, 0 if they are equal, and 1 if v is higher than o.
+// BuildMetadata is ignored when comparing.
+func (v SemVer) Compare(o SemVer) int {
+	if c := compareInts(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease, o.PreRelease)
+}
+
+// Compare parses two semantic version strings and compares them.
+// See SemVer.Compare for the return values.
+func Compare(one, two string) (int, error) {
+	svOne, err := Make(one)
+	if err != nil {
+		return 0, err
+	}
+	svTwo, err := Make(two)
+	if err != nil {
+		return 0, err
+	}
+	return svOne.Compare(svTwo), nil
+}
+
+func compareInts(one, two int) int {
+	switch {
+	case one < two:
+		return -1
+	case one > two:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePreRelease compares prerelease versions. A version without a
+// prerelease has higher precedence than one with a prerelease. Otherwise,
+// dot separated identifiers are compared from left to right. Numeric
+// identifiers are compared numerically and have lower precedence than
+// alphanumeric identifiers, which are compared lexically.
+func comparePreRelease(one, two string) int {
+	switch {
+	case one == two:
+		return 0
+	case one == "":
+		return 1
+	case two == "":
+		return -1
+	}
+	idsOne := strings.Split(one, ".")
+	idsTwo := strings.Split(two, ".")
+	for i := 0; i < len(idsOne) && i < len(idsTwo); i++ {
+		numOne, errOne := strconv.Atoi(idsOne[i])
+		numTwo, errTwo := strconv.Atoi(idsTwo[i])
+		var c int
+		switch {
+		case errOne == nil && errTwo == nil:
+			c = compareInts(numOne, numTwo)
+		case errOne == nil:
+			c = -1
+		case errTwo == nil:
+			c = 1
+		default:
+			c = strings.Compare(idsOne[i], idsTwo[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(idsOne), len(idsTwo))
+}
+
 // Make returns a SemVer object from a semantic version string
 func Make(input string) (SemVer, error) {
 	var match map[string]string
@@ -217,3 +217,39 @@ func TestBuildMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		one  string
+		two  string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "v1.2.3", 0},
+		{"1.2.3", "2.0.0", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"1.2.3", "1.2.4", -1},
+		{"1.2.3-beta", "1.2.3", -1},
+		{"1.2.3", "1.2.3-beta", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+		{"1.2.3+build.1", "1.2.3+build.2", 0},
+	}
+	for _, c := range cases {
+		got, err := Compare(c.one, c.two)
+		if err != nil {
+			t.Errorf("Function failed. Input: %s, %s, error: %v", c.one, c.two, err)
+		}
+		if got != c.want {
+			t.Errorf("Unexpected function return. Input: %s, %s, Expected: %d, Got: %d", c.one, c.two, c.want, got)
+		}
+	}
+	for _, v := range invalidTestCases {
+		_, err := Compare(v, basicSemverStr)
+		if err == nil {
+			t.Errorf("Function did not fail on invalid input: %s", v)
+		}
+	}
+}